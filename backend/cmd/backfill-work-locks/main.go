@@ -0,0 +1,50 @@
+// main.go
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"log/slog"
+
+	"github.com/CamPlume1/khoury-classroom/internal/config"
+	"github.com/CamPlume1/khoury-classroom/internal/storage/postgres"
+	"github.com/joho/godotenv"
+)
+
+// Opts the works that were already past due when work locking was introduced into locking. The scheduler locks
+// them on its following runs, like any other work past its due date. Safe to run repeatedly.
+func main() {
+	ctx := context.Background()
+
+	// Load environment variables if running locally
+	if isLocal() {
+		if err := godotenv.Load(".env"); err != nil {
+			log.Fatalf("Unable to load environment variables necessary for application: %v", err)
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Unable to load configuration: %v", err)
+	}
+
+	db, err := postgres.New(ctx, cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to establish database connection: %v", err)
+	}
+	defer db.Close(context.Background())
+
+	backfilled, err := db.BackfillWorkLocks(ctx)
+	if err != nil {
+		slog.Error("Failed to backfill work locks", "err", err)
+		os.Exit(1)
+	}
+
+	slog.Info("Backfilled work locks", "works", backfilled)
+}
+
+func isLocal() bool {
+	return os.Getenv("APP_ENVIRONMENT") == "LOCAL"
+}
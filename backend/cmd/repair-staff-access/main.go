@@ -0,0 +1,96 @@
+// main.go
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"log/slog"
+
+	"github.com/CamPlume1/khoury-classroom/internal/config"
+	"github.com/CamPlume1/khoury-classroom/internal/github/installations"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/provisioning"
+	"github.com/CamPlume1/khoury-classroom/internal/storage/postgres"
+	"github.com/joho/godotenv"
+)
+
+// Backfills the staff team of existing classrooms: creates the team where missing, adds the classroom's TAs and
+// professors to it, and grants it access to every base repository and student repository. Safe to run repeatedly.
+func main() {
+	classroomID := flag.Int64("classroom", 0, "only repair the classroom with this ID")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	// Load environment variables if running locally
+	if isLocal() {
+		if err := godotenv.Load(".env"); err != nil {
+			log.Fatalf("Unable to load environment variables necessary for application: %v", err)
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Unable to load configuration: %v", err)
+	}
+
+	db, err := postgres.New(ctx, cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to establish database connection: %v", err)
+	}
+	defer db.Close(context.Background())
+
+	GitHubApp, err := installations.New(&cfg.GitHubAppClient)
+	if err != nil {
+		log.Fatalf("Unable to establish connection with GitHub: %v", err)
+	}
+
+	var classrooms []models.Classroom
+	if *classroomID != 0 {
+		classroom, err := db.GetClassroomByID(ctx, *classroomID)
+		if err != nil {
+			log.Fatalf("Unable to find classroom %d: %v", *classroomID, err)
+		}
+		classrooms = []models.Classroom{classroom}
+	} else {
+		classrooms, err = db.GetClassrooms(ctx)
+		if err != nil {
+			log.Fatalf("Unable to list classrooms: %v", err)
+		}
+	}
+
+	failed := false
+	for _, classroom := range classrooms {
+		appClient, err := GitHubApp.ForOrg(ctx, classroom.OrgName)
+		if err != nil {
+			slog.Error("Failed to repair staff access", "classroom", classroom.ID, "err", err)
+			failed = true
+			continue
+		}
+
+		report, err := provisioning.RepairStaffAccess(ctx, db, appClient, classroom)
+		if err != nil {
+			slog.Error("Failed to repair staff access", "classroom", classroom.ID, "err", err)
+			failed = true
+			continue
+		}
+
+		slog.Info("Repaired staff access", "classroom", classroom.ID, "team", report.TeamName,
+			"members", report.Members, "base_repos", report.BaseRepos, "work_repos", report.WorkRepos)
+		for _, failure := range report.Failures {
+			slog.Error("Failed to repair staff access", "classroom", classroom.ID, "err", failure)
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func isLocal() bool {
+	return os.Getenv("APP_ENVIRONMENT") == "LOCAL"
+}
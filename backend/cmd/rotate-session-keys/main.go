@@ -0,0 +1,51 @@
+// main.go
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"log/slog"
+
+	"github.com/CamPlume1/khoury-classroom/internal/config"
+	"github.com/CamPlume1/khoury-classroom/internal/storage/postgres"
+	"github.com/joho/godotenv"
+)
+
+// Brings every stored session under the active session encryption key: encrypts sessions still holding plaintext
+// tokens, and re-encrypts the data keys of sessions sealed with an older key. Once it succeeds, older keys can be
+// removed from DATABASE_SESSION_KEYS. Safe to run repeatedly.
+func main() {
+	ctx := context.Background()
+
+	// Load environment variables if running locally
+	if isLocal() {
+		if err := godotenv.Load(".env"); err != nil {
+			log.Fatalf("Unable to load environment variables necessary for application: %v", err)
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Unable to load configuration: %v", err)
+	}
+
+	db, err := postgres.New(ctx, cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to establish database connection: %v", err)
+	}
+	defer db.Close(context.Background())
+
+	encrypted, rewrapped, err := db.RotateSessionKeys(ctx)
+	if err != nil {
+		slog.Error("Failed to rotate session keys", "encrypted", encrypted, "rewrapped", rewrapped, "err", err)
+		os.Exit(1)
+	}
+
+	slog.Info("Rotated session keys", "key_id", cfg.Database.SessionKeyID, "encrypted", encrypted, "rewrapped", rewrapped)
+}
+
+func isLocal() bool {
+	return os.Getenv("APP_ENVIRONMENT") == "LOCAL"
+}
@@ -11,7 +11,8 @@ import (
 	"log/slog"
 
 	"github.com/CamPlume1/khoury-classroom/internal/config"
-	"github.com/CamPlume1/khoury-classroom/internal/github/appclient"
+	"github.com/CamPlume1/khoury-classroom/internal/github/installations"
+	"github.com/CamPlume1/khoury-classroom/internal/scheduler"
 	"github.com/CamPlume1/khoury-classroom/internal/server"
 	"github.com/CamPlume1/khoury-classroom/internal/storage/postgres"
 	"github.com/CamPlume1/khoury-classroom/internal/types"
@@ -42,8 +43,17 @@ func main() {
 	}
 	defer db.Close(context.Background())
 
-	// Initialize GitHub App Client
-	GitHubApp, err := appclient.New(&cfg.GitHubAppClient)
+	// Encrypt any sessions stored before session encryption, so no plaintext tokens are left at rest
+	encrypted, err := db.EncryptPlaintextSessions(ctx)
+	if err != nil {
+		log.Fatalf("Failed to encrypt plaintext sessions: %v", err)
+	}
+	if encrypted > 0 {
+		slog.Info("Encrypted plaintext sessions", "count", encrypted)
+	}
+
+	// Initialize the GitHub App, with a client for each of its installations
+	GitHubApp, err := installations.New(&cfg.GitHubAppClient)
 	if err != nil {
 		log.Fatalf("Unable to establish connection with GitHub: %v", err)
 	}
@@ -55,6 +65,11 @@ func main() {
 		UserCfg:   cfg.GitHubUserClient,
 	})
 
+	// Run background jobs (scheduled releases, etc.) until shutdown
+	schedulerCtx, stopScheduler := context.WithCancel(ctx)
+	defer stopScheduler()
+	go scheduler.New(db, GitHubApp).Start(schedulerCtx)
+
 	// Start the server in a separate goroutine
 	go func() {
 		if err := app.Listen(":8080"); err != nil {
@@ -69,6 +84,7 @@ func main() {
 
 	// Begin shutdown process
 	slog.Info("Shutting down server")
+	stopScheduler()
 	if err := app.Shutdown(); err != nil {
 		slog.Error("Failed to shutdown server", "error", err)
 	}
@@ -0,0 +1,56 @@
+-- Feedback comments are soft deleted so that the grade history stays intact
+ALTER TABLE feedback_comment ADD COLUMN IF NOT EXISTS deleted BOOLEAN DEFAULT FALSE NOT NULL;
+
+-- The pull request review comment a feedback comment was posted as, so edits and deletions can follow it to GitHub
+ALTER TABLE feedback_comment ADD COLUMN IF NOT EXISTS github_comment_id BIGINT;
+
+CREATE OR REPLACE VIEW student_works_with_scores AS
+SELECT sw.*,
+    CASE
+        WHEN COUNT(ri.id) = 0 THEN NULL
+        ELSE COALESCE(SUM(ri.point_value), 0) + COALESCE(ao.default_score, 0)
+    END AS manual_feedback_score,
+    NULL AS auto_grader_score -- TODO REPLACE WITH MAXIMUM AUTO GRADER SCORE
+FROM student_works sw
+LEFT JOIN feedback_comment fc ON sw.id = fc.student_work_id AND fc.deleted = FALSE
+LEFT JOIN rubric_items ri ON fc.rubric_item_id = ri.id
+LEFT JOIN assignment_outlines ao ON ao.id = sw.assignment_outline_id
+GROUP BY sw.id, ao.default_score;
+
+-- Late penalty overrides are out of scope: scores have no late penalties to override yet
+DO $$ BEGIN
+    CREATE TYPE GRADE_EVENT_TYPE AS
+    ENUM('FEEDBACK_CREATED', 'FEEDBACK_EDITED', 'FEEDBACK_DELETED', 'REGRADE_RESOLVED', 'GRADES_PUBLISHED');
+EXCEPTION
+    WHEN duplicate_object THEN null;
+END $$;
+
+-- Append-only log of every change to a student work's grade
+CREATE TABLE IF NOT EXISTS grade_events (
+    id SERIAL PRIMARY KEY,
+    student_work_id INTEGER NOT NULL,
+    feedback_comment_id INTEGER,
+    actor_user_id INTEGER NOT NULL,
+    event_type GRADE_EVENT_TYPE NOT NULL,
+    before_score INTEGER,
+    after_score INTEGER,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (student_work_id) REFERENCES student_works(id),
+    FOREIGN KEY (feedback_comment_id) REFERENCES feedback_comment(id),
+    FOREIGN KEY (actor_user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS grade_events_student_work_id_idx ON grade_events (student_work_id);
+
+-- Reject any attempt to rewrite or remove history
+CREATE OR REPLACE FUNCTION reject_grade_event_modification() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'grade_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS grade_events_append_only ON grade_events;
+CREATE TRIGGER grade_events_append_only
+    BEFORE UPDATE OR DELETE ON grade_events
+    FOR EACH ROW EXECUTE FUNCTION reject_grade_event_modification();
@@ -0,0 +1,13 @@
+-- Feedback can now be anchored to a whole file (file_path without file_line)
+-- or to a range of lines (start_line through file_line)
+ALTER TABLE feedback_comment ADD COLUMN IF NOT EXISTS start_line INTEGER;
+
+ALTER TABLE feedback_comment DROP CONSTRAINT IF EXISTS if_file_path_then_file_line;
+
+ALTER TABLE feedback_comment DROP CONSTRAINT IF EXISTS if_file_line_then_file_path;
+ALTER TABLE feedback_comment ADD CONSTRAINT if_file_line_then_file_path
+    CHECK (NOT (file_line IS NOT NULL AND file_path IS NULL));
+
+ALTER TABLE feedback_comment DROP CONSTRAINT IF EXISTS valid_line_range;
+ALTER TABLE feedback_comment ADD CONSTRAINT valid_line_range
+    CHECK (start_line IS NULL OR (file_line IS NOT NULL AND start_line <= file_line));
@@ -0,0 +1,3 @@
+-- Track the commit each feedback comment was anchored against, so its position
+-- can be carried forward (or flagged as outdated) after later pushes
+ALTER TABLE feedback_comment ADD COLUMN IF NOT EXISTS commit_sha VARCHAR(40);
@@ -0,0 +1,30 @@
+-- Group assignment settings: groups are either set up by staff or formed by students with a join code
+ALTER TABLE assignment_outlines ADD COLUMN IF NOT EXISTS max_group_size INTEGER;
+ALTER TABLE assignment_outlines ADD COLUMN IF NOT EXISTS self_formed_groups BOOLEAN DEFAULT FALSE NOT NULL;
+
+CREATE TABLE IF NOT EXISTS assignment_groups (
+    id SERIAL PRIMARY KEY,
+    assignment_outline_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    join_code VARCHAR(255) UNIQUE NOT NULL,
+    student_work_id INTEGER UNIQUE,
+    -- claimed by the first member to accept, so the whole group forks to the same repository
+    repo_name VARCHAR(255) UNIQUE,
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (assignment_outline_id) REFERENCES assignment_outlines(id),
+    FOREIGN KEY (student_work_id) REFERENCES student_works(id),
+    UNIQUE (assignment_outline_id, name)
+);
+
+-- A student can only be in one group per assignment
+CREATE TABLE IF NOT EXISTS assignment_group_members (
+    group_id INTEGER NOT NULL,
+    assignment_outline_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (group_id) REFERENCES assignment_groups(id) ON DELETE CASCADE,
+    FOREIGN KEY (assignment_outline_id) REFERENCES assignment_outlines(id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    PRIMARY KEY (group_id, user_id),
+    UNIQUE (assignment_outline_id, user_id)
+);
@@ -0,0 +1,13 @@
+-- Tracks whether the student team has been given access to an assignment's base repository.
+-- Assignments without a release time are released as soon as their base repository is set up.
+ALTER TABLE assignment_outlines ADD COLUMN IF NOT EXISTS released BOOLEAN DEFAULT FALSE NOT NULL;
+
+UPDATE assignment_outlines
+SET released = TRUE
+WHERE released_at IS NULL OR released_at <= (NOW() AT TIME ZONE 'UTC');
+
+-- Assignments are only released once the push webhook has finished setting up their base repository.
+-- Base repositories that already exist were set up before this was tracked.
+ALTER TABLE assignment_base_repos ADD COLUMN IF NOT EXISTS initialized BOOLEAN DEFAULT FALSE NOT NULL;
+
+UPDATE assignment_base_repos SET initialized = TRUE;
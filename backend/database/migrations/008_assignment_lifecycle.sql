@@ -0,0 +1,2 @@
+-- Archived assignments stay visible but can no longer be accepted or changed
+ALTER TABLE assignment_outlines ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
@@ -0,0 +1,38 @@
+DO $$ BEGIN
+    CREATE TYPE JOB_STATUS AS
+    ENUM('PENDING', 'SUCCEEDED', 'FAILED');
+EXCEPTION
+    WHEN duplicate_object THEN null;
+END $$;
+
+-- The progress of every background job. Each queue keeps the work to do in its own table, whose rows reference
+-- their job here, so every queue shares the same status, retry and error tracking.
+CREATE TABLE IF NOT EXISTS jobs (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(64) NOT NULL,
+    status JOB_STATUS DEFAULT 'PENDING' NOT NULL,
+    attempts INTEGER DEFAULT 0 NOT NULL,
+    error TEXT,
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX IF NOT EXISTS jobs_kind_status_idx ON jobs (kind, status);
+
+-- Creates a pending job, for a queue table to reference from the row describing its work
+CREATE OR REPLACE FUNCTION create_job(job_kind VARCHAR) RETURNS INTEGER AS $$
+    INSERT INTO jobs (kind) VALUES (job_kind) RETURNING id;
+$$ LANGUAGE SQL;
+
+-- One row per repository whose deadline enforcement workflow must be re-rendered after a due date change
+CREATE TABLE IF NOT EXISTS deadline_updates (
+    id SERIAL PRIMARY KEY,
+    job_id INTEGER NOT NULL UNIQUE,
+    assignment_outline_id INTEGER NOT NULL,
+    repo_owner VARCHAR(255) NOT NULL,
+    repo_name VARCHAR(255) NOT NULL,
+    due_date TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (job_id) REFERENCES jobs(id),
+    FOREIGN KEY (assignment_outline_id) REFERENCES assignment_outlines(id)
+);
@@ -0,0 +1,36 @@
+-- A starter code update pushed from an assignment's base repository to the student forks
+CREATE TABLE IF NOT EXISTS starter_code_syncs (
+    id SERIAL PRIMARY KEY,
+    assignment_outline_id INTEGER NOT NULL,
+    base_commit_sha VARCHAR(40) NOT NULL,
+    head_commit_sha VARCHAR(40) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    created_by_user_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (assignment_outline_id) REFERENCES assignment_outlines(id),
+    FOREIGN KEY (created_by_user_id) REFERENCES users(id)
+);
+
+-- Whether a starter code pull request was merged or closed once opened, apart from the job opening it
+DO $$ BEGIN
+    CREATE TYPE STARTER_CODE_PR_STATE AS
+    ENUM('OPEN', 'MERGED', 'CLOSED');
+EXCEPTION
+    WHEN duplicate_object THEN null;
+END $$;
+
+-- The pull request opened in each student fork for a starter code update
+CREATE TABLE IF NOT EXISTS starter_code_sync_prs (
+    id SERIAL PRIMARY KEY,
+    job_id INTEGER NOT NULL UNIQUE,
+    sync_id INTEGER NOT NULL,
+    student_work_id INTEGER NOT NULL,
+    repo_name VARCHAR(255) NOT NULL,
+    pr_number INTEGER,
+    pr_url VARCHAR(255),
+    pr_state STARTER_CODE_PR_STATE,
+    FOREIGN KEY (job_id) REFERENCES jobs(id),
+    FOREIGN KEY (sync_id) REFERENCES starter_code_syncs(id),
+    FOREIGN KEY (student_work_id) REFERENCES student_works(id),
+    UNIQUE (sync_id, student_work_id)
+);
@@ -0,0 +1,8 @@
+-- Branch names and protected paths used when setting up an assignment's repositories
+ALTER TABLE assignment_outlines ADD COLUMN IF NOT EXISTS submission_branch VARCHAR(255) DEFAULT 'main' NOT NULL;
+ALTER TABLE assignment_outlines ADD COLUMN IF NOT EXISTS feedback_branch VARCHAR(255) DEFAULT 'feedback' NOT NULL;
+ALTER TABLE assignment_outlines ADD COLUMN IF NOT EXISTS working_branches TEXT[] DEFAULT '{development}' NOT NULL;
+ALTER TABLE assignment_outlines ADD COLUMN IF NOT EXISTS protected_paths TEXT[] DEFAULT '{}' NOT NULL;
+
+-- The deadline workflow lives on the assignment's submission branch
+ALTER TABLE deadline_updates ADD COLUMN IF NOT EXISTS branch_name VARCHAR(255) DEFAULT 'main' NOT NULL;
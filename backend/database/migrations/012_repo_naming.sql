@@ -0,0 +1,3 @@
+-- Templates used to name the repositories of a classroom's assignments, see utils.RenderRepoName
+ALTER TABLE classrooms ADD COLUMN IF NOT EXISTS base_repo_name_template VARCHAR(255) DEFAULT '{classroom}-{assignment}' NOT NULL;
+ALTER TABLE classrooms ADD COLUMN IF NOT EXISTS work_repo_name_template VARCHAR(255) DEFAULT '{classroom}-{assignment}-{login}' NOT NULL;
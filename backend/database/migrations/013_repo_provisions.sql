@@ -0,0 +1,13 @@
+-- One row per student whose repository is created ahead of time, instead of through an accept link
+CREATE TABLE IF NOT EXISTS repo_provisions (
+    id SERIAL PRIMARY KEY,
+    job_id INTEGER NOT NULL UNIQUE,
+    assignment_outline_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    repo_name VARCHAR(255),
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (job_id) REFERENCES jobs(id),
+    FOREIGN KEY (assignment_outline_id) REFERENCES assignment_outlines(id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    UNIQUE (assignment_outline_id, user_id)
+);
@@ -0,0 +1,31 @@
+-- Student repositories are made read-only once their due date passes
+ALTER TABLE student_works ADD COLUMN IF NOT EXISTS locked_at TIMESTAMP;
+ALTER TABLE student_works ADD COLUMN IF NOT EXISTS locked_commit_sha VARCHAR(40);
+-- set when a professor unlocks a work without giving it a new due date, so it isn't locked again
+ALTER TABLE student_works ADD COLUMN IF NOT EXISTS lock_exempt BOOLEAN DEFAULT FALSE NOT NULL;
+ALTER TABLE student_works ADD COLUMN IF NOT EXISTS lock_attempts INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE student_works ADD COLUMN IF NOT EXISTS lock_error TEXT;
+-- set on works already past due when locking is introduced, which are left unlocked unless given a new due date or
+-- locked all at once by cmd/backfill-work-locks
+ALTER TABLE student_works ADD COLUMN IF NOT EXISTS predates_locking BOOLEAN DEFAULT FALSE NOT NULL;
+
+UPDATE student_works sw
+SET predates_locking = TRUE
+FROM assignment_outlines ao
+WHERE ao.id = sw.assignment_outline_id AND sw.locked_at IS NULL
+    AND COALESCE(sw.unique_due_date, ao.main_due_date) <= (NOW() AT TIME ZONE 'UTC');
+
+-- Recreate the view so it picks up the new student_works columns
+DROP VIEW IF EXISTS student_works_with_scores;
+CREATE VIEW student_works_with_scores AS
+SELECT sw.*,
+    CASE
+        WHEN COUNT(ri.id) = 0 THEN NULL
+        ELSE COALESCE(SUM(ri.point_value), 0) + COALESCE(ao.default_score, 0)
+    END AS manual_feedback_score,
+    NULL AS auto_grader_score -- TODO REPLACE WITH MAXIMUM AUTO GRADER SCORE
+FROM student_works sw
+LEFT JOIN feedback_comment fc ON sw.id = fc.student_work_id AND fc.deleted = FALSE
+LEFT JOIN rubric_items ri ON fc.rubric_item_id = ri.id
+LEFT JOIN assignment_outlines ao ON ao.id = sw.assignment_outline_id
+GROUP BY sw.id, ao.default_score;
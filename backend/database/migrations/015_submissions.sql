@@ -0,0 +1,18 @@
+-- One row per push to a work's submission branch, tagged in the student's repository
+CREATE TABLE IF NOT EXISTS submissions (
+    id SERIAL PRIMARY KEY,
+    student_work_id INTEGER NOT NULL,
+    commit_sha VARCHAR(40) NOT NULL,
+    pushed_by VARCHAR(255),
+    submitted_at TIMESTAMP NOT NULL,
+    due_date TIMESTAMP,
+    on_time BOOLEAN NOT NULL,
+    tag_name VARCHAR(255),
+    -- the submission graders review instead of the latest commit
+    selected_for_grading BOOLEAN DEFAULT FALSE NOT NULL,
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (student_work_id) REFERENCES student_works(id)
+);
+
+CREATE INDEX IF NOT EXISTS submissions_student_work_id_idx ON submissions (student_work_id);
+CREATE UNIQUE INDEX IF NOT EXISTS submissions_selected_for_grading_idx ON submissions (student_work_id) WHERE selected_for_grading;
@@ -0,0 +1,4 @@
+-- Deadlines are reported as a commit status by the app; the generated workflow is only written when opted into.
+-- Existing assignments already have the workflow in their repositories and keep it.
+ALTER TABLE assignment_outlines ADD COLUMN IF NOT EXISTS deadline_workflow BOOLEAN DEFAULT TRUE NOT NULL;
+ALTER TABLE assignment_outlines ALTER COLUMN deadline_workflow SET DEFAULT FALSE;
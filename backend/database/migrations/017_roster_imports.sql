@@ -0,0 +1,29 @@
+-- The ID a classroom member is known by outside of GitMarks (e.g. a university ID), set by roster imports
+ALTER TABLE classroom_membership ADD COLUMN IF NOT EXISTS external_id VARCHAR(255);
+
+-- Roster imports run in the background, one job per row, while the professor polls the import for its progress
+CREATE TABLE IF NOT EXISTS roster_imports (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL,
+    dry_run BOOLEAN DEFAULT FALSE NOT NULL,
+    created_by INTEGER,
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (classroom_id) REFERENCES classrooms(id),
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS roster_imports_classroom_id_idx ON roster_imports (classroom_id);
+
+-- A roster row to import. The row's outcome is saved once its job has run.
+CREATE TABLE IF NOT EXISTS roster_import_rows (
+    id SERIAL PRIMARY KEY,
+    job_id INTEGER NOT NULL UNIQUE,
+    import_id INTEGER NOT NULL,
+    entry JSONB NOT NULL,
+    result JSONB,
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (job_id) REFERENCES jobs(id),
+    FOREIGN KEY (import_id) REFERENCES roster_imports(id)
+);
+
+CREATE INDEX IF NOT EXISTS roster_import_rows_import_id_idx ON roster_import_rows (import_id);
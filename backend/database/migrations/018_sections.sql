@@ -0,0 +1,23 @@
+-- Lab sections within a classroom, each with its own students and TAs
+CREATE TABLE IF NOT EXISTS sections (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    team_id BIGINT,
+    team_name VARCHAR(255),
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (classroom_id) REFERENCES classrooms(id),
+    UNIQUE (classroom_id, name)
+);
+
+-- Students belong to at most one section of a classroom, TAs can lead several
+CREATE TABLE IF NOT EXISTS section_membership (
+    section_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (section_id) REFERENCES sections(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    PRIMARY KEY (section_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS section_membership_user_idx ON section_membership (user_id);
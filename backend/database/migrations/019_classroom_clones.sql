@@ -0,0 +1,31 @@
+-- A classroom created from another one's configuration, e.g. for the next term
+CREATE TABLE IF NOT EXISTS classroom_clones (
+    id SERIAL PRIMARY KEY,
+    source_classroom_id INTEGER NOT NULL,
+    target_classroom_id INTEGER NOT NULL,
+    due_date_offset_days INTEGER DEFAULT 0 NOT NULL,
+    created_by INTEGER,
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (source_classroom_id) REFERENCES classrooms(id),
+    FOREIGN KEY (target_classroom_id) REFERENCES classrooms(id),
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+-- One row per assignment copied into the new classroom, each with a base repository created in the background
+CREATE TABLE IF NOT EXISTS classroom_clone_assignments (
+    id SERIAL PRIMARY KEY,
+    job_id INTEGER NOT NULL UNIQUE,
+    clone_id INTEGER NOT NULL,
+    source_assignment_id INTEGER NOT NULL,
+    target_rubric_id INTEGER,
+    target_assignment_id INTEGER,
+    -- picked before the base repository is created, so a retry picks up the same repository instead of creating another
+    base_repo_name VARCHAR(255),
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (job_id) REFERENCES jobs(id),
+    FOREIGN KEY (clone_id) REFERENCES classroom_clones(id),
+    FOREIGN KEY (source_assignment_id) REFERENCES assignment_outlines(id),
+    FOREIGN KEY (target_rubric_id) REFERENCES rubrics(id),
+    FOREIGN KEY (target_assignment_id) REFERENCES assignment_outlines(id),
+    UNIQUE (clone_id, source_assignment_id)
+);
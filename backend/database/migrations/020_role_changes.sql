@@ -0,0 +1,25 @@
+-- The GitHub team of a classroom's TAs and professors
+ALTER TABLE classrooms ADD COLUMN IF NOT EXISTS staff_team_name VARCHAR(255);
+
+DO $$ BEGIN
+    CREATE TYPE AUDIT_LOG_ACTION AS
+    ENUM('ROLE_CHANGED');
+EXCEPTION
+    WHEN duplicate_object THEN null;
+END $$;
+
+-- Changes made by professors to a classroom's members, with the specifics of each change in details
+CREATE TABLE IF NOT EXISTS audit_log_entries (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL,
+    actor_user_id INTEGER,
+    subject_user_id INTEGER,
+    action AUDIT_LOG_ACTION NOT NULL,
+    details JSONB DEFAULT '{}'::jsonb NOT NULL,
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (classroom_id) REFERENCES classrooms(id),
+    FOREIGN KEY (actor_user_id) REFERENCES users(id),
+    FOREIGN KEY (subject_user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_entries_classroom_idx ON audit_log_entries (classroom_id, created_at);
@@ -0,0 +1,38 @@
+-- Usage limits, revocation and allowlists for classroom and assignment links
+ALTER TABLE classroom_tokens
+    ADD COLUMN IF NOT EXISTS max_uses INTEGER,
+    ADD COLUMN IF NOT EXISTS use_count INTEGER DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS revoked BOOLEAN DEFAULT FALSE NOT NULL,
+    ADD COLUMN IF NOT EXISTS allowed_usernames TEXT[],
+    ADD COLUMN IF NOT EXISTS allowed_email_domains TEXT[],
+    ADD COLUMN IF NOT EXISTS created_by INTEGER REFERENCES users(id);
+
+ALTER TABLE assignment_outline_tokens
+    ADD COLUMN IF NOT EXISTS max_uses INTEGER,
+    ADD COLUMN IF NOT EXISTS use_count INTEGER DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS revoked BOOLEAN DEFAULT FALSE NOT NULL,
+    ADD COLUMN IF NOT EXISTS allowed_usernames TEXT[],
+    ADD COLUMN IF NOT EXISTS allowed_email_domains TEXT[],
+    ADD COLUMN IF NOT EXISTS created_by INTEGER REFERENCES users(id);
+
+-- Every use of a token. Only a user's first use counts towards the token's max uses.
+CREATE TABLE IF NOT EXISTS classroom_token_redemptions (
+    id SERIAL PRIMARY KEY,
+    token VARCHAR(255) NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (token) REFERENCES classroom_tokens(token),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS assignment_token_redemptions (
+    id SERIAL PRIMARY KEY,
+    token VARCHAR(255) NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (token) REFERENCES assignment_outline_tokens(token),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS classroom_token_redemptions_token_idx ON classroom_token_redemptions (token, user_id);
+CREATE INDEX IF NOT EXISTS assignment_token_redemptions_token_idx ON assignment_token_redemptions (token, user_id);
@@ -0,0 +1,28 @@
+-- How a classroom handles users joining through its links. Classrooms without settings let everyone in.
+CREATE TABLE IF NOT EXISTS classroom_join_settings (
+    classroom_id INTEGER PRIMARY KEY,
+    require_approval BOOLEAN DEFAULT FALSE NOT NULL,
+    auto_approve_roster BOOLEAN DEFAULT FALSE NOT NULL,
+    auto_approve_email_domains TEXT[],
+    updated_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (classroom_id) REFERENCES classrooms(id)
+);
+
+-- One row per approved join request, whose org invitation is sent in the background
+CREATE TABLE IF NOT EXISTS join_approvals (
+    id SERIAL PRIMARY KEY,
+    job_id INTEGER NOT NULL UNIQUE,
+    classroom_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    approved_by INTEGER,
+    created_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (job_id) REFERENCES jobs(id),
+    FOREIGN KEY (classroom_id) REFERENCES classrooms(id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (approved_by) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS join_approvals_classroom_idx ON join_approvals (classroom_id, user_id);
+
+-- Whether a classroom member was added through a roster import, which doesn't require an external ID
+ALTER TABLE classroom_membership ADD COLUMN IF NOT EXISTS on_roster BOOLEAN DEFAULT FALSE NOT NULL;
@@ -0,0 +1,5 @@
+-- When the session's access and refresh tokens really expire, as reported by GitHub. NULL means the token doesn't
+-- expire, which is also assumed for sessions created before expiry was tracked.
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS refresh_token_expires_at TIMESTAMP;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC');
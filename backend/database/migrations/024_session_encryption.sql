@@ -0,0 +1,9 @@
+-- Session tokens are encrypted with a data key per session, stored in encrypted_data_key sealed with the master key
+-- named by key_id. Encrypted tokens no longer fit the original column lengths.
+ALTER TABLE sessions ALTER COLUMN access_token TYPE TEXT;
+ALTER TABLE sessions ALTER COLUMN refresh_token TYPE TEXT;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS key_id VARCHAR(64);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS encrypted_data_key TEXT;
+
+-- Existing sessions keep plaintext tokens, with a NULL key_id, until the server encrypts them at startup, as the master
+-- keys never reach the database.
@@ -0,0 +1,9 @@
+-- Students request a regrade of a feedback comment once grades are published, and staff resolve it, possibly changing
+-- the comment's points. The resolution is recorded in the work's grade history.
+ALTER TABLE regrade_requests
+    ADD COLUMN IF NOT EXISTS requested_by INTEGER REFERENCES users(id),
+    ADD COLUMN IF NOT EXISTS resolved_by INTEGER REFERENCES users(id),
+    ADD COLUMN IF NOT EXISTS resolution TEXT,
+    ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS regrade_requests_feedback_comment_id_idx ON regrade_requests (feedback_comment_id);
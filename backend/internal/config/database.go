@@ -1,5 +1,14 @@
 package config
 
+import "time"
+
 type Database struct {
 	URL string `env:"URL"`
+	// master keys for encrypting session tokens, as comma separated id:base64key pairs
+	SessionKeys map[string]string `env:"SESSION_KEYS" envKeyValSeparator:":"`
+	// the master key new session tokens are encrypted with; older keys stay configured until rotated out
+	SessionKeyID string `env:"SESSION_KEY_ID"`
+	// sessions still holding plaintext tokens are encrypted when read until this time (RFC 3339), and rejected after
+	// it; they are rejected right away if it isn't set
+	SessionPlaintextCutoff time.Time `env:"SESSION_PLAINTEXT_CUTOFF"`
 }
@@ -0,0 +1,145 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// Length of master and data keys, for AES-256
+const keySize = 32
+
+// Envelope encryption with AES-GCM. Each record is encrypted with its own random data key, and the data key is
+// stored encrypted with one of the master keys. Master keys are identified by ID, so they can be rotated by
+// re-encrypting the data keys alone.
+type Keyring struct {
+	keys        map[string][]byte
+	activeKeyID string
+}
+
+// A data key as stored alongside the record it encrypts
+type SealedDataKey struct {
+	// the master key the data key is encrypted with
+	KeyID string
+	// the encrypted data key, base64 encoded
+	DataKey string
+}
+
+// Builds a keyring from base64 encoded master keys by ID. New data keys are encrypted with the active key.
+func NewKeyring(keys map[string]string, activeKeyID string) (*Keyring, error) {
+	keyring := &Keyring{keys: make(map[string][]byte), activeKeyID: activeKeyID}
+	for keyID, encoded := range keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("master key %s is not valid base64: %v", keyID, err)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("master key %s must be %d bytes, got %d", keyID, keySize, len(key))
+		}
+		keyring.keys[keyID] = key
+	}
+
+	if _, ok := keyring.keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("active master key %q is not among the configured keys", activeKeyID)
+	}
+	return keyring, nil
+}
+
+func (k *Keyring) ActiveKeyID() string {
+	return k.activeKeyID
+}
+
+// Generates a data key, returning it along with its sealed form to store
+func (k *Keyring) NewDataKey() ([]byte, SealedDataKey, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, SealedDataKey{}, err
+	}
+
+	sealed, err := seal(k.keys[k.activeKeyID], dataKey, []byte(k.activeKeyID))
+	if err != nil {
+		return nil, SealedDataKey{}, err
+	}
+	return dataKey, SealedDataKey{KeyID: k.activeKeyID, DataKey: sealed}, nil
+}
+
+// Decrypts a stored data key with the master key it was sealed with
+func (k *Keyring) OpenDataKey(sealed SealedDataKey) ([]byte, error) {
+	masterKey, ok := k.keys[sealed.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %q is not configured", sealed.KeyID)
+	}
+	return open(masterKey, sealed.DataKey, []byte(sealed.KeyID))
+}
+
+// Seals a stored data key again with the active master key
+func (k *Keyring) Rewrap(sealed SealedDataKey) (SealedDataKey, error) {
+	dataKey, err := k.OpenDataKey(sealed)
+	if err != nil {
+		return SealedDataKey{}, err
+	}
+
+	rewrapped, err := seal(k.keys[k.activeKeyID], dataKey, []byte(k.activeKeyID))
+	if err != nil {
+		return SealedDataKey{}, err
+	}
+	return SealedDataKey{KeyID: k.activeKeyID, DataKey: rewrapped}, nil
+}
+
+// Encrypts a value with a data key. The associated data, e.g. the record and field the value belongs to, must be
+// given again to decrypt it, so encrypted values can't be moved between records.
+func Encrypt(dataKey []byte, plaintext string, associatedData string) (string, error) {
+	return seal(dataKey, []byte(plaintext), []byte(associatedData))
+}
+
+func Decrypt(dataKey []byte, ciphertext string, associatedData string) (string, error) {
+	plaintext, err := open(dataKey, ciphertext, []byte(associatedData))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Encrypts with AES-GCM, returning the nonce and ciphertext base64 encoded
+func seal(key []byte, plaintext []byte, associatedData []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, associatedData)), nil
+}
+
+func open(key []byte, encoded string, associatedData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("encrypted value is too short")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, associatedData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
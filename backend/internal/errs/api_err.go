@@ -36,7 +36,7 @@ func InvalidJSON() APIError {
 }
 
 func NotFound(title string, withKey string, withValue any) APIError {
-	return NewAPIError(http.StatusNotFound, fmt.Errorf("%s with %s='%s' not found", title, withKey, withValue))
+	return NewAPIError(http.StatusNotFound, fmt.Errorf("%s with %s='%v' not found", title, withKey, withValue))
 }
 
 func NotFoundMultiple(title string, params map[string]string) APIError {
@@ -62,6 +62,22 @@ func ExpiredTokenError() APIError {
 	return NewAPIError(http.StatusUnauthorized, errors.New("token expired"))
 }
 
+func RevokedTokenError() APIError {
+	return NewAPIError(http.StatusUnauthorized, errors.New("token revoked"))
+}
+
+func TokenUsedUpError() APIError {
+	return NewAPIError(http.StatusUnauthorized, errors.New("token has reached its maximum number of uses"))
+}
+
+func TokenNotAllowedError() APIError {
+	return NewAPIError(http.StatusForbidden, errors.New("this link is restricted to other users"))
+}
+
+func AppNotInstalledError(orgName string) APIError {
+	return NewAPIError(http.StatusBadRequest, fmt.Errorf("the GitHub App is not installed on %s", orgName))
+}
+
 func InvalidRoleOperation() APIError {
 	return NewAPIError(http.StatusBadRequest, errors.New("invalid role operation attempted"))
 }
@@ -23,3 +23,10 @@ func EmptyResult() DatabaseError {
 		Message: "unexpected: no rows in result",
 	}
 }
+
+// A write the database refused because it would break a rule the schema can't enforce on its own
+func ConstraintViolation() DatabaseError {
+	return DatabaseError{
+		Message: "constraint violation",
+	}
+}
@@ -19,18 +19,18 @@ type AppAPI struct { //app API
 	appTokenSource oauth2.TokenSource
 }
 
-func New(cfg *config.GitHubAppClient) (*AppAPI, error) {
-	// Read private key and IDs from the config
-	privateKey := []byte(cfg.Key)
-	appID := cfg.AppID
-	installationID := cfg.InstallationID
-
-	// Create an Application Token Source
-	appTokenSource, err := githubauth.NewApplicationTokenSource(appID, privateKey)
+// Creates the token source that signs JWTs as the app itself, from the private key and app ID in the config
+func NewAppTokenSource(cfg *config.GitHubAppClient) (oauth2.TokenSource, error) {
+	appTokenSource, err := githubauth.NewApplicationTokenSource(cfg.AppID, []byte(cfg.Key))
 	if err != nil {
 		return nil, fmt.Errorf("error creating application token source: %v", err)
 	}
+	return appTokenSource, nil
+}
 
+// Creates a client acting as one installation of the app. The OAuth2 client reuses each installation token until
+// it expires.
+func NewForInstallation(appTokenSource oauth2.TokenSource, appID int64, installationID int64, webhookSecret string) *AppAPI {
 	// Create an Installation Token Source
 	installationTokenSource := githubauth.NewInstallationTokenSource(installationID, appTokenSource)
 
@@ -43,10 +43,11 @@ func New(cfg *config.GitHubAppClient) (*AppAPI, error) {
 	return &AppAPI{
 		CommonAPI: sharedclient.CommonAPI{
 			Client: githubClient,
+			AppID:  appID,
 		},
-		webhooksecret:  cfg.WebhookSecret,
+		webhooksecret:  webhookSecret,
 		appTokenSource: appTokenSource,
-	}, nil
+	}
 }
 
 func (api *AppAPI) GetWebhookSecret() string {
@@ -84,10 +85,19 @@ func (api *AppAPI) ListInstallations(ctx context.Context) ([]*github.Installatio
 		return nil, fmt.Errorf("error getting github client with JWT auth: %v", err)
 	}
 
-	// List installations
-	installations, _, err := client.Apps.ListInstallations(ctx, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error listing installations: %v", err)
+	// List installations, one page at a time
+	var installations []*github.Installation
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Apps.ListInstallations(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing installations: %v", err)
+		}
+		installations = append(installations, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
 	}
 
 	return installations, nil
@@ -119,8 +129,17 @@ func (api *AppAPI) AssignPermissionToUser(ctx context.Context, ownerName string,
 	return nil
 }
 
-func (api *AppAPI) CreateRepoFromTemplate(ctx context.Context, orgName, templateRepoName, newRepoName string) (*models.AssignmentBaseRepo, error) {
-	endpoint := fmt.Sprintf("/repos/%s/%s/generate", orgName, templateRepoName)
+func (api *AppAPI) RemovePermissionFromUser(ctx context.Context, ownerName string, repoName string, userName string) error {
+	_, err := api.Client.Repositories.RemoveCollaborator(ctx, ownerName, repoName, userName)
+	if err != nil {
+		return fmt.Errorf("error removing permission from user: %v", err)
+	}
+
+	return nil
+}
+
+func (api *AppAPI) CreateRepoFromTemplate(ctx context.Context, templateOwner, templateRepoName, orgName, newRepoName string) (*models.AssignmentBaseRepo, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/generate", templateOwner, templateRepoName)
 
 	// Construct the request
 	req, err := api.Client.NewRequest("POST", endpoint, map[string]interface{}{
@@ -145,3 +164,12 @@ func (api *AppAPI) CreateRepoFromTemplate(ctx context.Context, orgName, template
 		BaseID:        repo.ID,
 	}, nil
 }
+
+func (api *AppAPI) GetRemainingRateLimit(ctx context.Context) (int, error) {
+	limits, _, err := api.Client.RateLimits(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error getting rate limits: %v", err)
+	}
+
+	return limits.GetCore().Remaining, nil
+}
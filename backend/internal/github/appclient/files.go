@@ -7,7 +7,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/CamPlume1/khoury-classroom/internal/errs"
 	"github.com/CamPlume1/khoury-classroom/internal/models"
 	"github.com/google/go-github/github"
 )
@@ -57,30 +56,14 @@ func atoi(s string) int {
 	return n
 }
 
-func (api *AppAPI) GetFileTree(owner string, repo string) ([]models.FileTreeNode, error) {
-	// get default branch
-	ghRepo, err := api.GetRepository(context.Background(), owner, repo)
-	if err != nil {
-		return nil, err
-	}
-	if ghRepo.DefaultBranch == nil {
-		return nil, errs.MissingDefaultBranchError()
-	}
-
-	// Get the reference to the branch
-	ref, _, err := api.Client.Git.GetRef(context.Background(), owner, repo, "heads/"+*ghRepo.DefaultBranch)
-	if err != nil {
-		return nil, fmt.Errorf("error fetching branch ref: %v", err)
-	}
-
-	// Get the commit from the ref
-	commitSHA := ref.Object.GetSHA()
+func (api *AppAPI) GetFileTree(owner string, repo string, commitSHA string) ([]models.FileTreeNode, error) {
+	// Get the commit being viewed
 	commit, _, err := api.Client.Git.GetCommit(context.Background(), owner, repo, commitSHA)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching commit: %v", err)
 	}
 
-	// Get the git tree from latest commit
+	// Get the git tree from the commit
 	treeSHA := commit.Tree.GetSHA()
 	gitTree, _, err := api.Client.Git.GetTree(context.Background(), owner, repo, treeSHA, true)
 	if err != nil {
@@ -16,7 +16,7 @@ type GitHubAppClient interface { // All methods in the APP client
 	// Get the installations of the github app
 	ListInstallations(ctx context.Context) ([]*github.Installation, error)
 
-	GetFileTree(owner string, repo string) ([]models.FileTreeNode, error)
+	GetFileTree(owner string, repo string, commitSHA string) ([]models.FileTreeNode, error)
 	GetFileBlob(owner string, repo string, sha string) ([]byte, error)
 
 	// Add a repository permission to a team
@@ -25,10 +25,36 @@ type GitHubAppClient interface { // All methods in the APP client
 	// Add a repository permission to a user
 	AssignPermissionToUser(ctx context.Context, ownerName string, repoName string, userName string, permission string) error
 
+	// Remove a user's access to a repository
+	RemovePermissionFromUser(ctx context.Context, ownerName string, repoName string, userName string) error
+
 	CreateDeadlineEnforcement(ctx context.Context, deadline *time.Time, orgName, repoName, branchName string) error
 
-	// Create instance of template repository
-	CreateRepoFromTemplate(ctx context.Context, orgName, templateRepoName, newRepoName string) (*models.AssignmentBaseRepo, error)
+	// Report a commit status (error, failure, pending or success) under the given context
+	CreateCommitStatus(ctx context.Context, owner, repo, sha, state, statusContext, description string) error
+
+	// Create instance of template repository in an org, which may differ from the template's owner
+	CreateRepoFromTemplate(ctx context.Context, templateOwner, templateRepoName, orgName, newRepoName string) (*models.AssignmentBaseRepo, error)
+
+	// Get the number of core API requests left before the rate limit resets
+	GetRemainingRateLimit(ctx context.Context) (int, error)
+}
+
+// The app's installations, each acting on the orgs it is installed on
+type GitHubAppInstallations interface {
+	// Get the app client of the installation on an org
+	ForOrg(ctx context.Context, orgName string) (GitHubAppClient, error)
+
+	GetWebhookSecret() string
+
+	// Get the installations of the github app, updating which installation each org uses
+	ListInstallations(ctx context.Context) ([]*github.Installation, error)
+
+	// Record that the app was installed on an org
+	SetInstallation(orgName string, installationID int64)
+
+	// Record that the app was uninstalled from an org, or suspended on it
+	RemoveInstallation(orgName string)
 }
 
 type GitHubUserClient interface { // All methods in the OAUTH client
@@ -40,6 +66,9 @@ type GitHubUserClient interface { // All methods in the OAUTH client
 	// Get the current authenticated user
 	GetCurrentUser(ctx context.Context) (models.GitHubUser, error)
 
+	// Get the verified email addresses of the current authenticated user
+	GetVerifiedEmails(ctx context.Context) ([]string, error)
+
 	// Get the organizations the authenticated user is part of
 	GetUserOrgs(ctx context.Context) ([]models.Organization, error)
 
@@ -48,12 +77,6 @@ type GitHubUserClient interface { // All methods in the OAUTH client
 
 	// Get the membership of the authenticated user to an organization (404 if not a member or invited)
 	GetCurrUserOrgMembership(ctx context.Context, orgName string) (*github.Membership, error)
-
-	// Fork a repository as a student
-	ForkRepository(ctx context.Context, srcOwner, srcRepo, dstOrg, dstRepo string) error
-
-	// Create initial feedback pull request
-	CreateFeedbackPR(ctx context.Context, owner, repo string) error
 }
 
 type GitHubBaseClient interface { //All methods in the SHARED client
@@ -70,17 +93,44 @@ type GitHubBaseClient interface { //All methods in the SHARED client
 	// Create a new branch in a repository
 	CreateBranch(ctx context.Context, owner, repo, baseBranch, newBranchName string) (*github.Reference, error)
 
+	// Get the SHA of the latest commit on a repository's default branch
+	GetHeadCommitSHA(ctx context.Context, owner, repo string) (string, error)
+
+	// Get the SHA of the latest commit on a branch
+	GetBranchHeadSHA(ctx context.Context, owner, repo, branchName string) (string, error)
+
+	// Point a tag at a commit
+	CreateTag(ctx context.Context, owner, repo, tagName, sha string) error
+
+	// Create an annotated tag for a commit
+	CreateAnnotatedTag(ctx context.Context, owner, repo, tagName, sha, message string) error
+
+	// Compare two commits, listing the files changed between them
+	CompareCommits(ctx context.Context, owner, repo, base, head string) (models.CommitComparison, error)
+
+	// Get the git file modes of every file in a commit's tree, by path
+	GetFileModes(ctx context.Context, owner, repo, commitSHA string) (map[string]string, error)
+
 	// Get the details of a pull request
 	GetPullRequest(ctx context.Context, owner string, repo string, pullNumber int) (*github.PullRequest, error)
 
 	// Get the diff of a pull request
 	GetPullRequestDiff(ctx context.Context, owner string, repo string, pullNumber int) (string, error)
 
+	// Find the pull request opened from a branch of the repository, nil if there is none
+	GetPullRequestByHead(ctx context.Context, owner string, repo string, headBranch string) (*github.PullRequest, error)
+
 	// Create a new pull request in a repository
 	CreatePullRequest(ctx context.Context, owner string, repo string, baseBranch string, headBranch string, title string, body string) (*github.PullRequest, error)
 
 	// Create a new pull request review
-	CreatePRReview(ctx context.Context, owner string, repo string, body string, comments []models.PRReviewComment) (*github.PullRequestComment, error)
+	CreatePRReview(ctx context.Context, owner string, repo string, commitID string, body string, comments []models.PRReviewComment) (*github.PullRequestComment, []int64, error)
+
+	// Change the body of a pull request review comment
+	EditPRReviewComment(ctx context.Context, owner string, repo string, commentID int64, body string) error
+
+	// Delete a pull request review comment
+	DeletePRReviewComment(ctx context.Context, owner string, repo string, commentID int64) error
 
 	// Get the details of a user
 	GetUser(ctx context.Context, userName string) (*github.User, error)
@@ -109,6 +159,12 @@ type GitHubBaseClient interface { //All methods in the SHARED client
 	// Get the details of a repository
 	GetRepository(ctx context.Context, owner string, repoName string) (*github.Repository, error)
 
+	// Archive a repository, making it read-only
+	ArchiveRepository(ctx context.Context, owner string, repoName string) error
+
+	// Delete a repository, succeeding if it is already gone
+	DeleteRepository(ctx context.Context, owner string, repoName string) error
+
 	// Get the details of a team
 	GetTeam(ctx context.Context, teamID int64) (*github.Team, error)
 
@@ -137,16 +193,28 @@ type GitHubBaseClient interface { //All methods in the SHARED client
 	RemoveRepoFromTeam(ctx context.Context, org, teamSlug, owner, repo string) error
 
 	//Create push ruleset to protect .github folders
-	CreatePushRuleset(ctx context.Context, orgName, repoName string) error
+	CreatePushRuleset(ctx context.Context, orgName, repoName string, restrictedPaths []string) error
 
 	//Create rulesets to protect corresponding branches
-	CreateBranchRuleset(ctx context.Context, orgName, repoName string) error
+	CreateBranchRuleset(ctx context.Context, orgName, repoName, submissionBranch, feedbackBranch string) error
+
+	// Let the app bypass every ruleset of a repository, so it can commit changes the rulesets block
+	AllowAppRulesetBypass(ctx context.Context, orgName, repoName string) error
 
 	//Creates PR enforcements
-	CreatePREnforcement(ctx context.Context, orgName, repoName, branchName string) error
+	CreatePREnforcement(ctx context.Context, orgName, repoName, branchName, feedbackBranch string) error
 
 	// Create empty commit (will create a diff that allows feedback PR to be created)
-	CreateEmptyCommit(ctx context.Context, owner, repo string) error
+	CreateEmptyCommit(ctx context.Context, owner, repo, branchName string) error
+
+	// Commit a set of file changes on a new branch from a commit, keeping the branch if it already exists
+	CommitFileChanges(ctx context.Context, owner, repo, parentCommitSHA, newBranchName, message string, changes []models.FileChange) error
+
+	// Fork a repository into an organization
+	ForkRepository(ctx context.Context, srcOwner, srcRepo, dstOrg, dstRepo string) error
+
+	// Create initial feedback pull request
+	CreateFeedbackPR(ctx context.Context, owner, repo, headBranch, baseBranch string) error
 
 	// Check if a fork has finished initializing
 	CheckForkIsReady(ctx context.Context, repo *github.Repository) bool
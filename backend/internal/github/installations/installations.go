@@ -0,0 +1,156 @@
+package installations
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CamPlume1/khoury-classroom/internal/config"
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/github"
+	"github.com/CamPlume1/khoury-classroom/internal/github/appclient"
+	gh "github.com/google/go-github/github"
+	"golang.org/x/oauth2"
+)
+
+// How long to wait before listing the app's installations again when an org isn't found among them
+const refreshInterval = time.Minute
+
+// Hands out an app client per installation of the GitHub App, so the classrooms of each org are managed through
+// that org's own installation. Orgs are mapped to installations by listing the app's installations, and the mapping
+// is kept current by installation webhooks. Clients are cached per installation and reuse their installation token
+// until it expires.
+type Installations struct {
+	appTokenSource oauth2.TokenSource
+	appID          int64
+	webhookSecret  string
+	// acts as the app itself, to list its installations
+	app *appclient.AppAPI
+
+	mu          sync.Mutex
+	orgs        map[string]int64
+	clients     map[int64]*appclient.AppAPI
+	refreshedAt time.Time
+}
+
+func New(cfg *config.GitHubAppClient) (*Installations, error) {
+	appTokenSource, err := appclient.NewAppTokenSource(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Installations{
+		appTokenSource: appTokenSource,
+		appID:          cfg.AppID,
+		webhookSecret:  cfg.WebhookSecret,
+		// listing installations authenticates with the app's JWT, so this client belongs to no installation
+		app:     appclient.NewForInstallation(appTokenSource, cfg.AppID, 0, cfg.WebhookSecret),
+		orgs:    make(map[string]int64),
+		clients: make(map[int64]*appclient.AppAPI),
+	}, nil
+}
+
+// Gets the client of the installation on an org, listing the app's installations if the org isn't known yet. Orgs
+// the app isn't installed on get an AppNotInstalledError.
+func (i *Installations) ForOrg(ctx context.Context, orgName string) (github.GitHubAppClient, error) {
+	if installationID, ok := i.installationID(orgName); ok {
+		return i.client(installationID), nil
+	}
+
+	if i.startRefresh() {
+		_, err := i.ListInstallations(ctx)
+		if err != nil {
+			i.cancelRefresh()
+			return nil, errs.GithubAPIError(err)
+		}
+		if installationID, ok := i.installationID(orgName); ok {
+			return i.client(installationID), nil
+		}
+	}
+
+	return nil, errs.AppNotInstalledError(orgName)
+}
+
+func (i *Installations) GetWebhookSecret() string {
+	return i.webhookSecret
+}
+
+// Lists the app's installations and maps each org to its installation
+func (i *Installations) ListInstallations(ctx context.Context) ([]*gh.Installation, error) {
+	installations, err := i.app.ListInstallations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orgs := make(map[string]int64)
+	for _, installation := range installations {
+		if installation.GetAccount().GetLogin() != "" {
+			orgs[orgKey(installation.GetAccount().GetLogin())] = installation.GetID()
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.orgs = orgs
+	i.refreshedAt = time.Now()
+	return installations, nil
+}
+
+func (i *Installations) SetInstallation(orgName string, installationID int64) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.orgs[orgKey(orgName)] = installationID
+}
+
+func (i *Installations) RemoveInstallation(orgName string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	installationID, ok := i.orgs[orgKey(orgName)]
+	if !ok {
+		return
+	}
+	delete(i.orgs, orgKey(orgName))
+	delete(i.clients, installationID)
+}
+
+func (i *Installations) installationID(orgName string) (int64, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	installationID, ok := i.orgs[orgKey(orgName)]
+	return installationID, ok
+}
+
+// Claims the next refresh of the installations, unless one happened recently
+func (i *Installations) startRefresh() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if time.Since(i.refreshedAt) < refreshInterval {
+		return false
+	}
+	i.refreshedAt = time.Now()
+	return true
+}
+
+// Lets the next lookup of an unknown org list the installations again, after a listing failed
+func (i *Installations) cancelRefresh() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.refreshedAt = time.Time{}
+}
+
+func (i *Installations) client(installationID int64) *appclient.AppAPI {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	client, ok := i.clients[installationID]
+	if !ok {
+		client = appclient.NewForInstallation(i.appTokenSource, i.appID, installationID, i.webhookSecret)
+		i.clients[installationID] = client
+	}
+	return client
+}
+
+// Org logins are case-insensitive on GitHub
+func orgKey(orgName string) string {
+	return strings.ToLower(orgName)
+}
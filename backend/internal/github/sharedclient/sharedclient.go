@@ -3,7 +3,9 @@ package sharedclient
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"net/http"
 	"time"
 	"github.com/CamPlume1/khoury-classroom/internal/errs"
 	"github.com/CamPlume1/khoury-classroom/internal/models"
@@ -12,6 +14,8 @@ import (
 
 type CommonAPI struct {
 	Client *github.Client
+	// the GitHub App this client acts as, 0 for users. Rulesets it creates let the app bypass them.
+	AppID int64
 }
 
 func (api *CommonAPI) Ping(ctx context.Context) (string, error) {
@@ -94,6 +98,105 @@ func (api *CommonAPI) CreateBranch(ctx context.Context, owner, repo, baseBranch,
 	return &branch, nil
 }
 
+// Get the SHA of the latest commit on a repository's default branch
+func (api *CommonAPI) GetHeadCommitSHA(ctx context.Context, owner, repo string) (string, error) {
+	ghRepo, err := api.GetRepository(ctx, owner, repo)
+	if err != nil {
+		return "", err
+	}
+	if ghRepo.DefaultBranch == nil {
+		return "", errs.MissingDefaultBranchError()
+	}
+
+	ref, err := api.getBranchHead(ctx, owner, repo, *ghRepo.DefaultBranch)
+	if err != nil {
+		return "", err
+	}
+
+	return ref.Object.GetSHA(), nil
+}
+
+func (api *CommonAPI) GetBranchHeadSHA(ctx context.Context, owner, repo, branchName string) (string, error) {
+	ref, err := api.getBranchHead(ctx, owner, repo, branchName)
+	if err != nil {
+		return "", err
+	}
+
+	return ref.Object.GetSHA(), nil
+}
+
+// Points a lightweight tag at a commit, moving the tag if it already exists
+func (api *CommonAPI) CreateTag(ctx context.Context, owner, repo, tagName, sha string) error {
+	_, resp, err := api.Client.Git.CreateRef(ctx, owner, repo, &github.Reference{
+		Ref:    github.String("refs/tags/" + tagName),
+		Object: &github.GitObject{SHA: github.String(sha)},
+	})
+	if err == nil {
+		return nil
+	}
+	if resp == nil || resp.StatusCode != http.StatusUnprocessableEntity {
+		return err
+	}
+
+	// the tag exists already, e.g. from an earlier attempt
+	_, _, err = api.Client.Git.UpdateRef(ctx, owner, repo, &github.Reference{
+		Ref:    github.String("tags/" + tagName),
+		Object: &github.GitObject{SHA: github.String(sha)},
+	}, true)
+	return err
+}
+
+// Creates an annotated tag object for a commit and a tag ref pointing at it
+func (api *CommonAPI) CreateAnnotatedTag(ctx context.Context, owner, repo, tagName, sha, message string) error {
+	tag, _, err := api.Client.Git.CreateTag(ctx, owner, repo, &github.Tag{
+		Tag:     github.String(tagName),
+		Message: github.String(message),
+		Object:  &github.GitObject{Type: github.String("commit"), SHA: github.String(sha)},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _, err = api.Client.Git.CreateRef(ctx, owner, repo, &github.Reference{
+		Ref:    github.String("refs/tags/" + tagName),
+		Object: &github.GitObject{SHA: tag.SHA},
+	})
+	return err
+}
+
+// List the files changed between two commits
+func (api *CommonAPI) CompareCommits(ctx context.Context, owner, repo, base, head string) (models.CommitComparison, error) {
+	endpoint := fmt.Sprintf("/repos/%s/%s/compare/%s...%s", owner, repo, base, head)
+
+	req, err := api.Client.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return models.CommitComparison{}, fmt.Errorf("error creating request: %v", err)
+	}
+
+	var comparison models.CommitComparison
+	_, err = api.Client.Do(ctx, req, &comparison)
+	if err != nil {
+		return models.CommitComparison{}, fmt.Errorf("error comparing commits: %v", err)
+	}
+
+	return comparison, nil
+}
+
+func (api *CommonAPI) GetFileModes(ctx context.Context, owner, repo, commitSHA string) (map[string]string, error) {
+	tree, _, err := api.Client.Git.GetTree(ctx, owner, repo, commitSHA, true)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching tree: %v", err)
+	}
+
+	modes := make(map[string]string)
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "blob" {
+			modes[entry.GetPath()] = entry.GetMode()
+		}
+	}
+	return modes, nil
+}
+
 func (api *CommonAPI) GetPullRequest(ctx context.Context, owner string, repo string, pullNumber int) (*github.PullRequest, error) {
 	pr, _, err := api.Client.PullRequests.Get(ctx, owner, repo, pullNumber)
 
@@ -109,6 +212,20 @@ func (api *CommonAPI) GetPullRequestDiff(ctx context.Context, owner string, repo
 	return diff, nil
 }
 
+func (api *CommonAPI) GetPullRequestByHead(ctx context.Context, owner string, repo string, headBranch string) (*github.PullRequest, error) {
+	prs, _, err := api.Client.PullRequests.List(ctx, owner, repo, &github.PullRequestListOptions{
+		State: "all",
+		Head:  owner + ":" + headBranch,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pull requests: %v", err)
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	return prs[0], nil
+}
+
 func (api *CommonAPI) CreatePullRequest(ctx context.Context, owner string, repo string, baseBranch string, headBranch string, title string, body string) (*github.PullRequest, error) {
 	newPR := &github.NewPullRequest{
 		Title: github.String(title),      // Title of the PR
@@ -124,20 +241,77 @@ func (api *CommonAPI) CreatePullRequest(ctx context.Context, owner string, repo
 	return pr, nil
 }
 
-func (api *CommonAPI) CreatePRReview(ctx context.Context, owner string, repo string, body string, comments []models.PRReviewComment) (*github.PullRequestComment, error) {
+// A line or multi-line review comment as expected by GitHub's review API
+type reviewComment struct {
+	Path      *string `json:"path"`
+	Body      string  `json:"body"`
+	Line      *int    `json:"line"`
+	Side      string  `json:"side"`
+	StartLine *int    `json:"start_line,omitempty"`
+	StartSide string  `json:"start_side,omitempty"`
+}
+
+// Posts a review on the student's pull request. Returns the review and the ID of the review comment each of the
+// given comments was posted as, in the same order.
+func (api *CommonAPI) CreatePRReview(ctx context.Context, owner string, repo string, commitID string, body string, comments []models.PRReviewComment) (*github.PullRequestComment, []int64, error) {
 	// hardcode PR number to 1 since we auto create the PR on fork
 	endpoint := fmt.Sprintf("/repos/%s/%s/pulls/%d/reviews", owner, repo, 1)
 
+	// Split out whole-file comments, reviews only accept comments anchored to lines
+	commentIDs := make([]int64, len(comments))
+	lineComments := []reviewComment{}
+	var lineIndexes []int
+	var fileComments []models.PRReviewComment
+	var fileIndexes []int
+	for i, comment := range comments {
+		if comment.IsFileLevel() {
+			fileComments = append(fileComments, comment)
+			fileIndexes = append(fileIndexes, i)
+			continue
+		}
+
+		formatted := reviewComment{
+			Path: comment.Path,
+			Body: comment.Body,
+			Line: comment.Line,
+			Side: "RIGHT",
+		}
+		if comment.IsMultiLine() {
+			formatted.StartLine = comment.StartLine
+			formatted.StartSide = "RIGHT"
+		}
+		lineComments = append(lineComments, formatted)
+		lineIndexes = append(lineIndexes, i)
+	}
+
+	// Post whole-file comments first, they can still be deleted if the review fails
+	fileCommentIDs, err := api.createFileComments(ctx, owner, repo, commitID, fileComments)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, commentID := range fileCommentIDs {
+		commentIDs[fileIndexes[i]] = commentID
+	}
+
+	// GitHub rejects a review with neither a body nor comments
+	if len(lineComments) == 0 && body == "" {
+		return nil, commentIDs, nil
+	}
+
 	// Create a new POST request
 	requestBody := map[string]interface{}{
 		"event":    "COMMENT",
 		"body":     body,
-		"comments": comments,
+		"comments": lineComments,
+	}
+	// review a specific commit of the PR rather than its head
+	if commitID != "" {
+		requestBody["commit_id"] = commitID
 	}
 
 	req, err := api.Client.NewRequest("POST", endpoint, requestBody)
 	if err != nil {
-		return nil, fmt.Errorf("error creating request: %v", err)
+		return nil, nil, errors.Join(fmt.Errorf("error creating request: %v", err), api.deleteReviewComments(ctx, owner, repo, fileCommentIDs))
 	}
 
 	// Response container
@@ -146,10 +320,107 @@ func (api *CommonAPI) CreatePRReview(ctx context.Context, owner string, repo str
 	// Make the API call
 	_, err = api.Client.Do(ctx, req, &cmt)
 	if err != nil {
-		return nil, fmt.Errorf("error creating PR comment: %v", err)
+		return nil, nil, errors.Join(fmt.Errorf("error creating PR comment: %v", err), api.deleteReviewComments(ctx, owner, repo, fileCommentIDs))
+	}
+
+	if len(lineComments) == 0 {
+		return &cmt, commentIDs, nil
+	}
+
+	// The review's comments are listed in the order they were submitted
+	reviewComments, err := api.listReviewComments(ctx, owner, repo, cmt.GetID())
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, reviewComment := range reviewComments {
+		if i < len(lineIndexes) {
+			commentIDs[lineIndexes[i]] = reviewComment.GetID()
+		}
+	}
+
+	return &cmt, commentIDs, nil
+}
+
+// Lists every comment of a pull request review
+func (api *CommonAPI) listReviewComments(ctx context.Context, owner string, repo string, reviewID int64) ([]*github.PullRequestComment, error) {
+	var allComments []*github.PullRequestComment
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		comments, resp, err := api.Client.PullRequests.ListReviewComments(ctx, owner, repo, 1, reviewID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing review comments: %v", err)
+		}
+		allComments = append(allComments, comments...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return allComments, nil
+}
+
+// Changes the body of a pull request review comment
+func (api *CommonAPI) EditPRReviewComment(ctx context.Context, owner string, repo string, commentID int64, body string) error {
+	_, _, err := api.Client.PullRequests.EditComment(ctx, owner, repo, commentID, &github.PullRequestComment{Body: &body})
+	if err != nil {
+		return fmt.Errorf("error editing review comment %d: %v", commentID, err)
 	}
+	return nil
+}
 
-	return &cmt, nil
+// Deletes a pull request review comment
+func (api *CommonAPI) DeletePRReviewComment(ctx context.Context, owner string, repo string, commentID int64) error {
+	return api.deleteReviewComments(ctx, owner, repo, []int64{commentID})
+}
+
+// Whole-file comments must be created one at a time against the reviewed commit, or the PR's head commit when none
+// is given. If any fails, those already created are deleted so none are left behind.
+func (api *CommonAPI) createFileComments(ctx context.Context, owner string, repo string, commitID string, comments []models.PRReviewComment) ([]int64, error) {
+	if len(comments) == 0 {
+		return nil, nil
+	}
+
+	if commitID == "" {
+		pr, err := api.GetPullRequest(ctx, owner, repo, 1)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching pull request: %v", err)
+		}
+		commitID = pr.GetHead().GetSHA()
+	}
+
+	endpoint := fmt.Sprintf("/repos/%s/%s/pulls/%d/comments", owner, repo, 1)
+	var createdIDs []int64
+	for _, comment := range comments {
+		req, err := api.Client.NewRequest("POST", endpoint, map[string]interface{}{
+			"body":         comment.Body,
+			"path":         comment.Path,
+			"commit_id":    commitID,
+			"subject_type": "file",
+		})
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("error creating request: %v", err), api.deleteReviewComments(ctx, owner, repo, createdIDs))
+		}
+
+		var created github.PullRequestComment
+		_, err = api.Client.Do(ctx, req, &created)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("error creating file comment: %v", err), api.deleteReviewComments(ctx, owner, repo, createdIDs))
+		}
+		createdIDs = append(createdIDs, created.GetID())
+	}
+
+	return createdIDs, nil
+}
+
+// Deletes PR review comments to undo a partly posted review
+func (api *CommonAPI) deleteReviewComments(ctx context.Context, owner string, repo string, commentIDs []int64) error {
+	for _, commentID := range commentIDs {
+		_, err := api.Client.PullRequests.DeleteComment(ctx, owner, repo, commentID)
+		if err != nil {
+			return fmt.Errorf("error deleting review comment %d: %v", commentID, err)
+		}
+	}
+	return nil
 }
 
 func (api *CommonAPI) GetUserOrgs(ctx context.Context) ([]models.Organization, error) {
@@ -186,9 +457,45 @@ func (api *CommonAPI) GetUser(ctx context.Context, userName string) (*github.Use
 
 
 
-func (api *CommonAPI) createRuleSet(ctx context.Context, ruleset interface{}, orgName, repoName string) error {
+// The app as a ruleset bypass actor, so it can still commit workflow changes such as new deadlines
+func (api *CommonAPI) appBypassActor() map[string]interface{} {
+	return map[string]interface{}{
+		"actor_id":    api.AppID,
+		"actor_type":  "Integration",
+		"bypass_mode": "always",
+	}
+}
+
+// Creates a ruleset on a repository, or updates the repository's ruleset of the same name if it already has one
+func (api *CommonAPI) createRuleSet(ctx context.Context, ruleset map[string]interface{}, orgName, repoName string) error {
+	if api.AppID != 0 {
+		ruleset["bypass_actors"] = []interface{}{api.appBypassActor()}
+	}
+
 	endpoint := fmt.Sprintf("/repos/%s/%s/rulesets", orgName, repoName)
-	req, err := api.Client.NewRequest("POST", endpoint, ruleset)
+	req, err := api.Client.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	var existing []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	_, err = api.Client.Do(ctx, req, &existing)
+	if err != nil {
+		return err
+	}
+
+	method := "POST"
+	for _, rs := range existing {
+		if rs.Name == ruleset["name"] {
+			method = "PUT"
+			endpoint = fmt.Sprintf("%s/%d", endpoint, rs.ID)
+			break
+		}
+	}
+
+	req, err = api.Client.NewRequest(method, endpoint, ruleset)
 	if err != nil {
 		return err
 	}
@@ -196,8 +503,74 @@ func (api *CommonAPI) createRuleSet(ctx context.Context, ruleset interface{}, or
 	return err
 }
 
+// Lets the app bypass every ruleset of a repository, for repositories whose rulesets were created without it
+func (api *CommonAPI) AllowAppRulesetBypass(ctx context.Context, orgName, repoName string) error {
+	if api.AppID == 0 {
+		return errors.New("only an app client can be allowed to bypass rulesets")
+	}
+
+	endpoint := fmt.Sprintf("/repos/%s/%s/rulesets", orgName, repoName)
+	req, err := api.Client.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	var rulesets []struct {
+		ID int64 `json:"id"`
+	}
+	_, err = api.Client.Do(ctx, req, &rulesets)
+	if err != nil {
+		return err
+	}
+
+	for _, rs := range rulesets {
+		rulesetEndpoint := fmt.Sprintf("%s/%d", endpoint, rs.ID)
+		req, err = api.Client.NewRequest("GET", rulesetEndpoint, nil)
+		if err != nil {
+			return err
+		}
+		var ruleset struct {
+			BypassActors []map[string]interface{} `json:"bypass_actors"`
+		}
+		_, err = api.Client.Do(ctx, req, &ruleset)
+		if err != nil {
+			return err
+		}
+
+		bypassing := false
+		for _, actor := range ruleset.BypassActors {
+			actorID, _ := actor["actor_id"].(float64)
+			if actor["actor_type"] == "Integration" && int64(actorID) == api.AppID {
+				bypassing = true
+				break
+			}
+		}
+		if bypassing {
+			continue
+		}
+
+		// only the bypass actors are sent, the rest of the ruleset is left as it is
+		bypassActors := make([]interface{}, 0, len(ruleset.BypassActors)+1)
+		for _, actor := range ruleset.BypassActors {
+			bypassActors = append(bypassActors, actor)
+		}
+		bypassActors = append(bypassActors, api.appBypassActor())
+		req, err = api.Client.NewRequest("PUT", rulesetEndpoint, map[string]interface{}{
+			"bypass_actors": bypassActors,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = api.Client.Do(ctx, req, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 //Given a repo name and org name, create a push ruleset to protect the .github directory
-func (api *CommonAPI) CreatePushRuleset(ctx context.Context, orgName, repoName string) error {
+func (api *CommonAPI) CreatePushRuleset(ctx context.Context, orgName, repoName string, restrictedPaths []string) error {
 	body := map[string]interface{}{
 		"name":        "Restrict .github Directory Edits: Preserves Submission Deadline",
 		"target":      "push",
@@ -206,7 +579,7 @@ func (api *CommonAPI) CreatePushRuleset(ctx context.Context, orgName, repoName s
 			map[string]interface{}{
 				"type": "file_path_restriction",
 				"parameters": map[string]interface{}{
-					"restricted_file_paths": []string{".github/**/*"},
+					"restricted_file_paths": restrictedPaths,
 				},
 			},
 		},
@@ -216,7 +589,7 @@ func (api *CommonAPI) CreatePushRuleset(ctx context.Context, orgName, repoName s
 
 
 
-func (api *CommonAPI) CreateBranchRuleset(ctx context.Context,  orgName, repoName string) error {
+func (api *CommonAPI) CreateBranchRuleset(ctx context.Context, orgName, repoName, submissionBranch, feedbackBranch string) error {
 	body := map[string]interface{}{
 		"name": "Feedback and Main Branch Protedtion: PR Enforcement",
 		"target": "branch",
@@ -224,7 +597,7 @@ func (api *CommonAPI) CreateBranchRuleset(ctx context.Context,  orgName, repoNam
 		"conditions": map[string]interface{}{
 			"ref_name": map[string]interface{}{
 				"exclude": []interface{}{},
-				"include": []interface{}{"refs/heads/feedback", "~DEFAULT_BRANCH"},
+				"include": []interface{}{"refs/heads/" + feedbackBranch, "refs/heads/" + submissionBranch, "~DEFAULT_BRANCH"},
 			},
 		},
 		"rules": []interface{}{
@@ -296,42 +669,45 @@ func (api *CommonAPI) CreateDeadlineEnforcement(ctx context.Context, deadline *t
 
 }
 
+// Reports a commit status, replacing any earlier status with the same context on that commit
+func (api *CommonAPI) CreateCommitStatus(ctx context.Context, owner, repo, sha, state, statusContext, description string) error {
+	_, _, err := api.Client.Repositories.CreateStatus(ctx, owner, repo, sha, &github.RepoStatus{
+		State:       github.String(state),
+		Context:     github.String(statusContext),
+		Description: github.String(description),
+	})
+	return err
+}
 
 func actionWithDeadline(deadline *time.Time) string {
-	// yyyy, mm, dd, hh, mm, ss
-	  var scriptString = `name: deadline-enforcement
-  on:
-	pull_request:
-	  types: [opened, reopened, edited, synchronize]
-  
-  jobs:
-	deadline-enforcement:
-	  runs-on: ubuntu-latest
-	  steps:
-		- name: Execute python deadline check
-		  run: |
-			  python -c "
-			  from datetime import datetime, timezone
-			  import sys
-			  
-			  def check_date():
-				  target_date = datetime(%d, %d, %d, %d, %d, %d, tzinfo=timezone.utc)
-				  current_date = datetime.now(timezone.utc)
-				  if current_date > target_date:
-					  sys.exit(1)
-				  else:
-					  sys.exit(0)
-  
-			  if __name__ == '__main__':
-				  check_date()
-			  "
-  `
-  
-	  return fmt.Sprintf(scriptString, deadline.Year(), deadline.Month(), deadline.Day(), deadline.Hour(), deadline.Minute(), deadline.Second())
-  }
-  
-  
-  func targetBranchProtectionAction() string {
+	// yyyy, mm, dd, hh, mm, ss, always rendered in UTC
+	var scriptString = `name: deadline-enforcement
+on:
+  pull_request:
+    types: [opened, reopened, edited, synchronize]
+
+jobs:
+  deadline-enforcement:
+    runs-on: ubuntu-latest
+    steps:
+      - name: Execute python deadline check
+        run: |
+          python -c "
+          from datetime import datetime, timezone
+          import sys
+
+          target_date = datetime(%d, %d, %d, %d, %d, %d, tzinfo=timezone.utc)
+          if datetime.now(timezone.utc) > target_date:
+              sys.exit(1)
+          "
+`
+
+	utc := deadline.UTC()
+	return fmt.Sprintf(scriptString, utc.Year(), utc.Month(), utc.Day(), utc.Hour(), utc.Minute(), utc.Second())
+}
+
+
+  func targetBranchProtectionAction(feedbackBranch string) string {
 	  var actionString = `name: check-pr-target-branch
   
   on:
@@ -344,22 +720,22 @@ func actionWithDeadline(deadline *time.Time) string {
 	  steps:
 		- name: Check PR destination branch
 		  run: |
-			if [[ "${{ github.event.pull_request.base.ref }}" == "feedback" ]]; then
-			  echo "Error: Pull requests targeting the '' branch are not allowed"
+			if [[ "${{ github.event.pull_request.base.ref }}" == "%s" ]]; then
+			  echo "Error: Pull requests targeting the '%s' branch are not allowed"
 			  exit 1
 			fi`
-			return actionString
+			return fmt.Sprintf(actionString, feedbackBranch, feedbackBranch)
   }
 
 
-func (api *CommonAPI) CreatePREnforcement(ctx context.Context, orgName, repoName, branchName string) error {
+func (api *CommonAPI) CreatePREnforcement(ctx context.Context, orgName, repoName, branchName, feedbackBranch string) error {
 
 	addition := models.RepositoryAddition{
 		FilePath: ".github/workflows/check-pr-target-branch.yml",
 		RepoName: repoName,
 		OwnerName: orgName,
 		DestinationBranch: branchName,
-		Content: targetBranchProtectionAction(),
+		Content: targetBranchProtectionAction(feedbackBranch),
 		CommitMessage: "Deadline enforcement GH action files",
 	}
 	return api.EditRepository(ctx, &addition)
@@ -375,6 +751,14 @@ func (api *CommonAPI) EditRepository(ctx context.Context, addition *models.Repos
 		"content": encodedContent,
 		"branch": addition.DestinationBranch,
 	}
+
+	// Overwriting an existing file requires its current blob SHA
+	existingFile, _, _, err := api.Client.Repositories.GetContents(ctx, addition.OwnerName, addition.RepoName, addition.FilePath,
+		&github.RepositoryContentGetOptions{Ref: addition.DestinationBranch})
+	if err == nil && existingFile != nil && existingFile.SHA != nil {
+		body["sha"] = *existingFile.SHA
+	}
+
 	req, err := api.Client.NewRequest("PUT", endpoint, body)
 	if err != nil {
 		return err
@@ -501,6 +885,20 @@ func (api *CommonAPI) GetRepository(ctx context.Context, owner string, repoName
 	return repo, err
 }
 
+func (api *CommonAPI) ArchiveRepository(ctx context.Context, owner string, repoName string) error {
+	_, _, err := api.Client.Repositories.Edit(ctx, owner, repoName, &github.Repository{Archived: github.Bool(true)})
+	return err
+}
+
+// Deletes a repository, succeeding if it is already gone
+func (api *CommonAPI) DeleteRepository(ctx context.Context, owner string, repoName string) error {
+	resp, err := api.Client.Repositories.Delete(ctx, owner, repoName)
+	if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
 func (api *CommonAPI) UpdateTeamRepoPermissions(ctx context.Context, org, teamSlug, owner, repo, permission string) error {
 	endpoint := fmt.Sprintf("/orgs/%s/teams/%s/repos/%s/%s", org, teamSlug, owner, repo)
 
@@ -603,17 +1001,9 @@ func (api *CommonAPI) GetTeamMembers(ctx context.Context, teamID int64) ([]*gith
 	return members, err
 }
 
-func (api *CommonAPI) CreateEmptyCommit(ctx context.Context, owner, repo string) error {
-	ghRepo, err := api.GetRepository(ctx, owner, repo)
-	if err != nil {
-		return err
-	}
-	if ghRepo.DefaultBranch == nil {
-		return errs.MissingDefaultBranchError()
-	}
-
-	// Get the reference to main branch
-	ref, _, err := api.Client.Git.GetRef(context.Background(), owner, repo, "heads/"+*ghRepo.DefaultBranch)
+func (api *CommonAPI) CreateEmptyCommit(ctx context.Context, owner, repo, branchName string) error {
+	// Get the reference to the branch
+	ref, _, err := api.Client.Git.GetRef(context.Background(), owner, repo, "heads/"+branchName)
 	if err != nil {
 		return err
 	}
@@ -653,8 +1043,8 @@ func (api *CommonAPI) CreateEmptyCommit(ctx context.Context, owner, repo string)
 		return errs.GithubAPIError(err)
 	}
 
-	// update main to point to the new empty commit
-	endpoint = fmt.Sprintf("/repos/%s/%s/git/refs/heads/%s", owner, repo, *ghRepo.DefaultBranch)
+	// update the branch to point to the new empty commit
+	endpoint = fmt.Sprintf("/repos/%s/%s/git/refs/heads/%s", owner, repo, branchName)
 	req, err = api.Client.NewRequest("PATCH", endpoint, map[string]interface{}{
 		"sha":   commit.SHA,
 		"force": true,
@@ -670,6 +1060,79 @@ func (api *CommonAPI) CreateEmptyCommit(ctx context.Context, owner, repo string)
 	return nil
 }
 
+// Commits a set of file changes on a new branch created from the given commit. A branch left by an earlier
+// attempt is kept, as it is only created once its commit is complete.
+func (api *CommonAPI) CommitFileChanges(ctx context.Context, owner, repo, parentCommitSHA, newBranchName, message string, changes []models.FileChange) error {
+	_, resp, err := api.Client.Git.GetRef(ctx, owner, repo, "refs/heads/"+newBranchName)
+	if err == nil {
+		return nil
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("error fetching branch: %v", err)
+	}
+
+	parentCommit, _, err := api.Client.Git.GetCommit(ctx, owner, repo, parentCommitSHA)
+	if err != nil {
+		return err
+	}
+
+	// upload the new contents, a nil sha removes the file from the tree
+	treeEntries := []map[string]interface{}{}
+	for _, change := range changes {
+		var blobSHA *string
+		if !change.Deleted {
+			blob, _, err := api.Client.Git.CreateBlob(ctx, owner, repo, &github.Blob{
+				Content:  github.String(base64.StdEncoding.EncodeToString(change.Content)),
+				Encoding: github.String("base64"),
+			})
+			if err != nil {
+				return err
+			}
+			blobSHA = blob.SHA
+		}
+
+		mode := change.Mode
+		if mode == "" {
+			mode = "100644"
+		}
+		treeEntries = append(treeEntries, map[string]interface{}{
+			"path": change.Path,
+			"mode": mode,
+			"type": "blob",
+			"sha":  blobSHA,
+		})
+	}
+
+	endpoint := fmt.Sprintf("/repos/%s/%s/git/trees", owner, repo)
+	req, err := api.Client.NewRequest("POST", endpoint, map[string]interface{}{
+		"base_tree": parentCommit.Tree.GetSHA(),
+		"tree":      treeEntries,
+	})
+	if err != nil {
+		return err
+	}
+	var tree github.Tree
+	_, err = api.Client.Do(ctx, req, &tree)
+	if err != nil {
+		return err
+	}
+
+	commit, _, err := api.Client.Git.CreateCommit(ctx, owner, repo, &github.Commit{
+		Message: github.String(message),
+		Tree:    &tree,
+		Parents: []github.Commit{{SHA: github.String(parentCommitSHA)}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _, err = api.Client.Git.CreateRef(ctx, owner, repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + newBranchName),
+		Object: &github.GitObject{SHA: commit.SHA},
+	})
+	return err
+}
+
 func (api *CommonAPI) CheckForkIsReady(ctx context.Context, repo *github.Repository) bool {
 	if repo == nil || repo.Parent.FullName == nil {
 		return false
@@ -736,4 +1199,62 @@ func (api *CommonAPI) EnableActions(ctx context.Context, ownerName, repoName str
 	_, err = api.Client.Do(ctx, req, nil)
 	
 	return err
-}
\ No newline at end of file
+}
+
+func (api *CommonAPI) ForkRepository(ctx context.Context, srcOwner, srcRepo, dstOrg, dstRepo string) error {
+	endpoint := fmt.Sprintf("/repos/%s/%s/forks", srcOwner, srcRepo)
+
+	//Initialize post request
+	req, err := api.Client.NewRequest("POST", endpoint, map[string]interface{}{
+		"organization": dstOrg,
+		"name":         dstRepo,
+	})
+	if err != nil {
+		return errs.GithubAPIError(err)
+	}
+
+	// Make the API call
+	response, err := api.Client.Do(ctx, req, nil)
+	if err != nil && response.StatusCode != 202 {
+		return errs.GithubAPIError(err)
+	}
+
+	return nil
+}
+
+// Opens the pull request from the submission branch into the feedback branch that grading happens on, unless
+// it was already opened
+func (api *CommonAPI) CreateFeedbackPR(ctx context.Context, owner, repo, headBranch, baseBranch string) error {
+	existing, _, err := api.Client.PullRequests.List(ctx, owner, repo, &github.PullRequestListOptions{
+		State: "all",
+		Head:  owner + ":" + headBranch,
+		Base:  baseBranch,
+	})
+	if err != nil {
+		return errs.GithubAPIError(err)
+	}
+	if len(existing) > 0 {
+		return nil
+	}
+
+	endpoint := fmt.Sprintf("/repos/%s/%s/pulls", owner, repo)
+
+	//Initialize post request
+	req, err := api.Client.NewRequest("POST", endpoint, map[string]interface{}{
+		"title": "Feedback",
+		"head":  owner + ":" + headBranch,
+		"base":  baseBranch,
+		"body":  "Grade and feedback will be left here. Do not close or modify this PR!<br>Once graded, reply with a justification to any deduction you would like to dispute.",
+	})
+	if err != nil {
+		return errs.GithubAPIError(err)
+	}
+
+	// Make the API call
+	_, err = api.Client.Do(ctx, req, nil)
+	if err != nil {
+		return errs.GithubAPIError(err)
+	}
+
+	return nil
+}
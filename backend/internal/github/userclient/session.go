@@ -0,0 +1,61 @@
+package userclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"golang.org/x/oauth2"
+)
+
+// Where sessions are refreshed, one request per user at a time as GitHub rotates the refresh token on every refresh
+type SessionStore interface {
+	RefreshSession(ctx context.Context, gitHubUserID int64, refresh func(models.Session) (models.Session, error)) (models.Session, error)
+}
+
+// Serves a session's access token, refreshing it through the refresh token once it expires and saving the rotated
+// tokens back to the session
+type sessionTokenSource struct {
+	oAuthCfg     *oauth2.Config
+	store        SessionStore
+	githubUserID int64
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+func newSessionTokenSource(oAuthCfg *oauth2.Config, store SessionStore, session *models.Session) *sessionTokenSource {
+	token := session.CreateToken()
+	return &sessionTokenSource{
+		oAuthCfg:     oAuthCfg,
+		store:        store,
+		githubUserID: session.GitHubUserID,
+		token:        &token,
+	}
+}
+
+func (ts *sessionTokenSource) Token() (*oauth2.Token, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.token.Valid() {
+		return ts.token, nil
+	}
+
+	// The store passes the latest tokens, which another request may have refreshed while this one waited
+	ctx := context.Background()
+	session, err := ts.store.RefreshSession(ctx, ts.githubUserID, func(session models.Session) (models.Session, error) {
+		current := session.CreateToken()
+		token, err := ts.oAuthCfg.TokenSource(ctx, &current).Token()
+		if err != nil {
+			return models.Session{}, err
+		}
+		return models.NewSession(ts.githubUserID, token), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token := session.CreateToken()
+	ts.token = &token
+	return ts.token, nil
+}
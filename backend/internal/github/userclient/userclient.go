@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/CamPlume1/khoury-classroom/internal/config"
-	"github.com/CamPlume1/khoury-classroom/internal/errs"
 	"github.com/CamPlume1/khoury-classroom/internal/github/sharedclient"
 	"github.com/CamPlume1/khoury-classroom/internal/models"
 	"github.com/google/go-github/github"
@@ -28,9 +27,20 @@ func NewFromCode(cfg *config.GitHubUserClient, code string) (*UserAPI, error) {
 	return newFromToken(oAuthCfg, token)
 }
 
-func NewFromSession(oAuthCfg *oauth2.Config, session *models.Session) (*UserAPI, error) {
-	token := session.CreateToken()
-	return newFromToken(oAuthCfg, &token)
+// Creates a client from a stored session. Expired access tokens are refreshed and the new tokens saved to the store.
+func NewFromSession(oAuthCfg *oauth2.Config, store SessionStore, session *models.Session) (*UserAPI, error) {
+	tokenSource := newSessionTokenSource(oAuthCfg, store, session)
+	httpClient := oauth2.NewClient(context.Background(), tokenSource)
+
+	// Create the GitHub client
+	githubClient := github.NewClient(httpClient)
+
+	return &UserAPI{
+		CommonAPI: sharedclient.CommonAPI{
+			Client: githubClient,
+		},
+		Token: tokenSource.token,
+	}, nil
 }
 
 func newFromToken(oAuthCfg *oauth2.Config, token *oauth2.Token) (*UserAPI, error) {
@@ -66,6 +76,21 @@ func (api *UserAPI) GetCurrentUser(ctx context.Context) (models.GitHubUser, erro
 	return user, nil
 }
 
+func (api *UserAPI) GetVerifiedEmails(ctx context.Context) ([]string, error) {
+	emails, _, err := api.Client.Users.ListEmails(ctx, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching emails: %v", err)
+	}
+
+	verified := []string{}
+	for _, email := range emails {
+		if email.GetVerified() {
+			verified = append(verified, email.GetEmail())
+		}
+	}
+	return verified, nil
+}
+
 func (api *UserAPI) GetOrg(ctx context.Context, orgName string) (*models.Organization, error) {
 	// Construct the URL for the org endpoint
 	endpoint := fmt.Sprintf("/orgs/%s", orgName)
@@ -128,56 +153,3 @@ func (api *UserAPI) AcceptOrgInvitation(ctx context.Context, orgName string) err
 
 	return nil
 }
-
-func (api *UserAPI) ForkRepository(ctx context.Context, srcOwner, srcRepo, dstOrg, dstRepo string) error {
-	endpoint := fmt.Sprintf("/repos/%s/%s/forks", srcOwner, srcRepo)
-
-	//Initialize post request
-	req, err := api.Client.NewRequest("POST", endpoint, map[string]interface{}{
-		"organization": dstOrg,
-		"name":         dstRepo,
-	})
-	if err != nil {
-		return errs.GithubAPIError(err)
-	}
-
-	// Make the API call
-	response, err := api.Client.Do(ctx, req, nil)
-	if err != nil && response.StatusCode != 202 {
-		return errs.GithubAPIError(err)
-	}
-
-	return nil
-}
-
-func (api *UserAPI) CreateFeedbackPR(ctx context.Context, owner, repo string) error {
-	// get default branch
-	ghRepo, err := api.GetRepository(ctx, owner, repo)
-	if err != nil {
-		return err
-	}
-	if ghRepo.DefaultBranch == nil {
-		return errs.MissingDefaultBranchError()
-	}
-
-	endpoint := fmt.Sprintf("/repos/%s/%s/pulls", owner, repo)
-
-	//Initialize post request
-	req, err := api.Client.NewRequest("POST", endpoint, map[string]interface{}{
-		"title": "Feedback",
-		"head":  owner + ":" + *ghRepo.DefaultBranch,
-		"base":  "feedback",
-		"body":  "Grade and feedback will be left here. Do not close or modify this PR!<br>Once graded, reply with a justification to any deduction you would like to dispute.",
-	})
-	if err != nil {
-		return errs.GithubAPIError(err)
-	}
-
-	// Make the API call
-	_, err = api.Client.Do(ctx, req, nil)
-	if err != nil {
-		return errs.GithubAPIError(err)
-	}
-
-	return nil
-}
@@ -4,7 +4,6 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/CamPlume1/khoury-classroom/internal/errs"
 	"github.com/CamPlume1/khoury-classroom/internal/github/userclient"
@@ -100,37 +99,18 @@ func (service *AuthService) Login() fiber.Handler {
 		// Convert user.ID to string
 		userID := strconv.FormatInt(currentGitHubUser.ID, 10)
 
-		timeToExp := 24 * time.Hour
-		expirationTime := time.Now().Add(timeToExp)
-
-		err = service.store.CreateSession(c.Context(), models.Session{
-			GitHubUserID: currentGitHubUser.ID,
-			AccessToken:  client.Token.AccessToken,
-			TokenType:    client.Token.TokenType,
-			RefreshToken: client.Token.RefreshToken,
-			ExpiresIn:    int64(timeToExp.Seconds()),
-		})
-
+		// Keep the real expiry of the tokens, so they can be refreshed when they run out
+		err = service.store.CreateSession(c.Context(), models.NewSession(currentGitHubUser.ID, client.Token))
 		if err != nil {
 			return errs.InternalServerError()
 		}
 
 		// Generate JWT token
-		jwtToken, err := middleware.GenerateJWT(userID, expirationTime, service.userCfg.JWTSecret)
+		err = middleware.SetJWTCookie(c, userID, service.userCfg.JWTSecret)
 		if err != nil {
 			return errs.InternalServerError()
 		}
 
-		c.Cookie(&fiber.Cookie{
-			Name:     "jwt_cookie",
-			Value:    jwtToken,
-			Expires:  expirationTime,
-			HTTPOnly: true,
-			Secure:   true,
-			SameSite: "None",
-			Path:     "/",
-		})
-
 		return c.Status(fiber.StatusOK).JSON("Successfully logged in")
 	}
 }
@@ -25,10 +25,10 @@ func baseRouter(app *fiber.App, params types.Params) fiber.Router {
 	baseRouter.Post("/login", service.Login())
 
 	// Get the current authenticated user
-	baseRouter.Get("/user", middleware.Protected(params.UserCfg.JWTSecret), service.GetCurrentUser())
+	baseRouter.Get("/user", middleware.Protected(params.Store, params.UserCfg.JWTSecret), service.GetCurrentUser())
 
 	// Logout the current authenticated user
-	baseRouter.Post("/logout", middleware.Protected(params.UserCfg.JWTSecret), service.Logout())
+	baseRouter.Post("/logout", middleware.Protected(params.Store, params.UserCfg.JWTSecret), service.Logout())
 
 	return baseRouter
 }
@@ -2,14 +2,15 @@ package assignments
 
 import (
 	"errors"
+	"log"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/CamPlume1/khoury-classroom/internal/errs"
 	"github.com/CamPlume1/khoury-classroom/internal/middleware"
 	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/provisioning"
 	"github.com/CamPlume1/khoury-classroom/internal/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/jackc/pgx/v5"
@@ -23,11 +24,27 @@ func (s *AssignmentService) getAssignments() fiber.Handler {
 			return errs.BadRequest(err)
 		}
 
+		classroomUser, err := s.RequireAtLeastRole(c, classroomID, models.Student)
+		if err != nil {
+			return err
+		}
+
 		assignments, err := s.store.GetAssignmentsInClassroom(c.Context(), classroomID)
 		if err != nil {
 			return errs.InternalServerError()
 		}
 
+		// Students can't see assignments until they are released
+		if classroomUser.Role == models.Student {
+			releasedAssignments := []models.AssignmentOutline{}
+			for _, assignment := range assignments {
+				if assignment.Released {
+					releasedAssignments = append(releasedAssignments, assignment)
+				}
+			}
+			assignments = releasedAssignments
+		}
+
 		return c.Status(http.StatusOK).JSON(fiber.Map{
 			"assignment_outlines": assignments,
 		})
@@ -47,6 +64,14 @@ func (s *AssignmentService) getAssignment() fiber.Handler {
 			return errs.InternalServerError()
 		}
 
+		classroomUser, err := s.RequireAtLeastRole(c, assignment.ClassroomID, models.Student)
+		if err != nil {
+			return err
+		}
+		if classroomUser.Role == models.Student && !assignment.Released {
+			return errs.NotFound("assignment", "id", assignmentID)
+		}
+
 		return c.Status(http.StatusOK).JSON(fiber.Map{
 			"assignment_outline": assignment,
 		})
@@ -85,6 +110,11 @@ func (s *AssignmentService) createAssignment() fiber.Handler {
 			return err
 		}
 
+		assignmentData.RepoLayout = assignmentData.RepoLayout.WithDefaults()
+		if err := assignmentData.RepoLayout.Validate(); err != nil {
+			return errs.BadRequest(err)
+		}
+
 		// Error if assignment already exists
 		existingAssignment, err := s.store.GetAssignmentByNameAndClassroomID(c.Context(), assignmentData.Name, assignmentData.ClassroomID)
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
@@ -103,10 +133,17 @@ func (s *AssignmentService) createAssignment() fiber.Handler {
 		if err != nil {
 			return err
 		}
+		appClient, err := s.GetAppClient(c.Context(), classroom.OrgName)
+		if err != nil {
+			return err
+		}
 
 		// Create base repository and store locally
-		baseRepoName := generateSlugCase(classroom.OrgName, classroom.Name, assignmentData.Name)
-		baseRepo, err := s.appClient.CreateRepoFromTemplate(c.Context(), classroom.OrgName, template.TemplateRepoName, baseRepoName)
+		baseRepoName, err := provisioning.BaseRepoName(c.Context(), s.store, appClient, classroom, assignmentData.Name)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+		baseRepo, err := appClient.CreateRepoFromTemplate(c.Context(), template.TemplateRepoOwner, template.TemplateRepoName, classroom.OrgName, baseRepoName)
 		if err != nil {
 			return err
 		}
@@ -115,6 +152,12 @@ func (s *AssignmentService) createAssignment() fiber.Handler {
 			return err
 		}
 
+		// Staff can maintain the base repository; the repair command backfills access if this fails
+		err = provisioning.GrantStaffAccess(c.Context(), s.store, appClient, classroom, baseRepo.BaseRepoName, provisioning.StaffBaseRepoPermission)
+		if err != nil {
+			log.Default().Println("Warning: Failed to give staff access to base repository, ", err)
+		}
+
 		// Store assignment locally
 		assignmentData.BaseRepoID = baseRepo.BaseID
 		createdAssignment, err := s.store.CreateAssignment(c.Context(), assignmentData)
@@ -142,8 +185,19 @@ func (s *AssignmentService) generateAssignmentToken() fiber.Handler {
 			return errs.BadRequest(err)
 		}
 
+		assignment, err := s.store.GetAssignmentByID(c.Context(), assignmentID)
+		if err != nil {
+			return errs.NotFound("assignment", "id", assignmentID)
+		}
+
+		// Only allow staff to share assignment links
+		creator, err := s.RequireAtLeastRole(c, assignment.ClassroomID, models.TA)
+		if err != nil {
+			return err
+		}
+
 		// if the link is permenant, use the existing permanent token
-		if body.Duration == nil {
+		if body.Duration == nil && !body.HasLimits() {
 			assignmentToken, err := s.store.GetPermanentAssignmentTokenByAssignmentID(c.Context(), assignmentID)
 			if err == nil {
 				return c.Status(http.StatusOK).JSON(fiber.Map{"token": assignmentToken.Token})
@@ -158,9 +212,14 @@ func (s *AssignmentService) generateAssignmentToken() fiber.Handler {
 		tokenData := models.AssignmentToken{
 			AssignmentID: assignmentID,
 			BaseToken: models.BaseToken{
-				Token: token,
+				Token:     token,
+				CreatedBy: creator.ID,
 			},
 		}
+		err = body.Apply(&tokenData.BaseToken)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
 
 		// Set ExpiresAt only if Duration is provided
 		if body.Duration != nil {
@@ -187,10 +246,21 @@ func (s *AssignmentService) useAssignmentToken() fiber.Handler {
 		}
 
 		// Get assignment using the token
-		assignment, err := s.store.GetAssignmentByToken(c.Context(), token)
+		assignmentToken, err := s.store.GetAssignmentToken(c.Context(), token)
 		if err != nil {
 			return errs.BadRequest(errors.New("invalid token"))
 		}
+		err = assignmentToken.Usable(time.Now())
+		if err != nil {
+			return err
+		}
+		assignment, err := s.store.GetAssignmentByID(c.Context(), assignmentToken.AssignmentID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+		if !assignment.Released {
+			return errs.BadRequest(errors.New("assignment has not been released yet"))
+		}
 
 		// Get assignment base repository
 		baseRepo, err := s.store.GetBaseRepoByID(c.Context(), assignment.BaseRepoID)
@@ -215,106 +285,97 @@ func (s *AssignmentService) useAssignmentToken() fiber.Handler {
 		if err != nil {
 			return errs.InternalServerError()
 		}
+		appClient, err := s.GetAppClient(c.Context(), classroom.OrgName)
+		if err != nil {
+			return err
+		}
 
 		// Check if user has at least student role
-		_, err = s.RequireAtLeastRole(c, classroom.ID, models.Student)
+		classroomUser, err := s.RequireAtLeastRole(c, classroom.ID, models.Student)
 		if err != nil {
 			return err
 		}
 
-		// Check if fork already exists
-		forkName := generateSlugCase(classroom.Name, assignment.Name, user.Login)
-		studentWorkRepo, _ := client.GetRepository(c.Context(), classroom.OrgName, forkName)
-		if studentWorkRepo != nil {
-			// Ensure student team is removed
-			err = client.RemoveRepoFromTeam(c.Context(), classroom.OrgName, *classroom.StudentTeamName, classroom.OrgName, forkName)
-			if err != nil {
-				return errs.GithubAPIError(err)
-			}
-
-			// Get the student work
-			studentWork, err := s.store.GetWorkByRepoName(c.Context(), *studentWorkRepo.Name)
-			if err != nil {
-				// Recover from the case where the student work does not exist, but the repo does exist
-				studentWork, err = s.store.CreateStudentWork(c.Context(), assignment.ID, user.ID, forkName, models.WorkStateAccepted, assignment.MainDueDate)
-				if err != nil {
-					return errs.InternalServerError()
-				}
-			} else if studentWork.WorkState == models.WorkStateNotAccepted {
-				// Recover from the case where the workstate is out of sync with the github state (repo exists but student work is not accepted)
-				updatedStudentWork := studentWork
-				updatedStudentWork.WorkState = models.WorkStateAccepted
-				_, err = s.store.UpdateStudentWork(c.Context(), updatedStudentWork)
-				if err != nil {
-					return errs.InternalServerError()
-				}
-			}
-
-			return c.Status(http.StatusOK).JSON(fiber.Map{
-				"message":  "Assignment already accepted",
-				"repo_url": studentWorkRepo.HTMLURL,
-			})
+		err = middleware.CheckTokenAllowlist(c.Context(), client, assignmentToken.BaseToken, user.Login)
+		if err != nil {
+			return err
 		}
 
-		// Otherwise generate fork
-		err = client.ForkRepository(c.Context(),
-			baseRepo.BaseRepoOwner,
-			baseRepo.BaseRepoName,
-			classroom.OrgName,
-			forkName)
+		// Count the use, checking again in case the token ran out or was revoked in the meantime
+		_, err = s.store.RedeemAssignmentToken(c.Context(), token, *classroomUser.ID)
 		if err != nil {
-			return errs.GithubAPIError(err)
+			return err
 		}
 
-		// TODO HERE: insert repo name to fork_queue table, quit
-		// listen for webhook repository creation: if repo name in fork_queue table, create PR and remove read rights etc
-
-		// Wait to perform actions on the fork until it is finished initializing
-		initialDelay := 1 * time.Second
-		maxDelay := 30 * time.Second
-
-		for {
-			studentWorkRepo, _ = client.GetRepository(c.Context(), classroom.OrgName, forkName)
-			if studentWorkRepo != nil {
-				if client.CheckForkIsReady(c.Context(), studentWorkRepo) {
-					break
+		// Group assignments share one fork per group, named after the group
+		var group *models.AssignmentGroup
+		var forkName string
+		if assignment.GroupAssignment {
+			userGroup, err := s.store.GetUserAssignmentGroup(c.Context(), int64(assignment.ID), *classroomUser.ID)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return errs.BadRequest(errors.New("join or create a group before accepting this assignment"))
 				}
+				return errs.InternalServerError()
+			}
+			group = &userGroup
+			if group.RepoName != nil {
+				forkName = *group.RepoName
 			}
+		} else {
+			existingWork, err := s.store.GetUserWorkByAssignment(c.Context(), int64(assignment.ID), *classroomUser.ID)
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return errs.InternalServerError()
+			}
+			forkName = existingWork.RepoName
+		}
 
-			if initialDelay > maxDelay {
-				return errs.GithubAPIError(errors.New("fork unsuccessful, please try again later"))
+		// Name the fork the first time the assignment is accepted, reusing the stored name afterwards
+		if forkName == "" {
+			owner := user.Login
+			if group != nil {
+				owner = group.Name
+			}
+			forkName, err = provisioning.WorkRepoName(c.Context(), s.store, appClient, classroom, assignment, baseRepo, owner)
+			if err != nil {
+				return errs.InternalServerError()
 			}
 
-			time.Sleep(initialDelay)
-			initialDelay *= 2
+			// the first group member to accept names the fork, the others share it
+			if group != nil {
+				forkName, err = s.store.ClaimAssignmentGroupRepoName(c.Context(), int64(group.ID), forkName)
+				if err != nil {
+					return errs.InternalServerError()
+				}
+			}
 		}
 
-		//KHO-239
-		err = client.CreateBranchRuleset(c.Context(), classroom.OrgName, forkName)
+		studentWork, studentWorkRepo, alreadyAccepted, err := provisioning.SetUpWorkRepo(c.Context(), s.store, client,
+			classroom, assignment, baseRepo, forkName, user.ID)
 		if err != nil {
-			return errs.CriticalGithubError()
+			return err
 		}
 
-		// Remove student team's access to forked repo
-		err = client.RemoveRepoFromTeam(c.Context(), classroom.OrgName, *classroom.StudentTeamName, classroom.OrgName, *studentWorkRepo.Name)
+		// Staff can read the fork; the repair command backfills access if this fails
+		err = provisioning.GrantStaffAccess(c.Context(), s.store, appClient, classroom, studentWork.RepoName, provisioning.StaffWorkRepoPermission)
 		if err != nil {
-			return errs.GithubAPIError(err)
+			log.Default().Println("Warning: Failed to give staff access to student repository, ", err)
 		}
 
-		// Create initial feedback pull request
-		err = client.CreateFeedbackPR(c.Context(), classroom.OrgName, *studentWorkRepo.Name)
-		if err != nil {
-			return errs.GithubAPIError(err)
+		// Give the rest of the group access to the fork
+		if group != nil {
+			err = s.syncGroupRepo(c.Context(), classroom, *group, studentWork)
+			if err != nil {
+				return err
+			}
 		}
 
-		// Insert into DB
-		_, err = s.store.CreateStudentWork(c.Context(), assignment.ID, user.ID, forkName, models.WorkStateAccepted, assignment.MainDueDate)
-		if err != nil {
-			return err
+		if alreadyAccepted {
+			return c.Status(http.StatusOK).JSON(fiber.Map{
+				"message":  "Assignment already accepted",
+				"repo_url": studentWorkRepo.HTMLURL,
+			})
 		}
-		
-
-		// TODO Here: Enable Github Actions on student repo.
 
 		// Instead of getting the repository immediately, construct the expected URL
 		return c.Status(http.StatusOK).JSON(fiber.Map{
@@ -348,18 +409,6 @@ func (s *AssignmentService) checkAssignmentName() fiber.Handler {
 	}
 }
 
-// KHO-209
-// TODO: Choose naming pattern once we have a full assignment flow. Stub for now
-// TODO: ensure duplicates are impossible, just append an incrementing -x to name in that case
-func generateSlugCase(parts ...string) string {
-	var processedParts []string
-	for _, part := range parts {
-		processedParts = append(processedParts, strings.ReplaceAll(strings.ToLower(part), " ", "-"))
-	}
-
-	return strings.Join(processedParts, "-")
-}
-
 // Updates an existing assignment.
 func (s *AssignmentService) updateAssignmentRubric() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -424,8 +473,13 @@ func (s *AssignmentService) getGradedCount() fiber.Handler {
 			return errs.BadRequest(err)
 		}
 
+		sectionID, err := utils.ParseOptionalID(c.Query("section_id"))
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
 		// Query work status counts
-		counts, err := s.store.CountWorksByState(c.Context(), int(assignmentID))
+		counts, err := s.store.CountWorksByState(c.Context(), int(assignmentID), sectionID)
 		if err != nil {
 			return errs.InternalServerError()
 		}
@@ -463,8 +517,13 @@ func (s *AssignmentService) getAssignmentStatus() fiber.Handler {
 			return errs.BadRequest(err)
 		}
 
+		sectionID, err := utils.ParseOptionalID(c.Query("section_id"))
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
 		// Query work status counts
-		counts, err := s.store.CountWorksByState(c.Context(), int(assignmentID))
+		counts, err := s.store.CountWorksByState(c.Context(), int(assignmentID), sectionID)
 		if err != nil {
 			return errs.InternalServerError()
 		}
@@ -478,7 +537,7 @@ func (s *AssignmentService) getAssignmentStatus() fiber.Handler {
 			counts[models.WorkStateGradePublished]
 
 		// Determine unaccepted works using number of students in classroom
-		numStudents, err := s.store.GetNumberOfStudentsInClassroom(c.Context(), classroomID)
+		numStudents, err := s.store.GetNumberOfStudentsInClassroom(c.Context(), classroomID, sectionID)
 		if err != nil {
 			return errs.InternalServerError()
 		}
@@ -504,7 +563,12 @@ func (s *AssignmentService) GetFirstCommitDate() fiber.Handler {
 			return errs.BadRequest(err)
 		}
 
-		earliestCommitDate, err := s.store.GetEarliestCommitDate(c.Context(), assignmentID)
+		sectionID, err := utils.ParseOptionalID(c.Query("section_id"))
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		earliestCommitDate, err := s.store.GetEarliestCommitDate(c.Context(), assignmentID, sectionID)
 		if err != nil {
 			return err
 		}
@@ -523,7 +587,12 @@ func (s *AssignmentService) GetCommitCount() fiber.Handler {
 			return errs.BadRequest(err)
 		}
 
-		totalCommits, err := s.store.GetTotalWorkCommits(c.Context(), assignmentID)
+		sectionID, err := utils.ParseOptionalID(c.Query("section_id"))
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		totalCommits, err := s.store.GetTotalWorkCommits(c.Context(), assignmentID, sectionID)
 		if err != nil {
 			return errs.InternalServerError()
 		}
@@ -0,0 +1,471 @@
+package assignments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+// Gets the group assignment from the request path
+func (s *AssignmentService) getGroupAssignment(c *fiber.Ctx) (models.AssignmentOutline, error) {
+	assignmentID, err := strconv.ParseInt(c.Params("assignment_id"), 10, 64)
+	if err != nil {
+		return models.AssignmentOutline{}, errs.BadRequest(err)
+	}
+
+	assignment, err := s.store.GetAssignmentByID(c.Context(), assignmentID)
+	if err != nil {
+		return models.AssignmentOutline{}, errs.NotFound("assignment", "id", assignmentID)
+	}
+	if !assignment.GroupAssignment {
+		return models.AssignmentOutline{}, errs.BadRequest(errors.New("assignment is not a group assignment"))
+	}
+
+	return assignment, nil
+}
+
+// Returns the groups of an assignment and their members.
+func (s *AssignmentService) getAssignmentGroups() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignment, err := s.getGroupAssignment(c)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.RequireAtLeastRole(c, assignment.ClassroomID, models.TA)
+		if err != nil {
+			return err
+		}
+
+		groups, err := s.store.GetAssignmentGroups(c.Context(), int64(assignment.ID))
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"groups": groups,
+		})
+	}
+}
+
+// Returns the group the current user is in for an assignment.
+func (s *AssignmentService) getCurrentUserAssignmentGroup() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignment, err := s.getGroupAssignment(c)
+		if err != nil {
+			return err
+		}
+
+		classroomUser, err := s.RequireAtLeastRole(c, assignment.ClassroomID, models.Student)
+		if err != nil {
+			return err
+		}
+
+		group, err := s.store.GetUserAssignmentGroup(c.Context(), int64(assignment.ID), *classroomUser.ID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errs.NotFound("group", "user_id", *classroomUser.ID)
+			}
+			return errs.InternalServerError()
+		}
+
+		members, err := s.store.GetAssignmentGroupMembers(c.Context(), int64(group.ID))
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"group": models.AssignmentGroupWithMembers{AssignmentGroup: group, Members: members},
+		})
+	}
+}
+
+// Creates a group on an assignment. Staff can create a group with any students in it, while students
+// can only start their own group when the assignment allows self-formed groups.
+func (s *AssignmentService) createAssignmentGroup() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignment, err := s.getGroupAssignment(c)
+		if err != nil {
+			return err
+		}
+
+		var body models.AssignmentGroupRequestBody
+		if err := c.BodyParser(&body); err != nil {
+			return errs.InvalidRequestBody(body)
+		}
+		if body.Name == "" {
+			return errs.MissingAPIParamError("name")
+		}
+
+		classroomUser, err := s.RequireAtLeastRole(c, assignment.ClassroomID, models.Student)
+		if err != nil {
+			return err
+		}
+
+		memberIDs := body.MemberIDs
+		if classroomUser.Role == models.Student {
+			if !assignment.SelfFormedGroups {
+				return errs.InsufficientPermissionsError()
+			}
+			memberIDs = []int64{*classroomUser.ID}
+		}
+		if assignment.MaxGroupSize != nil && len(memberIDs) > *assignment.MaxGroupSize {
+			return errs.BadRequest(errors.New("group has more members than the maximum group size"))
+		}
+
+		// Every member must be a student in the classroom without a group
+		members := []models.User{}
+		for _, memberID := range memberIDs {
+			member, err := s.getUngroupedStudent(c.Context(), assignment, memberID)
+			if err != nil {
+				return err
+			}
+			members = append(members, member)
+		}
+
+		joinCode, err := utils.GenerateToken(4)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		// a new group has no repository yet, so its members only need saving
+		group, err := s.store.CreateAssignmentGroup(c.Context(), models.AssignmentGroup{
+			AssignmentOutlineID: assignment.ID,
+			Name:                body.Name,
+			JoinCode:            joinCode,
+		}, memberIDs)
+		if err != nil {
+			return errs.Conflict("group", "name", body.Name)
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"group": models.AssignmentGroupWithMembers{AssignmentGroup: group, Members: members},
+		})
+	}
+}
+
+// Joins the group with the given join code as the current user.
+func (s *AssignmentService) joinAssignmentGroup() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignment, err := s.getGroupAssignment(c)
+		if err != nil {
+			return err
+		}
+		if !assignment.SelfFormedGroups {
+			return errs.BadRequest(errors.New("groups for this assignment are set by course staff"))
+		}
+
+		var body models.AssignmentGroupJoinRequestBody
+		if err := c.BodyParser(&body); err != nil {
+			return errs.InvalidRequestBody(body)
+		}
+		if body.JoinCode == "" {
+			return errs.MissingAPIParamError("join_code")
+		}
+
+		classroomUser, err := s.RequireAtLeastRole(c, assignment.ClassroomID, models.Student)
+		if err != nil {
+			return err
+		}
+
+		group, err := s.store.GetAssignmentGroupByJoinCode(c.Context(), int64(assignment.ID), body.JoinCode)
+		if err != nil {
+			return errs.BadRequest(errors.New("invalid join code"))
+		}
+
+		member, err := s.getUngroupedStudent(c.Context(), assignment, *classroomUser.ID)
+		if err != nil {
+			return err
+		}
+
+		classroom, err := s.store.GetClassroomByID(c.Context(), assignment.ClassroomID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		err = s.addGroupMember(c.Context(), classroom, assignment, group, member)
+		if err != nil {
+			return err
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"group": group,
+		})
+	}
+}
+
+// Leaves the current user's group on an assignment.
+func (s *AssignmentService) leaveAssignmentGroup() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignment, err := s.getGroupAssignment(c)
+		if err != nil {
+			return err
+		}
+		if !assignment.SelfFormedGroups {
+			return errs.BadRequest(errors.New("groups for this assignment are set by course staff"))
+		}
+
+		classroomUser, err := s.RequireAtLeastRole(c, assignment.ClassroomID, models.Student)
+		if err != nil {
+			return err
+		}
+
+		group, err := s.store.GetUserAssignmentGroup(c.Context(), int64(assignment.ID), *classroomUser.ID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errs.NotFound("group", "user_id", *classroomUser.ID)
+			}
+			return errs.InternalServerError()
+		}
+
+		classroom, err := s.store.GetClassroomByID(c.Context(), assignment.ClassroomID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		err = s.removeGroupMember(c.Context(), classroom, group, classroomUser.User)
+		if err != nil {
+			return err
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"message": "Left group",
+		})
+	}
+}
+
+// Adds a student to a group on an assignment.
+func (s *AssignmentService) addAssignmentGroupMember() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignment, err := s.getGroupAssignment(c)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.RequireAtLeastRole(c, assignment.ClassroomID, models.TA)
+		if err != nil {
+			return err
+		}
+
+		var body models.AssignmentGroupMemberRequestBody
+		if err := c.BodyParser(&body); err != nil {
+			return errs.InvalidRequestBody(body)
+		}
+
+		group, err := s.getGroupInAssignment(c, assignment)
+		if err != nil {
+			return err
+		}
+
+		member, err := s.getUngroupedStudent(c.Context(), assignment, body.UserID)
+		if err != nil {
+			return err
+		}
+
+		classroom, err := s.store.GetClassroomByID(c.Context(), assignment.ClassroomID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		err = s.addGroupMember(c.Context(), classroom, assignment, group, member)
+		if err != nil {
+			return err
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"group": group,
+		})
+	}
+}
+
+// Removes a student from a group on an assignment.
+func (s *AssignmentService) removeAssignmentGroupMember() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignment, err := s.getGroupAssignment(c)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.RequireAtLeastRole(c, assignment.ClassroomID, models.TA)
+		if err != nil {
+			return err
+		}
+
+		userID, err := strconv.ParseInt(c.Params("user_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		group, err := s.getGroupInAssignment(c, assignment)
+		if err != nil {
+			return err
+		}
+
+		member, err := s.store.GetUserByID(c.Context(), userID)
+		if err != nil {
+			return errs.NotFound("user", "id", userID)
+		}
+
+		classroom, err := s.store.GetClassroomByID(c.Context(), assignment.ClassroomID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		err = s.removeGroupMember(c.Context(), classroom, group, member)
+		if err != nil {
+			return err
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"message": "Removed group member",
+		})
+	}
+}
+
+// Gets the group from the request path, making sure it belongs to the assignment
+func (s *AssignmentService) getGroupInAssignment(c *fiber.Ctx, assignment models.AssignmentOutline) (models.AssignmentGroup, error) {
+	groupID, err := strconv.ParseInt(c.Params("group_id"), 10, 64)
+	if err != nil {
+		return models.AssignmentGroup{}, errs.BadRequest(err)
+	}
+
+	group, err := s.store.GetAssignmentGroupByID(c.Context(), groupID)
+	if err != nil || group.AssignmentOutlineID != assignment.ID {
+		return models.AssignmentGroup{}, errs.NotFound("group", "id", groupID)
+	}
+
+	return group, nil
+}
+
+// Gets a student in the assignment's classroom, making sure they are not already in a group
+func (s *AssignmentService) getUngroupedStudent(ctx context.Context, assignment models.AssignmentOutline, userID int64) (models.User, error) {
+	classroomUser, err := s.store.GetUserInClassroom(ctx, assignment.ClassroomID, userID)
+	if err != nil || classroomUser.Role != models.Student || classroomUser.Status == models.UserStatusRemoved {
+		return models.User{}, errs.UserNotFoundInClassroomError()
+	}
+
+	_, err = s.store.GetUserAssignmentGroup(ctx, int64(assignment.ID), userID)
+	if err == nil {
+		return models.User{}, errs.Conflict("group member", "user_id", userID)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return models.User{}, errs.InternalServerError()
+	}
+
+	return classroomUser.User, nil
+}
+
+// Adds a member to a group, giving them push access to the group's repository if it already exists
+func (s *AssignmentService) addGroupMember(ctx context.Context, classroom models.Classroom, assignment models.AssignmentOutline,
+	group models.AssignmentGroup, member models.User) error {
+	err := s.store.AddAssignmentGroupMember(ctx, group, *member.ID, assignment.MaxGroupSize)
+	if err == errs.ConstraintViolation() {
+		return errs.BadRequest(errors.New("group is full"))
+	}
+	if err == errs.EmptyResult() {
+		return errs.NotFound("group", "id", group.ID)
+	}
+	if err != nil {
+		return errs.Conflict("group member", "user_id", *member.ID)
+	}
+
+	if group.StudentWorkID == nil {
+		return nil
+	}
+
+	err = s.store.AddWorkContributor(ctx, *group.StudentWorkID, *member.ID)
+	if err != nil {
+		return errs.InternalServerError()
+	}
+
+	appClient, err := s.GetAppClient(ctx, classroom.OrgName)
+	if err != nil {
+		return err
+	}
+	err = appClient.AssignPermissionToUser(ctx, classroom.OrgName, *group.RepoName, member.GithubUsername, "push")
+	if err != nil {
+		return errs.GithubAPIError(err)
+	}
+
+	return nil
+}
+
+// Removes a member from a group, revoking their access to the group's repository. Groups that end up
+// empty before creating a repository are deleted.
+func (s *AssignmentService) removeGroupMember(ctx context.Context, classroom models.Classroom, group models.AssignmentGroup, member models.User) error {
+	err := s.store.RemoveAssignmentGroupMember(ctx, int64(group.ID), *member.ID)
+	if err != nil {
+		return errs.NotFound("group member", "user_id", *member.ID)
+	}
+
+	if group.StudentWorkID == nil {
+		members, err := s.store.GetAssignmentGroupMembers(ctx, int64(group.ID))
+		if err != nil {
+			return errs.InternalServerError()
+		}
+		if len(members) == 0 {
+			err = s.store.DeleteAssignmentGroup(ctx, int64(group.ID))
+			if err != nil {
+				return errs.InternalServerError()
+			}
+		}
+		return nil
+	}
+
+	err = s.store.RemoveWorkContributor(ctx, *group.StudentWorkID, *member.ID)
+	if err != nil {
+		return errs.InternalServerError()
+	}
+
+	appClient, err := s.GetAppClient(ctx, classroom.OrgName)
+	if err != nil {
+		return err
+	}
+	err = appClient.RemovePermissionFromUser(ctx, classroom.OrgName, *group.RepoName, member.GithubUsername)
+	if err != nil {
+		return errs.GithubAPIError(err)
+	}
+
+	return nil
+}
+
+// Links a group to its repository and gives every member push access to it
+func (s *AssignmentService) syncGroupRepo(ctx context.Context, classroom models.Classroom, group models.AssignmentGroup, studentWork models.StudentWork) error {
+	if group.StudentWorkID == nil {
+		err := s.store.SetAssignmentGroupWork(ctx, int64(group.ID), studentWork.ID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+	}
+
+	members, err := s.store.GetAssignmentGroupMembers(ctx, int64(group.ID))
+	if err != nil {
+		return errs.InternalServerError()
+	}
+
+	appClient, err := s.GetAppClient(ctx, classroom.OrgName)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		err = s.store.AddWorkContributor(ctx, studentWork.ID, *member.ID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		err = appClient.AssignPermissionToUser(ctx, classroom.OrgName, studentWork.RepoName, member.GithubUsername, "push")
+		if err != nil {
+			return errs.GithubAPIError(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,393 @@
+package assignments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Gets the assignment from the request path and checks the user can manage it
+func (s *AssignmentService) getManagedAssignment(c *fiber.Ctx) (models.AssignmentOutline, error) {
+	assignment, err := s.getManagedAssignmentIncludingArchived(c)
+	if err != nil {
+		return models.AssignmentOutline{}, err
+	}
+
+	if assignment.ArchivedAt != nil {
+		return models.AssignmentOutline{}, errs.BadRequest(errors.New("assignment is archived"))
+	}
+
+	return assignment, nil
+}
+
+// Gets the assignment from the request path, even if it is archived, and checks the user can manage it
+func (s *AssignmentService) getManagedAssignmentIncludingArchived(c *fiber.Ctx) (models.AssignmentOutline, error) {
+	assignmentID, err := strconv.ParseInt(c.Params("assignment_id"), 10, 64)
+	if err != nil {
+		return models.AssignmentOutline{}, errs.BadRequest(err)
+	}
+
+	assignment, err := s.store.GetAssignmentByID(c.Context(), assignmentID)
+	if err != nil {
+		return models.AssignmentOutline{}, errs.NotFound("assignment", "id", assignmentID)
+	}
+
+	_, err = s.RequireAtLeastRole(c, assignment.ClassroomID, models.Professor)
+	if err != nil {
+		return models.AssignmentOutline{}, err
+	}
+
+	return assignment, nil
+}
+
+// Updates the settings of an assignment.
+func (s *AssignmentService) updateAssignment() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignment, err := s.getManagedAssignment(c)
+		if err != nil {
+			return err
+		}
+
+		var body models.AssignmentUpdateRequestBody
+		if err := c.BodyParser(&body); err != nil {
+			return errs.InvalidRequestBody(body)
+		}
+		if body.Name != nil && *body.Name == "" {
+			return errs.MissingAPIParamError("name")
+		}
+
+		// Only the settings in the request change
+		assignmentData := assignment
+		if body.Name != nil {
+			assignmentData.Name = *body.Name
+		}
+		assignmentData.MainDueDate = body.MainDueDate.Or(assignment.MainDueDate)
+		assignmentData.ReleasedAt = body.ReleasedAt.Or(assignment.ReleasedAt)
+		if body.GroupAssignment != nil {
+			assignmentData.GroupAssignment = *body.GroupAssignment
+		}
+		if body.DefaultScore != nil {
+			assignmentData.DefaultScore = *body.DefaultScore
+		}
+		assignmentData.MaxGroupSize = body.MaxGroupSize.Or(assignment.MaxGroupSize)
+		if body.SelfFormedGroups != nil {
+			assignmentData.SelfFormedGroups = *body.SelfFormedGroups
+		}
+
+		// Names must stay unique within the classroom
+		if assignmentData.Name != assignment.Name {
+			existingAssignment, _ := s.store.GetAssignmentByNameAndClassroomID(c.Context(), assignmentData.Name, assignment.ClassroomID)
+			if existingAssignment != nil {
+				return errs.BadRequest(errors.New("assignment with that name already exists"))
+			}
+		}
+
+		// Students already have the base repository once it is released
+		if assignment.Released && !timesEqual(assignment.ReleasedAt, assignmentData.ReleasedAt) {
+			return errs.BadRequest(errors.New("cannot reschedule an assignment that has already been released"))
+		}
+
+		// Existing repositories can't be merged into or split out of groups
+		if assignment.GroupAssignment != assignmentData.GroupAssignment {
+			repoNames, err := s.store.GetAssignmentWorkRepoNames(c.Context(), int64(assignment.ID))
+			if err != nil {
+				return errs.InternalServerError()
+			}
+			if len(repoNames) > 0 {
+				return errs.BadRequest(errors.New("cannot change group mode after students have accepted the assignment"))
+			}
+		}
+
+		// Works without an extension follow the assignment's due date. Removing the due date leaves
+		// the existing deadline workflows in place.
+		oldDueDate := assignment.MainDueDate
+		dueDateChanged := assignmentData.MainDueDate != nil && !timesEqual(oldDueDate, assignmentData.MainDueDate)
+
+		assignment = assignmentData
+
+		if c.QueryBool("dry_run") {
+			sideEffects := []models.AssignmentSideEffect{}
+			if dueDateChanged && assignment.DeadlineWorkflow {
+				sideEffects, err = s.planDeadlineUpdates(c.Context(), assignment, oldDueDate)
+				if err != nil {
+					return err
+				}
+			}
+
+			return c.Status(http.StatusOK).JSON(fiber.Map{
+				"dry_run":            true,
+				"assignment_outline": assignment,
+				"side_effects":       sideEffects,
+			})
+		}
+
+		updatedAssignment, err := s.store.UpdateAssignment(c.Context(), assignment)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		// The deadline workflows are re-rendered in the background, see the deadline-updates endpoint for progress
+		deadlineUpdates := []models.DeadlineUpdate{}
+		if dueDateChanged {
+			deadlineUpdates, err = s.queueDeadlineUpdates(c.Context(), updatedAssignment, oldDueDate)
+			if err != nil {
+				return err
+			}
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"assignment_outline": updatedAssignment,
+			"deadline_updates":   deadlineUpdates,
+		})
+	}
+}
+
+// Archives an assignment: its tokens are revoked, and the base repository and student repositories
+// are archived on GitHub so they become read-only. The assignment is archived before its repositories,
+// so no new repositories are created meanwhile. Archiving an archived assignment archives its repositories
+// again, finishing an archive that failed part way.
+func (s *AssignmentService) archiveAssignment() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignment, err := s.getManagedAssignmentIncludingArchived(c)
+		if err != nil {
+			return err
+		}
+
+		sideEffects, err := s.planRepositoryActions(c.Context(), assignment, models.SideEffectArchiveRepository)
+		if err != nil {
+			return err
+		}
+
+		if c.QueryBool("dry_run") {
+			return c.Status(http.StatusOK).JSON(fiber.Map{
+				"dry_run":      true,
+				"side_effects": sideEffects,
+			})
+		}
+
+		archivedAssignment, err := s.store.ArchiveAssignment(c.Context(), int64(assignment.ID))
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		err = s.applySideEffects(c.Context(), sideEffects)
+		if err != nil {
+			return err
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"assignment_outline": archivedAssignment,
+			"side_effects":       sideEffects,
+		})
+	}
+}
+
+// Deletes an assignment along with its base repository and student repositories. Only assignments
+// that students haven't started working on can be deleted, others should be archived instead. The
+// assignment is archived first so no new repositories are created meanwhile, and is only removed once
+// its repositories are gone. A delete that failed part way can be retried, repositories already
+// deleted are skipped.
+func (s *AssignmentService) deleteAssignment() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignment, err := s.getManagedAssignmentIncludingArchived(c)
+		if err != nil {
+			return err
+		}
+
+		hasActivity, err := s.store.AssignmentHasStudentActivity(c.Context(), int64(assignment.ID))
+		if err != nil {
+			return errs.InternalServerError()
+		}
+		if hasActivity {
+			return errs.NewAPIError(http.StatusConflict, errors.New("students have started this assignment, archive it instead"))
+		}
+
+		sideEffects, err := s.planRepositoryActions(c.Context(), assignment, models.SideEffectDeleteRepository)
+		if err != nil {
+			return err
+		}
+
+		if c.QueryBool("dry_run") {
+			return c.Status(http.StatusOK).JSON(fiber.Map{
+				"dry_run":      true,
+				"side_effects": sideEffects,
+			})
+		}
+
+		_, err = s.store.ArchiveAssignment(c.Context(), int64(assignment.ID))
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		err = s.applySideEffects(c.Context(), sideEffects)
+		if err != nil {
+			return err
+		}
+
+		err = s.store.DeleteAssignment(c.Context(), int64(assignment.ID))
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"message":      "Assignment deleted",
+			"side_effects": sideEffects,
+		})
+	}
+}
+
+// Returns the progress of due date changes being propagated to an assignment's repositories.
+func (s *AssignmentService) getDeadlineUpdates() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignmentID, err := strconv.ParseInt(c.Params("assignment_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		assignment, err := s.store.GetAssignmentByID(c.Context(), assignmentID)
+		if err != nil {
+			return errs.NotFound("assignment", "id", assignmentID)
+		}
+
+		_, err = s.RequireAtLeastRole(c, assignment.ClassroomID, models.TA)
+		if err != nil {
+			return err
+		}
+
+		deadlineUpdates, err := s.store.GetDeadlineUpdatesByAssignment(c.Context(), assignmentID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"deadline_updates": deadlineUpdates,
+		})
+	}
+}
+
+// Lists the deadline workflows that a due date change would re-render
+func (s *AssignmentService) planDeadlineUpdates(ctx context.Context, assignment models.AssignmentOutline, oldDueDate *time.Time) ([]models.AssignmentSideEffect, error) {
+	classroom, err := s.store.GetClassroomByID(ctx, assignment.ClassroomID)
+	if err != nil {
+		return nil, errs.InternalServerError()
+	}
+
+	sideEffects := []models.AssignmentSideEffect{}
+
+	baseRepo, err := s.store.GetBaseRepoByID(ctx, assignment.BaseRepoID)
+	if err != nil {
+		return nil, errs.InternalServerError()
+	}
+	sideEffects = append(sideEffects, models.AssignmentSideEffect{
+		Action:     models.SideEffectUpdateDeadline,
+		Repository: fmt.Sprintf("%s/%s", baseRepo.BaseRepoOwner, baseRepo.BaseRepoName),
+	})
+
+	repoNames, err := s.store.GetWorkRepoNamesWithDueDate(ctx, int64(assignment.ID), oldDueDate)
+	if err != nil {
+		return nil, errs.InternalServerError()
+	}
+	for _, repoName := range repoNames {
+		sideEffects = append(sideEffects, models.AssignmentSideEffect{
+			Action:     models.SideEffectUpdateDeadline,
+			Repository: fmt.Sprintf("%s/%s", classroom.OrgName, repoName),
+		})
+	}
+
+	return sideEffects, nil
+}
+
+// Moves works without an extension to the assignment's new due date and queues their deadline workflows for re-rendering,
+// if the assignment has them
+func (s *AssignmentService) queueDeadlineUpdates(ctx context.Context, assignment models.AssignmentOutline, oldDueDate *time.Time) ([]models.DeadlineUpdate, error) {
+	classroom, err := s.store.GetClassroomByID(ctx, assignment.ClassroomID)
+	if err != nil {
+		return nil, errs.InternalServerError()
+	}
+
+	baseRepo, err := s.store.GetBaseRepoByID(ctx, assignment.BaseRepoID)
+	if err != nil {
+		return nil, errs.InternalServerError()
+	}
+
+	deadlineUpdates, err := s.store.QueueDeadlineUpdates(ctx, int64(assignment.ID), baseRepo, classroom.OrgName, assignment.RepoLayout.SubmissionBranch, oldDueDate, *assignment.MainDueDate, assignment.DeadlineWorkflow)
+	if err != nil {
+		return nil, errs.InternalServerError()
+	}
+
+	return deadlineUpdates, nil
+}
+
+// Lists the action to take on the base repository and every student repository of an assignment
+func (s *AssignmentService) planRepositoryActions(ctx context.Context, assignment models.AssignmentOutline, action models.AssignmentSideEffectAction) ([]models.AssignmentSideEffect, error) {
+	classroom, err := s.store.GetClassroomByID(ctx, assignment.ClassroomID)
+	if err != nil {
+		return nil, errs.InternalServerError()
+	}
+
+	sideEffects := []models.AssignmentSideEffect{}
+
+	baseRepo, err := s.store.GetBaseRepoByID(ctx, assignment.BaseRepoID)
+	if err == nil {
+		sideEffects = append(sideEffects, models.AssignmentSideEffect{
+			Action:     action,
+			Repository: fmt.Sprintf("%s/%s", baseRepo.BaseRepoOwner, baseRepo.BaseRepoName),
+		})
+	}
+
+	repoNames, err := s.store.GetAssignmentWorkRepoNames(ctx, int64(assignment.ID))
+	if err != nil {
+		return nil, errs.InternalServerError()
+	}
+	for _, repoName := range repoNames {
+		sideEffects = append(sideEffects, models.AssignmentSideEffect{
+			Action:     action,
+			Repository: fmt.Sprintf("%s/%s", classroom.OrgName, repoName),
+		})
+	}
+
+	return sideEffects, nil
+}
+
+// Carries out planned side effects on GitHub
+func (s *AssignmentService) applySideEffects(ctx context.Context, sideEffects []models.AssignmentSideEffect) error {
+	for _, sideEffect := range sideEffects {
+		owner, repoName, found := strings.Cut(sideEffect.Repository, "/")
+		if !found {
+			return errs.InternalServerError()
+		}
+
+		appClient, err := s.GetAppClient(ctx, owner)
+		if err != nil {
+			return err
+		}
+
+		switch sideEffect.Action {
+		case models.SideEffectArchiveRepository:
+			err = appClient.ArchiveRepository(ctx, owner, repoName)
+		case models.SideEffectDeleteRepository:
+			err = appClient.DeleteRepository(ctx, owner, repoName)
+		}
+		if err != nil {
+			return errs.GithubAPIError(fmt.Errorf("%s %s: %v", sideEffect.Action, sideEffect.Repository, err))
+		}
+	}
+
+	return nil
+}
+
+func timesEqual(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
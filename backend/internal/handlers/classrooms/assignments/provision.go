@@ -0,0 +1,83 @@
+package assignments
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Queues the creation of a repository for every active student in the classroom, so students don't have to
+// accept the assignment themselves. Repositories are created in the background once the assignment is released.
+func (s *AssignmentService) provisionRepos() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignment, err := s.getManagedAssignment(c)
+		if err != nil {
+			return err
+		}
+		if assignment.GroupAssignment {
+			return errs.BadRequest(errors.New("repositories of group assignments are created once groups have formed"))
+		}
+
+		provisions, err := s.store.QueueRepoProvisions(c.Context(), int64(assignment.ID), assignment.ClassroomID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"queued": provisions,
+		})
+	}
+}
+
+// Returns the progress of an assignment's repository provisioning, per student
+func (s *AssignmentService) getRepoProvisions() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignmentID, err := strconv.ParseInt(c.Params("assignment_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		assignment, err := s.store.GetAssignmentByID(c.Context(), assignmentID)
+		if err != nil {
+			return errs.NotFound("assignment", "id", assignmentID)
+		}
+
+		_, err = s.RequireAtLeastRole(c, assignment.ClassroomID, models.TA)
+		if err != nil {
+			return err
+		}
+
+		provisions, err := s.store.GetRepoProvisionsByAssignment(c.Context(), assignmentID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"provisions": provisions,
+			"counts":     models.CountJobs(provisions),
+		})
+	}
+}
+
+// Requeues the failed repository provisions of an assignment
+func (s *AssignmentService) retryRepoProvisions() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignment, err := s.getManagedAssignment(c)
+		if err != nil {
+			return err
+		}
+
+		retried, err := s.store.RetryRepoProvisions(c.Context(), int64(assignment.ID))
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"retried": retried,
+		})
+	}
+}
@@ -9,7 +9,7 @@ import (
 func AssignmentRoutes(router fiber.Router, service *AssignmentService, params *types.Params) fiber.Router {
 	assignmentRouter := router.Group(
 		"/classrooms/classroom/:classroom_id/assignments",
-	).Use(middleware.Protected(service.userCfg.JWTSecret))
+	).Use(middleware.Protected(service.store, service.userCfg.JWTSecret))
 
 	// Get the assignments in a classroom
 	assignmentRouter.Get("/", service.getAssignments())
@@ -17,6 +17,12 @@ func AssignmentRoutes(router fiber.Router, service *AssignmentService, params *t
 	// Generate a token to accept this assignment
 	assignmentRouter.Post("/assignment/:assignment_id/token", service.generateAssignmentToken())
 
+	// Get the tokens of this assignment that can still be used
+	assignmentRouter.Get("/assignment/:assignment_id/tokens", service.getAssignmentTokens())
+
+	// Revoke a token of this assignment
+	assignmentRouter.Put("/assignment/:assignment_id/token/:token/revoke", service.revokeAssignmentToken())
+
 	// Use a token to accept an assignment
 	assignmentRouter.Post("/token/:token", service.useAssignmentToken())
 
@@ -29,6 +35,33 @@ func AssignmentRoutes(router fiber.Router, service *AssignmentService, params *t
 	// Create an assignment
 	assignmentRouter.Post("/", service.createAssignment())
 
+	// Update the settings of an assignment
+	assignmentRouter.Put("/assignment/:assignment_id", service.updateAssignment())
+
+	// Get the progress of due date changes being applied to an assignment's repositories
+	assignmentRouter.Get("/assignment/:assignment_id/deadline-updates", service.getDeadlineUpdates())
+
+	// Push starter code changes from the base repository to every student fork
+	assignmentRouter.Post("/assignment/:assignment_id/starter-code-syncs", service.createStarterCodeSync())
+
+	// Get the rollout status of an assignment's starter code updates
+	assignmentRouter.Get("/assignment/:assignment_id/starter-code-syncs", service.getStarterCodeSyncs())
+
+	// Create repositories for every student in the classroom without an accept link
+	assignmentRouter.Post("/assignment/:assignment_id/provisions", service.provisionRepos())
+
+	// Get the progress of an assignment's repository provisioning
+	assignmentRouter.Get("/assignment/:assignment_id/provisions", service.getRepoProvisions())
+
+	// Retry the repository provisions that failed
+	assignmentRouter.Post("/assignment/:assignment_id/provisions/retry", service.retryRepoProvisions())
+
+	// Archive an assignment and its repositories
+	assignmentRouter.Post("/assignment/:assignment_id/archive", service.archiveAssignment())
+
+	// Delete an assignment and its repositories
+	assignmentRouter.Delete("/assignment/:assignment_id", service.deleteAssignment())
+
 	// Update an assignment rubric
 	assignmentRouter.Put("/assignment/:assignment_id/rubric", service.updateAssignmentRubric())
 
@@ -50,5 +83,26 @@ func AssignmentRoutes(router fiber.Router, service *AssignmentService, params *t
 	// Get the total number of commits in all student works for this assignment
 	assignmentRouter.Get("/assignment/:assignment_id/commit-count", service.GetCommitCount())
 
+	// Get the groups of a group assignment
+	assignmentRouter.Get("/assignment/:assignment_id/groups", service.getAssignmentGroups())
+
+	// Create a group on a group assignment
+	assignmentRouter.Post("/assignment/:assignment_id/groups", service.createAssignmentGroup())
+
+	// Get the current user's group on a group assignment
+	assignmentRouter.Get("/assignment/:assignment_id/groups/mine", service.getCurrentUserAssignmentGroup())
+
+	// Join a group using its join code
+	assignmentRouter.Post("/assignment/:assignment_id/groups/join", service.joinAssignmentGroup())
+
+	// Leave the current user's group
+	assignmentRouter.Post("/assignment/:assignment_id/groups/leave", service.leaveAssignmentGroup())
+
+	// Add a student to a group
+	assignmentRouter.Post("/assignment/:assignment_id/groups/group/:group_id/members", service.addAssignmentGroupMember())
+
+	// Remove a student from a group
+	assignmentRouter.Delete("/assignment/:assignment_id/groups/group/:group_id/members/:user_id", service.removeAssignmentGroupMember())
+
 	return assignmentRouter
 }
@@ -1,6 +1,8 @@
 package assignments
 
 import (
+	"context"
+
 	"github.com/CamPlume1/khoury-classroom/internal/config"
 	"github.com/CamPlume1/khoury-classroom/internal/github"
 	"github.com/CamPlume1/khoury-classroom/internal/middleware"
@@ -10,12 +12,12 @@ import (
 type AssignmentService struct {
 	store storage.Storage
 	userCfg *config.GitHubUserClient
-	appClient github.GitHubAppClient
+	appClients github.GitHubAppInstallations
 	middleware.RoleChecker[AssignmentService]
 }
 
-func NewAssignmentService(store storage.Storage, userCfg *config.GitHubUserClient, appClient github.GitHubAppClient) *AssignmentService {
-	service := &AssignmentService{store: store, userCfg: userCfg, appClient: appClient}
+func NewAssignmentService(store storage.Storage, userCfg *config.GitHubUserClient, appClients github.GitHubAppInstallations) *AssignmentService {
+	service := &AssignmentService{store: store, userCfg: userCfg, appClients: appClients}
 	service.RoleChecker = middleware.RoleChecker[AssignmentService]{Checkable: service}
 	return service
 }
@@ -25,9 +27,9 @@ func (s *AssignmentService) GetStore() storage.Storage {
 	return s.store
 }
 
-// Getter for appClient field
-func (s *AssignmentService) GetAppClient() github.GitHubAppClient {
-	return s.appClient
+// Gets the app client of the installation on an org
+func (s *AssignmentService) GetAppClient(ctx context.Context, orgName string) (github.GitHubAppClient, error) {
+	return s.appClients.ForOrg(ctx, orgName)
 }
 
 // Getter for userCfg field
@@ -0,0 +1,122 @@
+package assignments
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/middleware"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Pushes the changes made to the base repository since the given commit to every student fork. Each fork
+// gets a pull request for the students to merge, opened in the background.
+func (s *AssignmentService) createStarterCodeSync() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignment, err := s.getManagedAssignment(c)
+		if err != nil {
+			return err
+		}
+
+		var body models.StarterCodeSyncRequestBody
+		if err := c.BodyParser(&body); err != nil {
+			return errs.InvalidRequestBody(body)
+		}
+		if body.BaseCommitSHA == "" {
+			return errs.MissingAPIParamError("base_commit_sha")
+		}
+		if body.Title == "" {
+			body.Title = "Starter code update"
+		}
+
+		_, _, user, err := middleware.GetClientAndUser(c, s.store, s.userCfg)
+		if err != nil {
+			return errs.AuthenticationError()
+		}
+
+		baseRepo, err := s.store.GetBaseRepoByID(c.Context(), assignment.BaseRepoID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		appClient, err := s.GetAppClient(c.Context(), baseRepo.BaseRepoOwner)
+		if err != nil {
+			return err
+		}
+
+		headSHA, err := appClient.GetHeadCommitSHA(c.Context(), baseRepo.BaseRepoOwner, baseRepo.BaseRepoName)
+		if err != nil {
+			return errs.GithubAPIError(err)
+		}
+
+		comparison, err := appClient.CompareCommits(c.Context(), baseRepo.BaseRepoOwner, baseRepo.BaseRepoName, body.BaseCommitSHA, headSHA)
+		if err != nil {
+			return errs.GithubAPIError(err)
+		}
+		changedFiles := comparison.Files
+		if len(changedFiles) == 0 {
+			return errs.BadRequest(errors.New("the base repository has no changes since that commit"))
+		}
+
+		if c.QueryBool("dry_run") {
+			repoNames, err := s.store.GetAssignmentWorkRepoNames(c.Context(), int64(assignment.ID))
+			if err != nil {
+				return errs.InternalServerError()
+			}
+
+			return c.Status(http.StatusOK).JSON(fiber.Map{
+				"dry_run":         true,
+				"head_commit_sha": headSHA,
+				"changed_files":   changedFiles,
+				"repositories":    repoNames,
+			})
+		}
+
+		sync, err := s.store.CreateStarterCodeSync(c.Context(), models.StarterCodeSync{
+			AssignmentOutlineID: int(assignment.ID),
+			BaseCommitSHA:       body.BaseCommitSHA,
+			HeadCommitSHA:       headSHA,
+			Title:               body.Title,
+			CreatedByUserID:     *user.ID,
+		})
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"starter_code_sync": sync,
+			"changed_files":     changedFiles,
+		})
+	}
+}
+
+// Returns the starter code updates of an assignment and the status of each fork's pull request.
+func (s *AssignmentService) getStarterCodeSyncs() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignmentID, err := strconv.ParseInt(c.Params("assignment_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		assignment, err := s.store.GetAssignmentByID(c.Context(), assignmentID)
+		if err != nil {
+			return errs.NotFound("assignment", "id", assignmentID)
+		}
+
+		_, err = s.RequireAtLeastRole(c, assignment.ClassroomID, models.TA)
+		if err != nil {
+			return err
+		}
+
+		syncs, err := s.store.GetStarterCodeSyncsByAssignment(c.Context(), assignmentID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"starter_code_syncs": syncs,
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package assignments
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+// Lists the assignment's tokens that can still be used
+func (s *AssignmentService) getAssignmentTokens() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignment, err := s.getTokenAssignment(c)
+		if err != nil {
+			return err
+		}
+
+		tokens, err := s.store.GetActiveAssignmentTokens(c.Context(), int64(assignment.ID))
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{"tokens": tokens})
+	}
+}
+
+// Revokes one of the assignment's tokens. Students who already accepted with it keep their repositories.
+func (s *AssignmentService) revokeAssignmentToken() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignment, err := s.getTokenAssignment(c)
+		if err != nil {
+			return err
+		}
+
+		token := c.Params("token")
+		assignmentToken, err := s.store.RevokeAssignmentToken(c.Context(), int64(assignment.ID), token)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.NotFound("token", "token", token)
+		}
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{"token": assignmentToken})
+	}
+}
+
+// Gets the assignment from the request path and checks the user can manage its links
+func (s *AssignmentService) getTokenAssignment(c *fiber.Ctx) (models.AssignmentOutline, error) {
+	assignmentID, err := strconv.ParseInt(c.Params("assignment_id"), 10, 64)
+	if err != nil {
+		return models.AssignmentOutline{}, errs.BadRequest(err)
+	}
+
+	assignment, err := s.store.GetAssignmentByID(c.Context(), assignmentID)
+	if err != nil {
+		return models.AssignmentOutline{}, errs.NotFound("assignment", "id", assignmentID)
+	}
+
+	_, err = s.RequireAtLeastRole(c, assignment.ClassroomID, models.TA)
+	if err != nil {
+		return models.AssignmentOutline{}, err
+	}
+
+	return assignment, nil
+}
@@ -0,0 +1,77 @@
+package works
+
+import (
+	"context"
+
+	"github.com/CamPlume1/khoury-classroom/internal/github"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	gh "github.com/google/go-github/github"
+)
+
+// Checks whether an edit leaves a comment where it was posted, so its GitHub comment can be edited in place
+func sameFeedbackAnchor(posted models.PRReviewCommentResponse, edited models.PRReviewCommentResponse) bool {
+	sameInt := func(a, b *int) bool { return (a == nil && b == nil) || (a != nil && b != nil && *a == *b) }
+	sameString := func(a, b *string) bool { return (a == nil && b == nil) || (a != nil && b != nil && *a == *b) }
+	return sameString(posted.Path, edited.Path) && sameInt(posted.StartLine, edited.StartLine) &&
+		sameInt(posted.Line, edited.Line) && sameString(posted.CommitSHA, edited.CommitSHA)
+}
+
+// Mirrors saved feedback changes onto the student's pull request. Deleted comments are removed, edited comments are
+// changed in place or re-posted if they moved, and new comments are posted in a review. The GitHub comment of each
+// posted feedback comment is recorded so later changes can follow it. Events are the saved changes, one per comment.
+func (s *WorkService) postFeedbackToGitHub(ctx context.Context, userClient github.GitHubUserClient, orgName string, repoName string,
+	commitSHA string, body string, comments []models.PRReviewCommentResponse, posted map[int]models.PRReviewCommentResponse,
+	events []models.GradeEvent) (*gh.PullRequestComment, error) {
+	var toPost []models.PRReviewCommentResponse
+	var toPostIDs []int
+	for i, comment := range comments {
+		if comment.Action == models.PRReviewCommentActionEdit || comment.Action == models.PRReviewCommentActionDelete {
+			previous := posted[*comment.FeedbackCommentID]
+			if previous.GitHubCommentID != nil {
+				if comment.Action == models.PRReviewCommentActionEdit && sameFeedbackAnchor(previous, comment) {
+					formatted := formatFeedbackForGitHub([]models.PRReviewCommentResponse{comment})[0]
+					err := userClient.EditPRReviewComment(ctx, orgName, repoName, *previous.GitHubCommentID, formatted.Body)
+					if err != nil {
+						return nil, err
+					}
+					continue
+				}
+
+				err := userClient.DeletePRReviewComment(ctx, orgName, repoName, *previous.GitHubCommentID)
+				if err != nil {
+					return nil, err
+				}
+				err = s.store.SetFeedbackGitHubComment(ctx, *comment.FeedbackCommentID, nil)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if comment.Action == models.PRReviewCommentActionDelete {
+				continue
+			}
+		}
+
+		// new comments, and edited comments that moved or were never posted
+		toPost = append(toPost, comment)
+		toPostIDs = append(toPostIDs, *events[i].FeedbackCommentID)
+	}
+
+	if len(toPost) == 0 && body == "" {
+		return nil, nil
+	}
+	review, commentIDs, err := userClient.CreatePRReview(ctx, orgName, repoName, commitSHA, body, formatFeedbackForGitHub(toPost))
+	if err != nil {
+		return nil, err
+	}
+	for i, commentID := range commentIDs {
+		if commentID == 0 {
+			continue
+		}
+		err = s.store.SetFeedbackGitHubComment(ctx, toPostIDs[i], &commentID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return review, nil
+}
@@ -15,13 +15,31 @@ func (s *WorkService) GetFileTree() fiber.Handler {
 			return err
 		}
 
-		tree, err := s.appClient.GetFileTree(work.OrgName, work.RepoName)
+		appClient, err := s.GetAppClient(c.Context(), work.OrgName)
+		if err != nil {
+			return err
+		}
+
+		// show the commit being graded, which feedback is anchored to
+		gradingSHA, err := s.getGradingCommitSHA(c.Context(), work.StudentWork)
+		if err != nil {
+			return errs.GithubAPIError(err)
+		}
+
+		tree, err := appClient.GetFileTree(work.OrgName, work.RepoName, gradingSHA)
 		if err != nil {
 			return errs.GithubAPIError(err)
 		}
 
+		// keep feedback positions in line with the tree being viewed
+		feedback, err := s.getReanchoredFeedback(c.Context(), work.StudentWork)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
 		return c.Status(http.StatusOK).JSON(fiber.Map{
-			"tree": tree,
+			"tree":     tree,
+			"feedback": feedback,
 		})
 	}
 }
@@ -37,7 +55,12 @@ func (s *WorkService) GetFileBlob() fiber.Handler {
 			return errs.BadRequest(errors.New("missing blob SHA"))
 		}
 
-		content, err := s.appClient.GetFileBlob(work.OrgName, work.RepoName, c.Params("sha"))
+		appClient, err := s.GetAppClient(c.Context(), work.OrgName)
+		if err != nil {
+			return err
+		}
+
+		content, err := appClient.GetFileBlob(work.OrgName, work.RepoName, c.Params("sha"))
 		if err != nil {
 			return errs.GithubAPIError(err)
 		}
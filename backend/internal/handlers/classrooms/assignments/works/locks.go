@@ -0,0 +1,52 @@
+package works
+
+import (
+	"net/http"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Gives the students of a work push access again after it was locked at its due date. Without a new due date
+// the work stays unlocked, otherwise it is locked again once the new due date passes.
+func (s *WorkService) unlockWork() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		work, err := s.getWork(c)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.RequireAtLeastRole(c, int64(work.ClassroomID), models.Professor)
+		if err != nil {
+			return err
+		}
+
+		var body models.UnlockWorkRequestBody
+		if err := c.BodyParser(&body); err != nil && len(c.Body()) > 0 {
+			return errs.InvalidRequestBody(body)
+		}
+
+		appClient, err := s.GetAppClient(c.Context(), work.OrgName)
+		if err != nil {
+			return err
+		}
+
+		for _, contributor := range work.Contributors {
+			err = appClient.AssignPermissionToUser(c.Context(), work.OrgName, work.RepoName, contributor.GithubUsername, "push")
+			if err != nil {
+				return errs.GithubAPIError(err)
+			}
+		}
+
+		err = s.store.UnlockStudentWork(c.Context(), work.ID, body.DueDate)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"work_id":  work.ID,
+			"due_date": body.DueDate,
+		})
+	}
+}
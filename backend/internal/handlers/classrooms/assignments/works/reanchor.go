@@ -0,0 +1,261 @@
+package works
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/CamPlume1/khoury-classroom/internal/github"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+)
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// Maps a line number in the old version of a file to the new version using the file's unified diff.
+// Returns false if the line was removed or rewritten.
+func mapLineThroughPatch(patch string, line int) (int, bool) {
+	offset := 0
+	oldLine, newLine := 0, 0
+	inHunk := false
+
+	for _, patchLine := range strings.Split(patch, "\n") {
+		if match := hunkHeader.FindStringSubmatch(patchLine); match != nil {
+			oldStart, _ := strconv.Atoi(match[1])
+			newStart, _ := strconv.Atoi(match[3])
+			// a hunk with a zero count starts after the line given in its header
+			if match[2] == "0" {
+				oldStart++
+			}
+			if match[4] == "0" {
+				newStart++
+			}
+
+			// the line sits between hunks, it was only shifted by earlier hunks
+			if line < oldStart {
+				return line + offset, true
+			}
+
+			oldLine, newLine = oldStart, newStart
+			inHunk = true
+			continue
+		}
+		if !inHunk || patchLine == "" {
+			continue
+		}
+
+		switch patchLine[0] {
+		case '+':
+			newLine++
+		case '-':
+			if oldLine == line {
+				return 0, false
+			}
+			oldLine++
+		case '\\':
+			// "\ No newline at end of file"
+			continue
+		default:
+			if oldLine == line {
+				return newLine, true
+			}
+			oldLine++
+			newLine++
+		}
+		offset = newLine - oldLine
+	}
+
+	return line + offset, true
+}
+
+// Moves a comment onto its position after the given file changes, or flags it as outdated
+func reanchorComment(comment models.PRReviewCommentResponse, changedFiles []models.ChangedFile) models.PRReviewCommentResponse {
+	for _, file := range changedFiles {
+		previousName := file.Filename
+		if file.PreviousFilename != nil {
+			previousName = *file.PreviousFilename
+		}
+		if previousName != *comment.Path {
+			continue
+		}
+
+		if file.Status == "removed" {
+			comment.Outdated = true
+			return comment
+		}
+
+		path := file.Filename
+		comment.Path = &path
+
+		// whole-file comments only follow renames
+		if comment.Line == nil {
+			return comment
+		}
+
+		// renames without content changes come without a patch, binary and oversized diffs can't be mapped
+		if file.Patch == nil {
+			comment.Outdated = file.Status != "renamed"
+			return comment
+		}
+
+		line, ok := mapLineThroughPatch(*file.Patch, *comment.Line)
+		if !ok {
+			comment.Outdated = true
+			return comment
+		}
+		if comment.StartLine != nil {
+			startLine, ok := mapLineThroughPatch(*file.Patch, *comment.StartLine)
+			if !ok {
+				comment.Outdated = true
+				return comment
+			}
+			comment.StartLine = &startLine
+		}
+		comment.Line = &line
+		return comment
+	}
+
+	// file was untouched
+	return comment
+}
+
+// Turns a file's change around, so it describes going from the newer commit back to the older one
+func invertChangedFile(file models.ChangedFile) models.ChangedFile {
+	inverted := file
+	if file.PreviousFilename != nil {
+		inverted.Filename = *file.PreviousFilename
+		filename := file.Filename
+		inverted.PreviousFilename = &filename
+	}
+	switch file.Status {
+	case "added":
+		inverted.Status = "removed"
+	case "removed":
+		inverted.Status = "added"
+	}
+	if file.Patch != nil {
+		patch := invertPatch(*file.Patch)
+		inverted.Patch = &patch
+	}
+	return inverted
+}
+
+// Swaps the old and new sides of a unified diff
+func invertPatch(patch string) string {
+	lines := strings.Split(patch, "\n")
+	for i, line := range lines {
+		if match := hunkHeader.FindStringSubmatch(line); match != nil {
+			oldRange, newRange := match[1], match[3]
+			if match[2] != "" {
+				oldRange += "," + match[2]
+			}
+			if match[4] != "" {
+				newRange += "," + match[4]
+			}
+			lines[i] = "@@ -" + newRange + " +" + oldRange + " @@" + line[len(match[0]):]
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		switch line[0] {
+		case '+':
+			lines[i] = "-" + line[1:]
+		case '-':
+			lines[i] = "+" + line[1:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Lists the files changed going from one commit to another, whichever of the two is newer. Returns false when
+// the commits have diverged, since their diff against a common ancestor can't carry lines between them.
+func getChangedFiles(ctx context.Context, appClient github.GitHubAppClient, orgName string, repoName string, fromSHA string, toSHA string) ([]models.ChangedFile, bool, error) {
+	comparison, err := appClient.CompareCommits(ctx, orgName, repoName, fromSHA, toSHA)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch comparison.Status {
+	case "ahead", "identical":
+		return comparison.Files, true, nil
+	case "behind":
+		// graders picked a submission older than the commit the feedback was left on
+		comparison, err = appClient.CompareCommits(ctx, orgName, repoName, toSHA, fromSHA)
+		if err != nil {
+			return nil, false, err
+		}
+		changedFiles := make([]models.ChangedFile, 0, len(comparison.Files))
+		for _, file := range comparison.Files {
+			changedFiles = append(changedFiles, invertChangedFile(file))
+		}
+		return changedFiles, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+// Carries a work's feedback to the commit it is graded at, the latest on its submission branch unless
+// graders picked another submission. Comments whose lines no longer exist are flagged as outdated and
+// keep their original anchor, as do comments left on a commit that has diverged from the graded one.
+// Nothing is saved, feedback is re-anchored from where it was left each time it is read.
+func (s *WorkService) reanchorFeedback(ctx context.Context, work models.StudentWork, feedback []models.PRReviewCommentResponse) ([]models.PRReviewCommentResponse, error) {
+	orgName, repoName := work.OrgName, work.RepoName
+	headSHA, err := s.getGradingCommitSHA(ctx, work)
+	if err != nil {
+		return feedback, err
+	}
+
+	appClient, err := s.GetAppClient(ctx, orgName)
+	if err != nil {
+		return feedback, err
+	}
+
+	type comparison struct {
+		changedFiles []models.ChangedFile
+		ok           bool
+	}
+	comparisons := make(map[string]comparison)
+	for i, comment := range feedback {
+		if comment.Path == nil || comment.CommitSHA == nil || *comment.CommitSHA == headSHA {
+			continue
+		}
+
+		compared, found := comparisons[*comment.CommitSHA]
+		if !found {
+			compared.changedFiles, compared.ok, err = getChangedFiles(ctx, appClient, orgName, repoName, *comment.CommitSHA, headSHA)
+			if err != nil {
+				return feedback, err
+			}
+			comparisons[*comment.CommitSHA] = compared
+		}
+		if !compared.ok {
+			continue
+		}
+
+		reanchored := reanchorComment(comment, compared.changedFiles)
+		if !reanchored.Outdated {
+			reanchored.CommitSHA = &headSHA
+		}
+		feedback[i] = reanchored
+	}
+
+	return feedback, nil
+}
+
+// Gets the feedback on a work, re-anchored to the commit it is graded at when possible
+func (s *WorkService) getReanchoredFeedback(ctx context.Context, work models.StudentWork) ([]models.PRReviewCommentResponse, error) {
+	feedback, err := s.store.GetFeedbackOnWork(ctx, work.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// fall back to the stored positions if GitHub can't be reached
+	reanchored, err := s.reanchorFeedback(ctx, work, feedback)
+	if err != nil {
+		log.Default().Println("Warning: Failed to re-anchor feedback, ", err)
+	}
+
+	return reanchored, nil
+}
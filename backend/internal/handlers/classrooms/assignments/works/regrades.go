@@ -0,0 +1,134 @@
+package works
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/middleware"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Requests a regrade of one of the feedback comments on the authenticated student's work, once its grades are published.
+func (s *WorkService) requestRegrade() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		studentWorkID, err := strconv.Atoi(c.Params("work_id"))
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		_, _, user, err := middleware.GetClientAndUser(c, s.store, s.userCfg)
+		if err != nil {
+			return errs.AuthenticationError()
+		}
+
+		isContributor, err := s.store.IsWorkContributor(c.Context(), studentWorkID, *user.ID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+		if !isContributor {
+			return errs.NotFound("student work", "id", c.Params("work_id"))
+		}
+
+		work, err := s.store.GetWorkByID(c.Context(), studentWorkID)
+		if err != nil {
+			return errs.NotFound("student work", "id", c.Params("work_id"))
+		}
+
+		// students removed from the classroom can no longer request regrades
+		_, err = s.RequireAtLeastRole(c, int64(work.ClassroomID), models.Student)
+		if err != nil {
+			return err
+		}
+
+		if !work.GradesPublished() {
+			return errs.BadRequest(errors.New("grades have not been published for this work"))
+		}
+
+		var body models.RegradeRequestBody
+		if err := c.BodyParser(&body); err != nil {
+			return errs.InvalidRequestBody(body)
+		}
+		if body.FeedbackCommentID == 0 {
+			return errs.MissingAPIParamError("feedback_comment_id")
+		}
+		if body.Comment == "" {
+			return errs.MissingAPIParamError("comment")
+		}
+
+		request, err := s.store.CreateRegradeRequest(c.Context(), work.ID, body.FeedbackCommentID, *user.ID, body.Comment)
+		if err == errs.EmptyResult() {
+			return errs.BadRequest(errors.New("feedback comment does not exist on this work or already has an open regrade request"))
+		}
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"regrade_request": request,
+		})
+	}
+}
+
+// Returns the regrade requests on a student work.
+func (s *WorkService) getRegradeRequests() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		work, err := s.getWork(c)
+		if err != nil {
+			return err
+		}
+
+		requests, err := s.store.GetRegradeRequestsByWork(c.Context(), work.ID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"work_id":          work.ID,
+			"regrade_requests": requests,
+		})
+	}
+}
+
+// Resolves a regrade request on a student work, optionally changing the points of the regraded feedback comment.
+func (s *WorkService) resolveRegradeRequest() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		work, err := s.getWork(c)
+		if err != nil {
+			return err
+		}
+
+		grader, err := s.RequireAtLeastRole(c, int64(work.ClassroomID), models.TA)
+		if err != nil {
+			return err
+		}
+
+		regradeID, err := strconv.Atoi(c.Params("regrade_id"))
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		var body models.RegradeResolutionBody
+		if err := c.BodyParser(&body); err != nil {
+			return errs.InvalidRequestBody(body)
+		}
+		if body.Reason == "" {
+			return errs.MissingAPIParamError("reason")
+		}
+
+		request, event, err := s.store.ResolveRegradeRequest(c.Context(), work.ID, regradeID, *grader.ID, body)
+		if err == errs.EmptyResult() {
+			return errs.NotFound("open regrade request", "id", regradeID)
+		}
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"regrade_request": request,
+			"grade_event":     event,
+		})
+	}
+}
@@ -8,7 +8,7 @@ import (
 func WorkRoutes(router fiber.Router, service *WorkService) fiber.Router {
 	workRouter := router.Group(
 		"/classrooms/classroom/:classroom_id/assignments/assignment/:assignment_id/works",
-	).Use(middleware.Protected(service.userCfg.JWTSecret))
+	).Use(middleware.Protected(service.store, service.userCfg.JWTSecret))
 
 	// Get the student works for an assignment
 	workRouter.Get("/", service.getWorksInAssignment())
@@ -34,5 +34,41 @@ func WorkRoutes(router fiber.Router, service *WorkService) fiber.Router {
 	//Get the number of commits per day in the student work repo
 	workRouter.Get("/work/:work_id/commits-per-day", service.GetCommitsPerDay())
 
+	// Get the grade history of a student work
+	workRouter.Get("/work/:work_id/history", service.getWorkHistory())
+
+	// Publish the grades of a student work to the student
+	workRouter.Post("/work/:work_id/publish", service.publishWorkGrades())
+
+	// Get the submission history of a student work
+	workRouter.Get("/work/:work_id/submissions", service.getSubmissions())
+
+	// Pick the submission of a student work to grade
+	workRouter.Post("/work/:work_id/submissions/:submission_id/grade", service.selectSubmission())
+
+	// Get the regrade requests on a student work
+	workRouter.Get("/work/:work_id/regrades", service.getRegradeRequests())
+
+	// Resolve a regrade request on a student work
+	workRouter.Post("/work/:work_id/regrades/:regrade_id/resolve", service.resolveRegradeRequest())
+
+	// Restore push access to a student work locked at its due date
+	workRouter.Post("/work/:work_id/unlock", service.unlockWork())
+
 	return workRouter
 }
+
+func StudentWorkRoutes(router fiber.Router, service *WorkService) fiber.Router {
+	studentWorkRouter := router.Group("/student/works").Use(middleware.Protected(service.store, service.userCfg.JWTSecret))
+
+	// Get the authenticated student's works across all classrooms
+	studentWorkRouter.Get("/", service.getStudentWorks())
+
+	// Get one of the authenticated student's works (with feedback once published)
+	studentWorkRouter.Get("/work/:work_id", service.getStudentWork())
+
+	// Request a regrade of feedback on one of the authenticated student's works
+	studentWorkRouter.Post("/work/:work_id/regrades", service.requestRegrade())
+
+	return studentWorkRouter
+}
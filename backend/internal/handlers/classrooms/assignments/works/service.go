@@ -1,6 +1,8 @@
 package works
 
 import (
+	"context"
+
 	"github.com/CamPlume1/khoury-classroom/internal/config"
 	"github.com/CamPlume1/khoury-classroom/internal/github"
 	"github.com/CamPlume1/khoury-classroom/internal/middleware"
@@ -8,14 +10,16 @@ import (
 )
 
 type WorkService struct {
-	store     storage.Storage
-	userCfg   *config.GitHubUserClient
-	appClient github.GitHubAppClient
+	store      storage.Storage
+	userCfg    *config.GitHubUserClient
+	appClients github.GitHubAppInstallations
 	middleware.RoleChecker[WorkService]
 }
 
-func NewWorkService(store storage.Storage, userCfg *config.GitHubUserClient, appClient github.GitHubAppClient) *WorkService {
-	return &WorkService{store: store, userCfg: userCfg, appClient: appClient}
+func NewWorkService(store storage.Storage, userCfg *config.GitHubUserClient, appClients github.GitHubAppInstallations) *WorkService {
+	service := &WorkService{store: store, userCfg: userCfg, appClients: appClients}
+	service.RoleChecker = middleware.RoleChecker[WorkService]{Checkable: service}
+	return service
 }
 
 // Getter for store field
@@ -28,7 +32,7 @@ func (s *WorkService) GetUserCfg() *config.GitHubUserClient {
 	return s.userCfg
 }
 
-// Getter for appClient field
-func (s *WorkService) GetAppClient() github.GitHubAppClient {
-	return s.appClient
+// Gets the app client of the installation on an org
+func (s *WorkService) GetAppClient(ctx context.Context, orgName string) (github.GitHubAppClient, error) {
+	return s.appClients.ForOrg(ctx, orgName)
 }
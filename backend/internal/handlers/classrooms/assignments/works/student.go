@@ -0,0 +1,122 @@
+package works
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/middleware"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Helper function to build the student's view of a work, hiding scores until grades are published
+func toStudentFacingWork(work *models.StudentWorkWithContributors) models.StudentFacingWork {
+	studentWork := *work
+	if !studentWork.GradesPublished() {
+		studentWork.ManualFeedbackScore = nil
+		studentWork.AutoGraderScore = nil
+	}
+
+	return models.StudentFacingWork{
+		StudentWorkWithContributors: studentWork,
+		RepoURL:                     fmt.Sprintf("https://github.com/%s/%s", work.OrgName, work.RepoName),
+	}
+}
+
+// Returns the authenticated student's works across all of their classrooms.
+func (s *WorkService) getStudentWorks() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, _, user, err := middleware.GetClientAndUser(c, s.store, s.userCfg)
+		if err != nil {
+			return errs.AuthenticationError()
+		}
+
+		works, err := s.store.GetWorksByUserID(c.Context(), *user.ID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		studentWorks := []models.StudentFacingWork{}
+		for _, work := range works {
+			studentWorks = append(studentWorks, toStudentFacingWork(work))
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"student_works": studentWorks,
+		})
+	}
+}
+
+// Returns one of the authenticated student's works, with feedback once grades are published.
+func (s *WorkService) getStudentWork() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		studentWorkID, err := strconv.Atoi(c.Params("work_id"))
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		_, _, user, err := middleware.GetClientAndUser(c, s.store, s.userCfg)
+		if err != nil {
+			return errs.AuthenticationError()
+		}
+
+		// Students may only ever see works they contribute to
+		isContributor, err := s.store.IsWorkContributor(c.Context(), studentWorkID, *user.ID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+		if !isContributor {
+			return errs.NotFound("student work", "id", c.Params("work_id"))
+		}
+
+		work, err := s.store.GetWorkByID(c.Context(), studentWorkID)
+		if err != nil {
+			return errs.NotFound("student work", "id", c.Params("work_id"))
+		}
+
+		_, err = s.RequireAtLeastRole(c, int64(work.ClassroomID), models.Student)
+		if err != nil {
+			return err
+		}
+
+		feedback := []models.PRReviewCommentResponse{}
+		if work.GradesPublished() {
+			feedback, err = s.getReanchoredFeedback(c.Context(), work.StudentWork)
+			if err != nil {
+				return errs.InternalServerError()
+			}
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"student_work": toStudentFacingWork(work),
+			"feedback":     feedback,
+		})
+	}
+}
+
+// Publishes the grades of a student work, making its feedback and score visible to the student.
+func (s *WorkService) publishWorkGrades() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		work, err := s.getWork(c)
+		if err != nil {
+			return err
+		}
+
+		grader, err := s.RequireAtLeastRole(c, int64(work.ClassroomID), models.TA)
+		if err != nil {
+			return err
+		}
+
+		publishedAt, err := s.store.PublishWorkGrades(c.Context(), work.ID, *grader.ID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"work_id":                    work.ID,
+			"grades_published_timestamp": publishedAt,
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package works
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+// Returns the submission history of a student work, most recent first
+func (s *WorkService) getSubmissions() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		work, err := s.getWork(c)
+		if err != nil {
+			return err
+		}
+
+		submissions, err := s.store.GetSubmissionsByWork(c.Context(), work.ID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"work_id":     work.ID,
+			"submissions": submissions,
+		})
+	}
+}
+
+// Picks the submission graders review for a student work, instead of its latest commit
+func (s *WorkService) selectSubmission() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		work, err := s.getWork(c)
+		if err != nil {
+			return err
+		}
+
+		submissionID, err := strconv.Atoi(c.Params("submission_id"))
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		submission, err := s.store.SelectSubmissionForGrading(c.Context(), work.ID, submissionID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errs.NotFound("submission", "id", submissionID)
+			}
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"submission": submission,
+		})
+	}
+}
+
+// Gets the commit a work is graded at: the submission graders picked, or else the head of its submission branch
+func (s *WorkService) getGradingCommitSHA(ctx context.Context, work models.StudentWork) (string, error) {
+	submission, err := s.store.GetSelectedSubmission(ctx, work.ID)
+	if err == nil {
+		return submission.CommitSHA, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", err
+	}
+
+	assignment, err := s.store.GetAssignmentByID(ctx, int64(work.AssignmentOutlineID))
+	if err != nil {
+		return "", err
+	}
+
+	appClient, err := s.GetAppClient(ctx, work.OrgName)
+	if err != nil {
+		return "", err
+	}
+
+	return appClient.GetBranchHeadSHA(ctx, work.OrgName, work.RepoName, assignment.RepoLayout.SubmissionBranch)
+}
@@ -1,6 +1,7 @@
 package works
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -9,6 +10,7 @@ import (
 	"github.com/CamPlume1/khoury-classroom/internal/errs"
 	"github.com/CamPlume1/khoury-classroom/internal/middleware"
 	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/go-github/github"
 )
@@ -28,10 +30,10 @@ func (s *WorkService) getWork(c *fiber.Ctx) (*models.PaginatedStudentWorkWithCon
 		return nil, errs.BadRequest(err)
 	}
 
-	// _, err = s.RequireAtLeastRole(c, int64(classroomID), models.TA)
-	// if err != nil {
-	// 	return nil, err
-	// }
+	_, err = s.RequireAtLeastRole(c, int64(classroomID), models.TA)
+	if err != nil {
+		return nil, err
+	}
 
 	work, err := s.store.GetWork(c.Context(), classroomID, assignmentID, studentWorkID)
 	if err != nil {
@@ -57,9 +59,15 @@ func (s *WorkService) getWorksInAssignment() fiber.Handler {
 			return errs.BadRequest(err)
 		}
 
-		assignmentOutline, err := s.store.GetAssignmentByID(c.Context(), int64(assignmentID))
+		_, err = s.RequireAtLeastRole(c, int64(classroomID), models.TA)
 		if err != nil {
-			return errs.InternalServerError()
+			return err
+		}
+
+		// the assignment must belong to the classroom the caller was authorized for
+		assignmentOutline, err := s.store.GetAssignmentByID(c.Context(), int64(assignmentID))
+		if err != nil || assignmentOutline.ClassroomID != int64(classroomID) {
+			return errs.NotFound("assignment", "id", c.Params("assignment_id"))
 		}
 
 		assignmentTemplate, err := s.store.GetAssignmentTemplateByID(c.Context(), assignmentOutline.TemplateID)
@@ -67,12 +75,13 @@ func (s *WorkService) getWorksInAssignment() fiber.Handler {
 			return errs.InternalServerError()
 		}
 
-		// _, err = s.RequireAtLeastRole(c, int64(classroomID), models.TA)
-		// if err != nil {
-		// 	return err
-		// }
+		// optionally only show the works of one section's students
+		sectionID, err := utils.ParseOptionalID(c.Query("section_id"))
+		if err != nil {
+			return errs.BadRequest(err)
+		}
 
-		works, err := s.store.GetWorks(c.Context(), classroomID, assignmentID)
+		works, err := s.store.GetWorks(c.Context(), classroomID, assignmentID, sectionID)
 		if err != nil {
 			return err
 		}
@@ -84,6 +93,13 @@ func (s *WorkService) getWorksInAssignment() fiber.Handler {
 		}
 
 		students := filterStudents(users)
+		if sectionID != nil {
+			members, err := s.store.GetSectionMembers(c.Context(), *sectionID)
+			if err != nil {
+				return errs.InternalServerError()
+			}
+			students = filterSectionStudents(students, members)
+		}
 		studentsWithoutWorks := filterStudentsWithoutWorks(students, works)
 
 		mockWorks := []*models.StudentWorkWithContributors{}
@@ -138,6 +154,22 @@ func filterStudents(users []models.ClassroomUser) []models.ClassroomUser {
 	return students
 }
 
+// filters out students who are not in the section
+func filterSectionStudents(students []models.ClassroomUser, members []models.SectionMember) []models.ClassroomUser {
+	inSection := make(map[int64]bool)
+	for _, member := range members {
+		inSection[*member.ID] = true
+	}
+
+	var sectionStudents []models.ClassroomUser
+	for _, student := range students {
+		if inSection[*student.ID] {
+			sectionStudents = append(sectionStudents, student)
+		}
+	}
+	return sectionStudents
+}
+
 // filters out students who haven't accepted the assignment
 func filterStudentsWithoutWorks(students []models.ClassroomUser, works []*models.StudentWorkWithContributors) []models.ClassroomUser {
 	var studentsWithoutWorks []models.ClassroomUser
@@ -169,7 +201,7 @@ func (s *WorkService) getWorkByID() fiber.Handler {
 			return err
 		}
 
-		feedback, err := s.store.GetFeedbackOnWork(c.Context(), work.ID)
+		feedback, err := s.getReanchoredFeedback(c.Context(), work.StudentWork)
 		if err != nil {
 			return errs.InternalServerError()
 		}
@@ -202,27 +234,49 @@ func formatFeedbackForGitHub(comments []models.PRReviewCommentResponse) []models
 	return formattedComments
 }
 
-func insertFeedbackInDB(s *WorkService, c *fiber.Ctx, comments []models.PRReviewCommentResponse, taUserID int64, workID int) error {
-	// insert into DB, remove points field and format the body to display the points
-	for _, comment := range comments {
-		// insert into DB
-		if comment.RubricItemID == nil {
-			// create new rubric item and then attach
-			err := s.store.CreateFeedbackComment(c.Context(), taUserID, workID, comment)
-			if err != nil {
-				return errs.InternalServerError()
-			}
-		} else {
-			// attach rubric item
-			err := s.store.CreateFeedbackCommentFromRubricItem(c.Context(), taUserID, workID, comment)
-			if err != nil {
-				return errs.InternalServerError()
-			}
+// Checks that a comment is anchored to nothing, a whole file, a single line, or a valid line range
+func validateFeedbackAnchor(comment models.PRReviewCommentResponse) error {
+	if comment.Line != nil && comment.Path == nil {
+		return errs.BadRequest(errors.New("a line comment requires a file path"))
+	}
+	if comment.StartLine != nil {
+		if comment.Line == nil {
+			return errs.BadRequest(errors.New("a line range requires an end line"))
+		}
+		if *comment.StartLine > *comment.Line {
+			return errs.BadRequest(errors.New("start line must not be after end line"))
 		}
 	}
 	return nil
 }
 
+// Checks that a comment is created, edited or deleted, and that edits and deletions say which comment they change
+func validateFeedbackAction(comment models.PRReviewCommentResponse) error {
+	switch comment.Action {
+	case "", models.PRReviewCommentActionCreate:
+		return nil
+	case models.PRReviewCommentActionEdit, models.PRReviewCommentActionDelete:
+		if comment.FeedbackCommentID == nil {
+			return errs.MissingAPIParamError("feedback_comment_id")
+		}
+		return nil
+	default:
+		return errs.BadRequest(fmt.Errorf("unknown feedback action %q", comment.Action))
+	}
+}
+
+func insertFeedbackInDB(s *WorkService, c *fiber.Ctx, comments []models.PRReviewCommentResponse, taUserID int64, workID int) ([]models.GradeEvent, error) {
+	// insert into DB along with the grade history, all or nothing
+	events, err := s.store.ApplyFeedbackChanges(c.Context(), taUserID, workID, comments)
+	if err == errs.EmptyResult() {
+		return nil, errs.NotFound("feedback comment", "work id", workID)
+	}
+	if err != nil {
+		return nil, errs.InternalServerError()
+	}
+	return events, nil
+}
+
 func (s *WorkService) gradeWorkByID() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// get the work first
@@ -249,19 +303,53 @@ func (s *WorkService) gradeWorkByID() fiber.Handler {
 		if err := c.BodyParser(&requestBody); err != nil {
 			return errs.InvalidRequestBody(requestBody)
 		}
+		for _, comment := range requestBody.Comments {
+			if err := validateFeedbackAnchor(comment); err != nil {
+				return err
+			}
+			if err := validateFeedbackAction(comment); err != nil {
+				return err
+			}
+		}
 
-		// create PR review via github API
-		review, err := userClient.CreatePRReview(c.Context(), work.OrgName, work.RepoName, requestBody.Body, formatFeedbackForGitHub(requestBody.Comments))
+		// anchor new and edited feedback to the commit being reviewed
+		gradingSHA, err := s.getGradingCommitSHA(c.Context(), work.StudentWork)
 		if err != nil {
 			return errs.GithubAPIError(err)
 		}
+		for i := range requestBody.Comments {
+			requestBody.Comments[i].CommitSHA = &gradingSHA
+		}
+
+		// edits and deletions must change feedback already on this work
+		feedback, err := s.store.GetFeedbackOnWork(c.Context(), work.ID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+		existing := make(map[int]models.PRReviewCommentResponse)
+		for _, comment := range feedback {
+			existing[*comment.FeedbackCommentID] = comment
+		}
+		for _, comment := range requestBody.Comments {
+			if comment.Action != models.PRReviewCommentActionEdit && comment.Action != models.PRReviewCommentActionDelete {
+				continue
+			}
+			if _, ok := existing[*comment.FeedbackCommentID]; !ok {
+				return errs.NotFound("feedback comment", "id", *comment.FeedbackCommentID)
+			}
+		}
 
-		// insert into DB
-		err = insertFeedbackInDB(s, c, requestBody.Comments, *taUser.ID, work.ID)
+		// save the feedback before posting it, so GitHub is never ahead of the grade
+		events, err := insertFeedbackInDB(s, c, requestBody.Comments, *taUser.ID, work.ID)
 		if err != nil {
 			return err
 		}
 
+		review, err := s.postFeedbackToGitHub(c.Context(), userClient, work.OrgName, work.RepoName, gradingSHA, requestBody.Body, requestBody.Comments, existing, events)
+		if err != nil {
+			return errs.GithubAPIError(err)
+		}
+
 		return c.Status(http.StatusOK).JSON(fiber.Map{
 			"review": review,
 		})
@@ -289,9 +377,14 @@ func (s *WorkService) GetCommitsPerDay() fiber.Handler {
 			return err
 		}
 
+		appClient, err := s.GetAppClient(c.Context(), work.OrgName)
+		if err != nil {
+			return err
+		}
+
 		var opts github.CommitsListOptions
 		opts.Author = work.Contributors[0].GithubUsername
-		commits, err := s.appClient.ListCommits(c.Context(), work.OrgName, work.RepoName, &opts)
+		commits, err := appClient.ListCommits(c.Context(), work.OrgName, work.RepoName, &opts)
 		if err != nil {
 			return errs.GithubAPIError(err)
 		}
@@ -325,3 +418,23 @@ func (s *WorkService) GetFirstCommitDate() fiber.Handler {
 		})
 	}
 }
+
+// Returns the grade history of a student work.
+func (s *WorkService) getWorkHistory() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		work, err := s.getWork(c)
+		if err != nil {
+			return err
+		}
+
+		events, err := s.store.GetGradeEventsByWork(c.Context(), work.ID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"work_id":      work.ID,
+			"grade_events": events,
+		})
+	}
+}
@@ -15,10 +15,81 @@ import (
 	"github.com/CamPlume1/khoury-classroom/internal/github"
 	"github.com/CamPlume1/khoury-classroom/internal/middleware"
 	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/provisioning"
 	"github.com/CamPlume1/khoury-classroom/internal/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
+// Creates a classroom with a new student team in its org, and makes the user its professor. The user must be an
+// admin of the org.
+func (s *ClassroomService) setUpClassroom(ctx context.Context, client github.GitHubUserClient, githubUser models.GitHubUser, user models.User, classroomData models.Classroom) (models.Classroom, error) {
+	// check if classroom exists already
+	exists, err := s.doesClassroomExist(ctx, classroomData.Name)
+	if err != nil {
+		return models.Classroom{}, errs.InternalServerError()
+	} else if exists {
+		return models.Classroom{}, errs.NewAPIError(http.StatusConflict, errors.New("classroom already exists"))
+	}
+
+	membership, err := client.GetUserOrgMembership(ctx, classroomData.OrgName, githubUser.Login)
+	if err != nil || *membership.Role != "admin" {
+		return models.Classroom{}, errs.InsufficientPermissionsError()
+	}
+
+	// Staff teams are never deleted, as one this classroom didn't create may be in use by another
+	if provisioning.StaffTeamTaken(ctx, client, classroomData) {
+		return models.Classroom{}, errs.NewAPIError(http.StatusConflict,
+			errors.New("team "+provisioning.StaffTeamName(classroomData)+" already exists in the organization"))
+	}
+
+	// Determine the team name for the classroom
+	studentTeamName := strings.ReplaceAll(strings.ToLower(classroomData.Name), " ", "-") + "-students"
+	classroomData.StudentTeamName = &studentTeamName
+
+	// Handle existing student team
+	existingTeam, err := client.GetTeamByName(ctx, classroomData.OrgName, studentTeamName)
+	if err == nil && existingTeam != nil {
+		// Team exists - delete it first
+		err = client.DeleteTeam(ctx, *existingTeam.ID)
+		if err != nil {
+			return models.Classroom{}, errs.InternalServerError()
+		}
+	}
+
+	// Create the student team
+	description := "The students of " + classroomData.OrgName + " - " + classroomData.Name + ".\n\nAutomatically generated by Khoury Classroom."
+	maintainers := []string{githubUser.Login}
+	_, err = client.CreateTeam(ctx, classroomData.OrgName, *classroomData.StudentTeamName, &description, maintainers)
+	if err != nil {
+		return models.Classroom{}, errs.InternalServerError()
+	}
+
+	// Create the classroom
+	createdClassroom, err := s.store.CreateClassroom(ctx, classroomData)
+	if err != nil {
+		return models.Classroom{}, errs.InternalServerError()
+	}
+
+	// Add the user as a professor to the classroom
+	_, err = s.store.AddUserToClassroom(ctx, createdClassroom.ID, string(models.Professor), models.UserStatusActive, *user.ID)
+	if err != nil {
+		return models.Classroom{}, errs.InternalServerError()
+	}
+
+	// Create the staff team, which TAs and professors join and which gets access to every repository, and make the
+	// professor its maintainer. The team is recorded on the classroom, the same way it is created for older classrooms.
+	err = provisioning.AddStaffTeamMember(ctx, s.store, client, createdClassroom, githubUser.Login, models.Professor)
+	if err != nil {
+		return models.Classroom{}, errs.GithubAPIError(err)
+	}
+	createdClassroom, err = s.store.GetClassroomByID(ctx, createdClassroom.ID)
+	if err != nil {
+		return models.Classroom{}, errs.InternalServerError()
+	}
+
+	return createdClassroom, nil
+}
+
 // Helper method to check if a classroom exists
 func (s *ClassroomService) doesClassroomExist(ctx context.Context, name string) (bool, error) {
 	_, err := s.store.GetClassroomByName(ctx, name)
@@ -93,52 +164,13 @@ func (s *ClassroomService) createClassroom() fiber.Handler {
 		if err != nil {
 			return errs.InvalidRequestBody(models.Classroom{})
 		}
-
-		// check if classroom exists already
-		exists, err := s.doesClassroomExist(c.Context(), classroomData.Name)
-		if err != nil {
-			return errs.InternalServerError()
-		} else if exists {
-			return c.Status(http.StatusConflict).SendString("Classroom already exists")
-		}
-
-		membership, err := client.GetUserOrgMembership(c.Context(), classroomData.OrgName, githubUser.Login)
-		if err != nil || *membership.Role != "admin" {
-			return errs.InsufficientPermissionsError()
-		}
-
-		// Determine the team name for the classroom
-		studentTeamName := strings.ReplaceAll(strings.ToLower(classroomData.Name), " ", "-") + "-students"
-		classroomData.StudentTeamName = &studentTeamName
-
-		// Handle existing student team
-		existingTeam, err := client.GetTeamByName(c.Context(), classroomData.OrgName, studentTeamName)
-		if err == nil && existingTeam != nil {
-			// Team exists - delete it first
-			err = client.DeleteTeam(c.Context(), *existingTeam.ID)
-			if err != nil {
-				return errs.InternalServerError()
-			}
-		}
-
-		// Create the student team
-		description := "The students of " + classroomData.OrgName + " - " + classroomData.Name + ".\n\nAutomatically generated by Khoury Classroom."
-		maintainers := []string{githubUser.Login}
-		_, err = client.CreateTeam(c.Context(), classroomData.OrgName, *classroomData.StudentTeamName, &description, maintainers)
-		if err != nil {
-			return errs.InternalServerError()
-		}
-
-		// Create the classroom
-		createdClassroom, err := s.store.CreateClassroom(c.Context(), classroomData)
-		if err != nil {
-			return errs.InternalServerError()
+		if err := setRepoNameTemplates(&classroomData); err != nil {
+			return errs.BadRequest(err)
 		}
 
-		// Add the user as a professor to the classroom
-		_, err = s.store.AddUserToClassroom(c.Context(), createdClassroom.ID, string(models.Professor), models.UserStatusActive, *user.ID)
+		createdClassroom, err := s.setUpClassroom(c.Context(), client, githubUser, user, classroomData)
 		if err != nil {
-			return errs.InternalServerError()
+			return err
 		}
 
 		return c.Status(http.StatusOK).JSON(fiber.Map{"classroom": createdClassroom})
@@ -166,6 +198,10 @@ func (s *ClassroomService) updateClassroom() fiber.Handler {
 			return err
 		}
 
+		if err := setRepoNameTemplates(&classroomData); err != nil {
+			return errs.BadRequest(err)
+		}
+
 		updatedClassroom, err := s.store.UpdateClassroom(c.Context(), classroomData)
 		if err != nil {
 			return errs.InternalServerError()
@@ -243,7 +279,7 @@ func (s *ClassroomService) getClassroomUsers() fiber.Handler {
 		updatedUsersInClassroom := []models.ClassroomUser{}
 
 		for _, classroomUser := range usersInClassroom {
-			newClassroomUser, err := s.updateUserStatus(c.Context(), s.appClient, classroomUser.User, classroom)
+			newClassroomUser, err := s.updateUserStatus(c.Context(), classroomUser.User, classroom)
 			// don't include members who are not in the org
 			if newClassroomUser.Status == models.UserStatusRemoved {
 				continue
@@ -317,8 +353,13 @@ func (s *ClassroomService) removeUserFromClassroom() fiber.Handler {
 			return errs.InternalServerError()
 		}
 
+		appClient, err := s.GetAppClient(c.Context(), classroom.OrgName)
+		if err != nil {
+			return err
+		}
+
 		// remove the user from the org and the github student team
-		err = s.appClient.RemoveUserFromOrganization(c.Context(), classroom.OrgName, toBeRemovedUser.GithubUsername)
+		err = appClient.RemoveUserFromOrganization(c.Context(), classroom.OrgName, toBeRemovedUser.GithubUsername)
 		if err != nil {
 			log.Default().Println("Warning: Failed to remove user from org, ", err)
 
@@ -353,13 +394,13 @@ func (s *ClassroomService) generateClassroomToken() fiber.Handler {
 		}
 
 		// Only allow professors to invite people to classrooms
-		_, err = s.RequireAtLeastRole(c, classroomID, models.Professor)
+		creator, err := s.RequireAtLeastRole(c, classroomID, models.Professor)
 		if err != nil {
 			return err
 		}
 
 		// if the link is permenant, use the existing permanent token
-		if body.Duration == nil {
+		if body.Duration == nil && !body.HasLimits() {
 			classroomToken, err := s.store.GetPermanentClassroomTokenByClassroomIDAndRole(c.Context(), classroomID, classroomRole)
 			if err == nil {
 				return c.Status(http.StatusOK).JSON(fiber.Map{"token": classroomToken.Token})
@@ -375,9 +416,14 @@ func (s *ClassroomService) generateClassroomToken() fiber.Handler {
 			ClassroomID:   classroomID,
 			ClassroomRole: classroomRole,
 			BaseToken: models.BaseToken{
-				Token: token,
+				Token:     token,
+				CreatedBy: creator.ID,
 			},
 		}
+		err = body.Apply(&tokenData.BaseToken)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
 
 		// Set ExpiresAt only if Duration is provided
 		if body.Duration != nil {
@@ -414,8 +460,19 @@ func (s *ClassroomService) useClassroomToken() fiber.Handler {
 		}
 
 		// Check if the token is valid
-		if classroomToken.ExpiresAt != nil && classroomToken.ExpiresAt.Before(time.Now()) {
-			return errs.ExpiredTokenError()
+		err = classroomToken.Usable(time.Now())
+		if err != nil {
+			return err
+		}
+		err = middleware.CheckTokenAllowlist(c.Context(), client, classroomToken.BaseToken, user.GithubUsername)
+		if err != nil {
+			return err
+		}
+
+		// Count the use, checking again in case the token ran out or was revoked in the meantime
+		classroomToken, err = s.store.RedeemClassroomToken(c.Context(), token, *user.ID)
+		if err != nil {
+			return err
 		}
 
         message, classroom, classroomUser, err := s.inviteUserToClassroom(
@@ -449,7 +506,7 @@ func (s *ClassroomService) inviteUserToClassroom(ctx context.Context, classroomI
 		}
     }	
 
-	classroomUser, err = s.updateUserStatus(ctx, s.appClient, *invitee, classroom)
+	classroomUser, err = s.updateUserStatus(ctx, *invitee, classroom)
 	if err != nil {
 		return "", models.Classroom{}, models.ClassroomUser{}, errs.InternalServerError()
 	}
@@ -464,6 +521,17 @@ func (s *ClassroomService) inviteUserToClassroom(ctx context.Context, classroomI
         return "Token applied successfully, user access has been requested", classroom, classroomUser, nil
 	}
 
+	// users in the requested state wait for a professor, unless the classroom approves them automatically
+	if classroomUser.Status == models.UserStatusRequested {
+		approved, err := s.isAutoApproved(ctx, classroomID, *invitee.ID, userClient)
+		if err != nil {
+			return "", models.Classroom{}, models.ClassroomUser{}, errs.InternalServerError()
+		}
+		if !approved {
+			return "Token applied successfully, user access has been requested", classroom, classroomUser, nil
+		}
+	}
+
 	// user is already in the classroom. If their role can be upgraded, do so. Don't downgrade them.
 	roleComparison := classroomUser.Role.Compare(classroomRole)
 	if roleComparison < 0 {
@@ -475,7 +543,7 @@ func (s *ClassroomService) inviteUserToClassroom(ctx context.Context, classroomI
 	}
 
 	// Invite the user to the organization
-	classroomUser, err = s.inviteUserToOrganization(ctx, s.appClient, classroom, classroomRole, *invitee)
+	classroomUser, err = s.inviteUserToOrganization(ctx, classroom, classroomRole, *invitee)
 	if err != nil {
 		return "", models.Classroom{}, models.ClassroomUser{}, errs.InternalServerError()
 	}
@@ -506,7 +574,7 @@ func (s *ClassroomService) getCurrentClassroomUser() fiber.Handler {
 			return errs.InternalServerError()
 		}
 
-		classroomUser, err := s.updateUserStatus(c.Context(), s.appClient, user, classroom)
+		classroomUser, err := s.updateUserStatus(c.Context(), user, classroom)
 		if err != nil {
 			if err == errs.UserNotFoundInClassroomError() {
 				// User not found in classroom, return null
@@ -521,13 +589,18 @@ func (s *ClassroomService) getCurrentClassroomUser() fiber.Handler {
 }
 
 // Updates the user's status in our DB to reflect their org membership, as of this moment
-// Note: currently only works for the app client as the user client doesn't ask for the right permissions
-func (s *ClassroomService) updateUserStatus(ctx context.Context, client github.GitHubBaseClient, user models.User, classroom models.Classroom) (models.ClassroomUser, error) {
+// Note: uses the app client of the classroom's org, as the user client doesn't ask for the right permissions
+func (s *ClassroomService) updateUserStatus(ctx context.Context, user models.User, classroom models.Classroom) (models.ClassroomUser, error) {
 	classroomUser, err := s.store.GetUserInClassroom(ctx, classroom.ID, *user.ID)
 	if err != nil {
 		return models.ClassroomUser{}, errs.UserNotFoundInClassroomError()
 	}
 
+	client, err := s.GetAppClient(ctx, classroom.OrgName)
+	if err != nil {
+		return models.ClassroomUser{}, err
+	}
+
 	// if the user has been removed from the classroom, don't update their org membership
 	if classroomUser.Status == models.UserStatusRemoved {
 		return classroomUser, nil
@@ -594,7 +667,7 @@ func (s *ClassroomService) sendOrganizationInviteToUser() fiber.Handler {
 		}
 
 		// use the current user's client to invite the user to the organization
-		invitee, err = s.inviteUserToOrganization(c.Context(), s.appClient, classroom, classroomRole, invitee.User)
+		invitee, err = s.inviteUserToOrganization(c.Context(), classroom, classroomRole, invitee.User)
 		if err != nil {
 			return errs.InternalServerError()
 		}
@@ -686,57 +759,18 @@ func (s *ClassroomService) revokeOrganizationInvite() fiber.Handler {
 	}
 }
 
-// Helper function to invite a user to the organization (delegates based on the role supplied)
-func (s *ClassroomService) inviteUserToOrganization(ctx context.Context, client github.GitHubBaseClient, classroom models.Classroom, classroomRole models.ClassroomRole, user models.User) (models.ClassroomUser, error) {
-	var classroomUser models.ClassroomUser
-	var err error
-	if classroomRole == models.Student {
-		// Get the team ID
-		studentTeam, err := client.GetTeamByName(ctx, classroom.OrgName, *classroom.StudentTeamName)
-		if err != nil {
-			return models.ClassroomUser{}, errs.InternalServerError()
-		}
-
-		// Invite the user to the organization
-		classroomUser, err = s.inviteMemberToOrganization(ctx, client, *studentTeam.ID, classroom.ID, user)
-		if err != nil {
-			return models.ClassroomUser{}, errs.InternalServerError()
-		}
-	} else {
-		// Invite the user to the organization
-		classroomUser, err = s.inviteAdminToOrganization(ctx, client, classroom.OrgName, classroom.ID, user)
-		if err != nil {
-			return models.ClassroomUser{}, errs.InternalServerError()
-		}
-	}
-
-	return classroomUser, nil
-}
-
-// Helper function to invite a student to the organization (adds them to the student team as well on acceptance)
-func (s *ClassroomService) inviteMemberToOrganization(context context.Context, client github.GitHubBaseClient, teamID int64, classroomID int64, invitee models.User) (models.ClassroomUser, error) {
-	err := client.AddTeamMember(context, teamID, invitee.GithubUsername, nil)
+// Helper function to invite a user to the organization for the role supplied
+func (s *ClassroomService) inviteUserToOrganization(ctx context.Context, classroom models.Classroom, classroomRole models.ClassroomRole, user models.User) (models.ClassroomUser, error) {
+	client, err := s.GetAppClient(ctx, classroom.OrgName)
 	if err != nil {
-		return models.ClassroomUser{}, errs.InternalServerError()
-	}
-	classroomUser, err := s.store.ModifyUserStatus(context, classroomID, models.UserStatusOrgInvited, *invitee.ID)
-	if err != nil {
-		return models.ClassroomUser{}, errs.InternalServerError()
+		return models.ClassroomUser{}, err
 	}
 
-	return classroomUser, nil
-}
-
-// Helper function to invite an admin to the organization
-func (s *ClassroomService) inviteAdminToOrganization(context context.Context, client github.GitHubBaseClient, orgName string, classroomID int64, invitee models.User) (models.ClassroomUser, error) {
-	err := client.SetUserMembershipInOrg(context, orgName, invitee.GithubUsername, "admin")
-	if err != nil {
-		return models.ClassroomUser{}, errs.InternalServerError()
-	}
-	classroomUser, err := s.store.ModifyUserStatus(context, classroomID, models.UserStatusOrgInvited, *invitee.ID)
+	classroomUser, err := provisioning.InviteToOrganization(ctx, s.store, client, classroom, classroomRole, user)
 	if err != nil {
 		return models.ClassroomUser{}, errs.InternalServerError()
 	}
+
 	return classroomUser, nil
 }
 
@@ -804,3 +838,19 @@ func stringInList(list []string, str string) bool {
 	}
 	return false
 }
+
+// Defaults any repository naming template the classroom leaves unset and validates the rest
+func setRepoNameTemplates(classroom *models.Classroom) error {
+	if classroom.BaseRepoNameTemplate == "" {
+		classroom.BaseRepoNameTemplate = models.DefaultBaseRepoNameTemplate
+	}
+	if classroom.WorkRepoNameTemplate == "" {
+		classroom.WorkRepoNameTemplate = models.DefaultWorkRepoNameTemplate
+	}
+
+	err := utils.ValidateRepoNameTemplate(classroom.BaseRepoNameTemplate, utils.RepoNameAssignment)
+	if err != nil {
+		return err
+	}
+	return utils.ValidateRepoNameTemplate(classroom.WorkRepoNameTemplate, utils.RepoNameAssignment, utils.RepoNameLogin)
+}
@@ -0,0 +1,118 @@
+package classrooms
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/middleware"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Creates a new classroom and student team from an existing classroom, in the same or another org. Rubrics are
+// copied right away, while the assignments and their base repositories are copied in the background with their
+// dates shifted by the given offset. Rosters are not copied.
+func (s *ClassroomService) cloneClassroom() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		client, githubUser, user, err := middleware.GetClientAndUser(c, s.store, s.userCfg)
+		if err != nil {
+			return errs.AuthenticationError()
+		}
+
+		classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		// Only allow professors to clone classrooms
+		_, err = s.RequireAtLeastRole(c, classroomID, models.Professor)
+		if err != nil {
+			return err
+		}
+
+		var body models.ClassroomCloneRequestBody
+		if err := c.BodyParser(&body); err != nil {
+			return errs.InvalidRequestBody(models.ClassroomCloneRequestBody{})
+		}
+		body.Name = strings.TrimSpace(body.Name)
+		if body.Name == "" {
+			return errs.BadRequest(errors.New("classroom name is required"))
+		}
+		if (body.OrgID == nil) != (body.OrgName == nil) {
+			return errs.BadRequest(errors.New("org_id and org_name must be given together"))
+		}
+
+		source, err := s.store.GetClassroomByID(c.Context(), classroomID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		target := models.Classroom{
+			Name:                 body.Name,
+			OrgID:                source.OrgID,
+			OrgName:              source.OrgName,
+			BaseRepoNameTemplate: source.BaseRepoNameTemplate,
+			WorkRepoNameTemplate: source.WorkRepoNameTemplate,
+		}
+		if body.OrgID != nil {
+			target.OrgID = *body.OrgID
+			target.OrgName = *body.OrgName
+		}
+
+		target, err = s.setUpClassroom(c.Context(), client, githubUser, user, target)
+		if err != nil {
+			return err
+		}
+
+		clone, assignments, err := s.store.QueueClassroomClone(c.Context(), models.ClassroomClone{
+			SourceClassroomID: source.ID,
+			TargetClassroomID: target.ID,
+			DueDateOffsetDays: body.DueDateOffsetDays,
+			CreatedBy:         user.ID,
+		}, target.OrgID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"classroom": target,
+			"clone":     models.NewClassroomCloneProgress(clone, assignments),
+		})
+	}
+}
+
+// Lists the clones made from a classroom with the progress of their assignment copies
+func (s *ClassroomService) getClassroomClones() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		_, err = s.RequireAtLeastRole(c, classroomID, models.Professor)
+		if err != nil {
+			return err
+		}
+
+		clones, err := s.store.GetClassroomClones(c.Context(), classroomID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		progress := []models.ClassroomCloneProgress{}
+		for _, clone := range clones {
+			assignments, err := s.store.GetClassroomCloneAssignments(c.Context(), clone.ID)
+			if err != nil {
+				return errs.InternalServerError()
+			}
+			progress = append(progress, models.NewClassroomCloneProgress(clone, assignments))
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"clones": progress,
+		})
+	}
+}
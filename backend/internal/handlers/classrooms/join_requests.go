@@ -0,0 +1,278 @@
+package classrooms
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/github"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Lists the users waiting in the requested state, filtered by role, a search over their names, whether they were
+// imported from a roster, and whether they have already been approved
+func (s *ClassroomService) getJoinRequests() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		_, err = s.RequireAtLeastRole(c, classroomID, models.Professor)
+		if err != nil {
+			return err
+		}
+
+		filter := models.JoinRequestFilter{Search: strings.TrimSpace(c.Query("q"))}
+		if c.Query("role") != "" {
+			role, err := models.NewClassroomRole(c.Query("role"))
+			if err != nil {
+				return errs.BadRequest(err)
+			}
+			filter.Role = &role
+		}
+		filter.OnRoster, err = utils.ParseOptionalBool(c.Query("on_roster"))
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+		filter.Approved, err = utils.ParseOptionalBool(c.Query("approved"))
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		requests, err := s.store.GetJoinRequests(c.Context(), classroomID, filter)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{"requests": requests})
+	}
+}
+
+// Approves requested users in bulk. Their invitations to the org and the classroom's team are sent in the
+// background, so each user's result only says whether their approval was queued.
+func (s *ClassroomService) approveJoinRequests() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		approver, err := s.RequireAtLeastRole(c, classroomID, models.Professor)
+		if err != nil {
+			return err
+		}
+
+		userIDs, err := parseJoinRequestsBody(c)
+		if err != nil {
+			return err
+		}
+
+		approvals, err := s.store.QueueJoinApprovals(c.Context(), classroomID, userIDs, *approver.ID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+		queued := make(map[int64]models.JoinApproval)
+		for _, approval := range approvals {
+			queued[approval.UserID] = approval
+		}
+
+		results := []models.JoinRequestResult{}
+		for _, userID := range userIDs {
+			result := models.JoinRequestResult{UserID: userID}
+			if approval, ok := queued[userID]; ok {
+				result.Status = models.JoinRequestQueued
+				result.Approval = &approval
+			} else if s.isRequested(c.Context(), classroomID, userID) {
+				// still requested but not queued, so an earlier approval is on its way
+				result.Status = models.JoinRequestAlreadyQueued
+			} else {
+				result.Status = models.JoinRequestNotRequested
+			}
+			results = append(results, result)
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{"results": results})
+	}
+}
+
+// Denies requested users in bulk, removing them from the classroom
+func (s *ClassroomService) denyJoinRequests() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		_, err = s.RequireAtLeastRole(c, classroomID, models.Professor)
+		if err != nil {
+			return err
+		}
+
+		userIDs, err := parseJoinRequestsBody(c)
+		if err != nil {
+			return err
+		}
+
+		results := []models.JoinRequestResult{}
+		for _, userID := range userIDs {
+			result := models.JoinRequestResult{UserID: userID, Status: models.JoinRequestDenied}
+			if !s.isRequested(c.Context(), classroomID, userID) {
+				result.Status = models.JoinRequestNotRequested
+			} else if err := s.store.RemoveUserFromClassroom(c.Context(), classroomID, userID); err != nil {
+				result.Status = models.JoinRequestFailed
+				message := err.Error()
+				result.Error = &message
+			}
+			results = append(results, result)
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{"results": results})
+	}
+}
+
+// Lists the approvals made in a classroom with the status of their invitations, newest first
+func (s *ClassroomService) getJoinApprovals() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		_, err = s.RequireAtLeastRole(c, classroomID, models.Professor)
+		if err != nil {
+			return err
+		}
+
+		approvals, err := s.store.GetJoinApprovals(c.Context(), classroomID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{"approvals": approvals})
+	}
+}
+
+func (s *ClassroomService) getJoinSettings() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		_, err = s.RequireAtLeastRole(c, classroomID, models.Professor)
+		if err != nil {
+			return err
+		}
+
+		settings, err := s.store.GetJoinSettings(c.Context(), classroomID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{"settings": settings})
+	}
+}
+
+// Sets whether users joining through the classroom's links need approval, and which of them are approved
+// automatically
+func (s *ClassroomService) updateJoinSettings() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		_, err = s.RequireAtLeastRole(c, classroomID, models.Professor)
+		if err != nil {
+			return err
+		}
+
+		var settings models.JoinSettings
+		if err := c.BodyParser(&settings); err != nil {
+			return errs.InvalidRequestBody(models.JoinSettings{})
+		}
+		settings.ClassroomID = classroomID
+
+		domains := []string{}
+		for _, domain := range settings.AutoApproveEmailDomains {
+			domain = strings.TrimPrefix(strings.TrimSpace(domain), "@")
+			if domain != "" {
+				domains = append(domains, strings.ToLower(domain))
+			}
+		}
+		settings.AutoApproveEmailDomains = domains
+
+		settings, err = s.store.UpdateJoinSettings(c.Context(), settings)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{"settings": settings})
+	}
+}
+
+// Whether a user joining through a link skips approval: either the classroom doesn't require it, or the user is
+// on its roster or has a verified email on one of its domains
+func (s *ClassroomService) isAutoApproved(ctx context.Context, classroomID int64, userID int64, userClient github.GitHubUserClient) (bool, error) {
+	settings, err := s.store.GetJoinSettings(ctx, classroomID)
+	if err != nil {
+		return false, err
+	}
+	if !settings.RequireApproval {
+		return true, nil
+	}
+
+	if settings.AutoApproveRoster {
+		onRoster, err := s.store.IsOnClassroomRoster(ctx, classroomID, userID)
+		if err != nil {
+			return false, err
+		}
+		if onRoster {
+			return true, nil
+		}
+	}
+
+	if len(settings.AutoApproveEmailDomains) > 0 {
+		emails, err := userClient.GetVerifiedEmails(ctx)
+		if err != nil {
+			return false, err
+		}
+		if models.EmailMatchesDomains(emails, settings.AutoApproveEmailDomains) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *ClassroomService) isRequested(ctx context.Context, classroomID int64, userID int64) bool {
+	classroomUser, err := s.store.GetUserInClassroom(ctx, classroomID, userID)
+	return err == nil && classroomUser.Status == models.UserStatusRequested
+}
+
+// Parses the users of a bulk approval or denial, dropping duplicates
+func parseJoinRequestsBody(c *fiber.Ctx) ([]int64, error) {
+	var body models.JoinRequestsRequestBody
+	if err := c.BodyParser(&body); err != nil {
+		return nil, errs.InvalidRequestBody(models.JoinRequestsRequestBody{})
+	}
+	if len(body.UserIDs) == 0 {
+		return nil, errs.BadRequest(errors.New("user_ids is required"))
+	}
+
+	seen := make(map[int64]bool)
+	userIDs := []int64{}
+	for _, userID := range body.UserIDs {
+		if !seen[userID] {
+			seen[userID] = true
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs, nil
+}
@@ -0,0 +1,135 @@
+package classrooms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/provisioning"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Changes a member's role in a classroom. Members already in the org are moved between the student team and the
+// staff team, and made org admins or members to match. The change is recorded in the classroom's audit log.
+func (s *ClassroomService) changeUserRole() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		classroomRole, err := models.NewClassroomRole(c.Params("classroom_role"))
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		userID, err := strconv.ParseInt(c.Params("user_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		// Only allow professors to change roles
+		actor, err := s.RequireAtLeastRole(c, classroomID, models.Professor)
+		if err != nil {
+			return err
+		}
+
+		// Professors can't demote themselves, which also means a classroom always keeps the professor making the change
+		if *actor.ID == userID {
+			return errs.BadRequest(errors.New("professors cannot change their own role"))
+		}
+
+		classroomUser, err := s.store.GetUserInClassroom(c.Context(), classroomID, userID)
+		if err != nil {
+			return errs.UserNotFoundInClassroomError()
+		}
+		if classroomUser.Status == models.UserStatusRemoved {
+			return errs.StudentRemovedFromClassroomError()
+		}
+
+		previousRole := classroomUser.Role
+		if previousRole == classroomRole {
+			return c.Status(http.StatusOK).JSON(fiber.Map{"user": classroomUser})
+		}
+
+		classroom, err := s.store.GetClassroomByID(c.Context(), classroomID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		// Users who haven't been invited to the org yet get their GitHub access from the new role once they are
+		githubSynced := classroomUser.Status == models.UserStatusActive || classroomUser.Status == models.UserStatusOrgInvited
+		if githubSynced {
+			err = s.syncGitHubRole(c.Context(), classroom, classroomUser, classroomRole)
+			if err != nil {
+				return err
+			}
+		}
+
+		classroomUser, err = s.store.ModifyUserRole(c.Context(), classroomID, string(classroomRole), userID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		details, err := json.Marshal(models.RoleChangeDetails{
+			PreviousRole: previousRole,
+			NewRole:      classroomRole,
+			GitHubSynced: githubSynced,
+		})
+		if err != nil {
+			return errs.InternalServerError()
+		}
+		_, err = s.store.CreateAuditLogEntry(c.Context(), models.AuditLogEntry{
+			ClassroomID:   classroomID,
+			ActorUserID:   actor.ID,
+			SubjectUserID: &userID,
+			Action:        models.AuditLogRoleChanged,
+			Details:       details,
+		})
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{"user": classroomUser})
+	}
+}
+
+// Gets the audit log of a classroom, newest first
+func (s *ClassroomService) getAuditLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		_, err = s.RequireAtLeastRole(c, classroomID, models.Professor)
+		if err != nil {
+			return err
+		}
+
+		entries, err := s.store.GetClassroomAuditLog(c.Context(), classroomID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{"entries": entries})
+	}
+}
+
+// Moves a member between the student and staff teams and sets their org role for their new classroom role
+func (s *ClassroomService) syncGitHubRole(ctx context.Context, classroom models.Classroom, classroomUser models.ClassroomUser, classroomRole models.ClassroomRole) error {
+	appClient, err := s.GetAppClient(ctx, classroom.OrgName)
+	if err != nil {
+		return err
+	}
+
+	err = provisioning.SyncGitHubRole(ctx, s.store, appClient, classroom, classroomUser, classroomRole)
+	if err != nil {
+		return errs.GithubAPIError(err)
+	}
+	return nil
+}
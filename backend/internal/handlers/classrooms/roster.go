@@ -0,0 +1,192 @@
+package classrooms
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Queues the import of a roster CSV into a classroom, which adds each user and invites them to the organization in
+// the background. The CSV is sent as the "roster" file of a multipart form or as the raw request body, with a header
+// row naming its columns: github_username (required), first_name, last_name, external_id and role (STUDENT unless
+// given). Poll the returned import for the outcome of each row. A dry run only reports the outcomes the import
+// would have.
+func (s *ClassroomService) importRoster() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		// Only allow professors to import rosters
+		professor, err := s.RequireAtLeastRole(c, classroomID, models.Professor)
+		if err != nil {
+			return err
+		}
+
+		data, err := readRosterUpload(c)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		entries, err := parseRoster(data)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		rows := []models.RosterImportRow{}
+		seen := make(map[string]bool)
+		for _, entry := range entries {
+			row := models.RosterImportRow{Entry: entry}
+			username := strings.ToLower(entry.GithubUsername)
+			if seen[username] {
+				message := "duplicate of an earlier row"
+				row.Result = &models.RosterImportResult{RosterEntry: entry, Status: models.RosterImportSkipped, Error: &message}
+			}
+			seen[username] = true
+			rows = append(rows, row)
+		}
+
+		rosterImport, rows, err := s.store.QueueRosterImport(c.Context(), models.RosterImport{
+			ClassroomID: classroomID,
+			DryRun:      c.QueryBool("dry_run"),
+			CreatedBy:   professor.ID,
+		}, rows)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"import": models.NewRosterImportProgress(rosterImport, rows),
+		})
+	}
+}
+
+// Returns a roster import with the progress of its rows
+func (s *ClassroomService) getRosterImport() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		importID, err := strconv.Atoi(c.Params("import_id"))
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		_, err = s.RequireAtLeastRole(c, classroomID, models.Professor)
+		if err != nil {
+			return err
+		}
+
+		rosterImport, err := s.store.GetRosterImportByID(c.Context(), importID)
+		if err != nil || rosterImport.ClassroomID != classroomID {
+			return errs.NotFound("roster import", "id", importID)
+		}
+
+		rows, err := s.store.GetRosterImportRows(c.Context(), importID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"import": models.NewRosterImportProgress(rosterImport, rows),
+		})
+	}
+}
+
+// Reads the roster CSV from the "roster" form file, falling back to the request body
+func readRosterUpload(c *fiber.Ctx) ([]byte, error) {
+	fileHeader, err := c.FormFile("roster")
+	if err != nil {
+		if len(c.Body()) == 0 {
+			return nil, errors.New("missing roster CSV")
+		}
+		return c.Body(), nil
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// Parses a roster CSV with a header row. Column names are case insensitive and unknown columns are ignored.
+func parseRoster(data []byte) ([]models.RosterEntry, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid roster CSV: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, errors.New("roster CSV must have a header row and at least one user")
+	}
+	if len(records)-1 > models.MaxRosterImportRows {
+		return nil, fmt.Errorf("roster CSV can have at most %d users", models.MaxRosterImportRows)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[strings.ReplaceAll(name, " ", "_")] = i
+	}
+	if _, ok := columns["github_username"]; !ok {
+		return nil, errors.New("roster CSV is missing a github_username column")
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	entries := []models.RosterEntry{}
+	for i, record := range records[1:] {
+		// rows are numbered as in a spreadsheet, after the header
+		row := i + 2
+		username := strings.TrimPrefix(field(record, "github_username"), "@")
+		if username == "" {
+			return nil, fmt.Errorf("row %d is missing a GitHub username", row)
+		}
+
+		role := models.Student
+		if value := field(record, "role"); value != "" {
+			role, err = models.NewClassroomRole(strings.ToUpper(value))
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", row, err)
+			}
+		}
+
+		entry := models.RosterEntry{
+			Row:            row,
+			FirstName:      field(record, "first_name"),
+			LastName:       field(record, "last_name"),
+			GithubUsername: username,
+			Role:           role,
+		}
+		if externalID := field(record, "external_id"); externalID != "" {
+			entry.ExternalID = &externalID
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
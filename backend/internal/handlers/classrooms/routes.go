@@ -24,12 +24,15 @@ func Routes(app *fiber.App, params types.Params) {
 
 	// Create the submission routes
 	works.WorkRoutes(baseRouter, workService)
+
+	// Create the student-facing submission routes
+	works.StudentWorkRoutes(baseRouter, workService)
 }
 
 func classroomRoutes(router fiber.Router, service *ClassroomService) fiber.Router {
 	classroomRouter := router.Group(
 		"/classrooms",
-	).Use(middleware.Protected(service.userCfg.JWTSecret))
+	).Use(middleware.Protected(service.store, service.userCfg.JWTSecret))
 
 	// Get the classrooms the authenticated user is part of
 	classroomRouter.Get("/", service.getUserClassrooms())
@@ -61,18 +64,78 @@ func classroomRoutes(router fiber.Router, service *ClassroomService) fiber.Route
 	// Send org invites to a specific user
 	classroomRouter.Put("/classroom/:classroom_id/invite/role/:classroom_role/user/:user_id", service.sendOrganizationInviteToUser())
 
+	// Change a user's role in a classroom
+	classroomRouter.Put("/classroom/:classroom_id/role/:classroom_role/user/:user_id", service.changeUserRole())
+
+	// Get the audit log of a classroom
+	classroomRouter.Get("/classroom/:classroom_id/audit-log", service.getAuditLog())
+
+	// Import a roster CSV, adding and inviting every user in it
+	classroomRouter.Post("/classroom/:classroom_id/roster", service.importRoster())
+
+	// Get the progress of a roster import
+	classroomRouter.Get("/classroom/:classroom_id/roster/imports/:import_id", service.getRosterImport())
+
 	// Deny a requested user
 	classroomRouter.Put("/classroom/:classroom_id/deny/user/:user_id", service.denyRequestedUser())
 
+	// Get the users waiting for approval to join a classroom
+	classroomRouter.Get("/classroom/:classroom_id/requests", service.getJoinRequests())
+
+	// Approve requested users in bulk, inviting them to the org in the background
+	classroomRouter.Post("/classroom/:classroom_id/requests/approve", service.approveJoinRequests())
+
+	// Deny requested users in bulk
+	classroomRouter.Post("/classroom/:classroom_id/requests/deny", service.denyJoinRequests())
+
+	// Get the approvals made in a classroom and the status of their invites
+	classroomRouter.Get("/classroom/:classroom_id/requests/approvals", service.getJoinApprovals())
+
+	// Get how a classroom handles users joining through its links
+	classroomRouter.Get("/classroom/:classroom_id/join-settings", service.getJoinSettings())
+
+	// Update how a classroom handles users joining through its links
+	classroomRouter.Put("/classroom/:classroom_id/join-settings", service.updateJoinSettings())
+
 	// Revoke an invite to a user
 	classroomRouter.Put("/classroom/:classroom_id/revoke/user/:user_id", service.revokeOrganizationInvite())
 
 	// Remove a user from a classroom
 	classroomRouter.Delete("/classroom/:classroom_id/students/:user_id", service.removeUserFromClassroom())
 
+	// Create a new classroom from this classroom's assignments and rubrics
+	classroomRouter.Post("/classroom/:classroom_id/clones", service.cloneClassroom())
+
+	// Get the clones made from a classroom and their progress
+	classroomRouter.Get("/classroom/:classroom_id/clones", service.getClassroomClones())
+
+	// Get the sections of a classroom
+	classroomRouter.Get("/classroom/:classroom_id/sections", service.getSections())
+
+	// Create a section in a classroom
+	classroomRouter.Post("/classroom/:classroom_id/sections", service.createSection())
+
+	// Delete a section
+	classroomRouter.Delete("/classroom/:classroom_id/sections/section/:section_id", service.deleteSection())
+
+	// Get the TAs and students of a section
+	classroomRouter.Get("/classroom/:classroom_id/sections/section/:section_id/members", service.getSectionMembers())
+
+	// Add users to a section
+	classroomRouter.Post("/classroom/:classroom_id/sections/section/:section_id/members", service.addSectionMembers())
+
+	// Remove a user from a section
+	classroomRouter.Delete("/classroom/:classroom_id/sections/section/:section_id/members/:user_id", service.removeSectionMember())
+
 	// Generate a token to join this classroom
 	classroomRouter.Post("/classroom/:classroom_id/token", service.generateClassroomToken())
 
+	// Get the join tokens of this classroom that can still be used
+	classroomRouter.Get("/classroom/:classroom_id/tokens", service.getClassroomTokens())
+
+	// Revoke a join token of this classroom
+	classroomRouter.Put("/classroom/:classroom_id/token/:token/revoke", service.revokeClassroomToken())
+
 	// Use a token to request to join a classroom
 	classroomRouter.Post("/classroom/token/:token", service.useClassroomToken())
 
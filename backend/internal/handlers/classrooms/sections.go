@@ -0,0 +1,318 @@
+package classrooms
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/go-github/github"
+	"github.com/jackc/pgx/v5"
+)
+
+// Lists the sections of a classroom
+func (s *ClassroomService) getSections() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		_, err = s.RequireAtLeastRole(c, classroomID, models.TA)
+		if err != nil {
+			return err
+		}
+
+		sections, err := s.store.GetSectionsInClassroom(c.Context(), classroomID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"sections": sections,
+		})
+	}
+}
+
+// Creates a section in a classroom, along with a GitHub team for its members if requested
+func (s *ClassroomService) createSection() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		// Only allow professors to manage sections
+		_, err = s.RequireAtLeastRole(c, classroomID, models.Professor)
+		if err != nil {
+			return err
+		}
+
+		var body models.SectionRequestBody
+		if err := c.BodyParser(&body); err != nil {
+			return errs.InvalidRequestBody(models.SectionRequestBody{})
+		}
+		body.Name = strings.TrimSpace(body.Name)
+		if body.Name == "" {
+			return errs.BadRequest(errors.New("section name is required"))
+		}
+
+		classroom, err := s.store.GetClassroomByID(c.Context(), classroomID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		section, err := s.store.CreateSection(c.Context(), models.Section{ClassroomID: classroomID, Name: body.Name})
+		if err != nil {
+			return errs.BadRequest(errors.New("a section with this name already exists"))
+		}
+
+		if body.CreateTeam {
+			teamName := strings.ReplaceAll(strings.ToLower(classroom.Name+" "+section.Name), " ", "-")
+			description := "The " + section.Name + " section of " + classroom.OrgName + " - " + classroom.Name + ".\n\nAutomatically generated by Khoury Classroom."
+			appClient, err := s.GetAppClient(c.Context(), classroom.OrgName)
+			if err != nil {
+				return err
+			}
+			team, err := appClient.CreateTeam(c.Context(), classroom.OrgName, teamName, &description, nil)
+			if err != nil {
+				// don't leave a section behind without the team that was asked for
+				_ = s.store.DeleteSection(c.Context(), section.ID)
+				return errs.GithubAPIError(err)
+			}
+
+			section, err = s.store.SetSectionTeam(c.Context(), section.ID, team.GetID(), team.GetSlug())
+			if err != nil {
+				return errs.InternalServerError()
+			}
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"section": section,
+		})
+	}
+}
+
+// Deletes a section and its GitHub team. The section's students and TAs stay in the classroom.
+func (s *ClassroomService) deleteSection() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		section, classroom, err := s.getManagedSection(c)
+		if err != nil {
+			return err
+		}
+
+		if section.TeamID != nil {
+			appClient, err := s.GetAppClient(c.Context(), classroom.OrgName)
+			if err != nil {
+				return err
+			}
+			err = appClient.DeleteTeam(c.Context(), *section.TeamID)
+			if err != nil {
+				return errs.GithubAPIError(err)
+			}
+		}
+
+		err = s.store.DeleteSection(c.Context(), section.ID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.SendStatus(http.StatusOK)
+	}
+}
+
+// Lists the TAs and students of a section. The TAs are the section's default graders.
+func (s *ClassroomService) getSectionMembers() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		section, err := s.getSection(c)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.RequireAtLeastRole(c, section.ClassroomID, models.TA)
+		if err != nil {
+			return err
+		}
+
+		members, err := s.store.GetSectionMembers(c.Context(), section.ID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		tas, students := models.SplitSectionMembers(members)
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"section":  section,
+			"tas":      tas,
+			"students": students,
+		})
+	}
+}
+
+// Adds classroom members to a section, and to the section's team if it has one. Students can only be in one
+// section of a classroom.
+func (s *ClassroomService) addSectionMembers() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		section, classroom, err := s.getManagedSection(c)
+		if err != nil {
+			return err
+		}
+
+		var body models.SectionMembersRequestBody
+		if err := c.BodyParser(&body); err != nil {
+			return errs.InvalidRequestBody(models.SectionMembersRequestBody{})
+		}
+		if len(body.UserIDs) == 0 {
+			return errs.BadRequest(errors.New("no users to add"))
+		}
+
+		// check every user before adding any of them
+		users := []models.ClassroomUser{}
+		for _, userID := range body.UserIDs {
+			classroomUser, err := s.store.GetUserInClassroom(c.Context(), classroom.ID, userID)
+			if err != nil || classroomUser.Status == models.UserStatusRemoved {
+				return errs.UserNotFoundInClassroomError()
+			}
+
+			if classroomUser.Role == models.Student {
+				sections, err := s.store.GetUserSectionsInClassroom(c.Context(), classroom.ID, userID)
+				if err != nil {
+					return errs.InternalServerError()
+				}
+				for _, other := range sections {
+					if other.ID != section.ID {
+						return errs.BadRequest(errors.New(classroomUser.GithubUsername + " is already in section " + other.Name))
+					}
+				}
+			}
+			users = append(users, classroomUser)
+		}
+
+		appClient, err := s.GetAppClient(c.Context(), classroom.OrgName)
+		if err != nil {
+			return err
+		}
+
+		for _, classroomUser := range users {
+			// checked again here, as another request may have put the student in a section since
+			err = s.store.AddSectionMember(c.Context(), section.ID, *classroomUser.ID)
+			if err == errs.ConstraintViolation() {
+				return errs.BadRequest(errors.New(classroomUser.GithubUsername + " is already in another section"))
+			}
+			if err == errs.EmptyResult() {
+				return errs.UserNotFoundInClassroomError()
+			}
+			if err != nil {
+				return errs.InternalServerError()
+			}
+
+			if section.TeamID != nil {
+				// TAs maintain their section's team
+				role := "member"
+				if classroomUser.Role != models.Student {
+					role = "maintainer"
+				}
+				err = appClient.AddTeamMember(c.Context(), *section.TeamID, classroomUser.GithubUsername,
+					&github.TeamAddTeamMembershipOptions{Role: role})
+				if err != nil {
+					return errs.GithubAPIError(err)
+				}
+			}
+		}
+
+		members, err := s.store.GetSectionMembers(c.Context(), section.ID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		tas, students := models.SplitSectionMembers(members)
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"section":  section,
+			"tas":      tas,
+			"students": students,
+		})
+	}
+}
+
+// Removes a user from a section and its team
+func (s *ClassroomService) removeSectionMember() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		section, classroom, err := s.getManagedSection(c)
+		if err != nil {
+			return err
+		}
+
+		userID, err := strconv.ParseInt(c.Params("user_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		user, err := s.store.GetUserByID(c.Context(), userID)
+		if err != nil {
+			return errs.NotFound("user", "id", userID)
+		}
+
+		err = s.store.RemoveSectionMember(c.Context(), section.ID, userID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		if section.TeamID != nil {
+			appClient, err := s.GetAppClient(c.Context(), classroom.OrgName)
+			if err != nil {
+				return err
+			}
+			err = appClient.RemoveTeamMember(c.Context(), classroom.OrgName, *section.TeamID, user.GithubUsername)
+			if err != nil {
+				return errs.GithubAPIError(err)
+			}
+		}
+
+		return c.SendStatus(http.StatusOK)
+	}
+}
+
+// Gets the section in the route, checking that it belongs to the route's classroom
+func (s *ClassroomService) getSection(c *fiber.Ctx) (models.Section, error) {
+	classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+	if err != nil {
+		return models.Section{}, errs.BadRequest(err)
+	}
+	sectionID, err := strconv.ParseInt(c.Params("section_id"), 10, 64)
+	if err != nil {
+		return models.Section{}, errs.BadRequest(err)
+	}
+
+	section, err := s.store.GetSectionByID(c.Context(), sectionID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && section.ClassroomID != classroomID) {
+		return models.Section{}, errs.NotFound("section", "id", sectionID)
+	}
+	if err != nil {
+		return models.Section{}, errs.InternalServerError()
+	}
+
+	return section, nil
+}
+
+// Gets the section in the route along with its classroom, for professors managing it
+func (s *ClassroomService) getManagedSection(c *fiber.Ctx) (models.Section, models.Classroom, error) {
+	section, err := s.getSection(c)
+	if err != nil {
+		return models.Section{}, models.Classroom{}, err
+	}
+
+	// Only allow professors to manage sections
+	_, err = s.RequireAtLeastRole(c, section.ClassroomID, models.Professor)
+	if err != nil {
+		return models.Section{}, models.Classroom{}, err
+	}
+
+	classroom, err := s.store.GetClassroomByID(c.Context(), section.ClassroomID)
+	if err != nil {
+		return models.Section{}, models.Classroom{}, errs.InternalServerError()
+	}
+
+	return section, classroom, nil
+}
@@ -1,6 +1,8 @@
 package classrooms
 
 import (
+	"context"
+
 	"github.com/CamPlume1/khoury-classroom/internal/config"
 	"github.com/CamPlume1/khoury-classroom/internal/github"
 	"github.com/CamPlume1/khoury-classroom/internal/middleware"
@@ -8,18 +10,18 @@ import (
 )
 
 type ClassroomService struct {
-	store     storage.Storage
-	appClient github.GitHubAppClient
-	userCfg   *config.GitHubUserClient
+	store      storage.Storage
+	appClients github.GitHubAppInstallations
+	userCfg    *config.GitHubUserClient
 	middleware.RoleChecker[ClassroomService]
 }
 
 func newClassroomService(
 	store storage.Storage,
-	appClient github.GitHubAppClient,
+	appClients github.GitHubAppInstallations,
 	userCfg *config.GitHubUserClient,
 ) *ClassroomService {
-	service := &ClassroomService{store: store, appClient: appClient, userCfg: userCfg}
+	service := &ClassroomService{store: store, appClients: appClients, userCfg: userCfg}
 	service.RoleChecker = middleware.RoleChecker[ClassroomService]{Checkable: service}
 	return service
 }
@@ -29,9 +31,9 @@ func (s *ClassroomService) GetStore() storage.Storage {
 	return s.store
 }
 
-// Getter for appClient field
-func (s *ClassroomService) GetAppClient() github.GitHubAppClient {
-	return s.appClient
+// Gets the app client of the installation on an org
+func (s *ClassroomService) GetAppClient(ctx context.Context, orgName string) (github.GitHubAppClient, error) {
+	return s.appClients.ForOrg(ctx, orgName)
 }
 
 // Getter for userCfg field
@@ -0,0 +1,61 @@
+package classrooms
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+// Lists the classroom's join tokens that can still be used
+func (s *ClassroomService) getClassroomTokens() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		// Only allow professors to see the classroom's invite links
+		_, err = s.RequireAtLeastRole(c, classroomID, models.Professor)
+		if err != nil {
+			return err
+		}
+
+		tokens, err := s.store.GetActiveClassroomTokens(c.Context(), classroomID)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{"tokens": tokens})
+	}
+}
+
+// Revokes one of the classroom's join tokens. Users who already joined with it stay in the classroom.
+func (s *ClassroomService) revokeClassroomToken() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		classroomID, err := strconv.ParseInt(c.Params("classroom_id"), 10, 64)
+		if err != nil {
+			return errs.BadRequest(err)
+		}
+
+		_, err = s.RequireAtLeastRole(c, classroomID, models.Professor)
+		if err != nil {
+			return err
+		}
+
+		token := c.Params("token")
+		classroomToken, err := s.store.RevokeClassroomToken(c.Context(), classroomID, token)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.NotFound("token", "token", token)
+		}
+		if err != nil {
+			return errs.InternalServerError()
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{"token": classroomToken})
+	}
+}
@@ -21,7 +21,7 @@ func Routes(app *fiber.App, params types.Params) {
 	protected := app.Group("/hello_protected")
 
 	// Register Middleware
-	protected.Use(middleware.Protected(params.UserCfg.JWTSecret))
+	protected.Use(middleware.Protected(params.Store, params.UserCfg.JWTSecret))
 
 	// Unprotected Routes
 	unprotected := app.Group("/hello")
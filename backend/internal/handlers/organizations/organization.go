@@ -41,17 +41,14 @@ func (service *OrganizationService) GetInstalledOrgs() fiber.Handler {
 		if err != nil {
 			return errs.GithubClientError(err)
 		}
-		// Get the app client
-		appClient := service.appClient
-
 		// Get the list of organizations the user is part of
 		userOrgs, err := userClient.GetUserOrgs(c.Context())
 		if err != nil {
 			return errs.GithubAPIError(err)
 		}
 
-		// Get the list of installations of the GitHub app
-		appInstallations, err := appClient.ListInstallations(c.Context())
+		// Get the list of installations of the GitHub app, which also refreshes the installation used for each org
+		appInstallations, err := service.appClients.ListInstallations(c.Context())
 		if err != nil {
 			return errs.GithubAPIError(err)
 		}
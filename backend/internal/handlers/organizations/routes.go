@@ -17,7 +17,7 @@ func Routes(app *fiber.App, params types.Params) {
 
 func OrgRoutes(router fiber.Router, service *OrganizationService) fiber.Router {
 	// Create the organization router with authentication middleware
-	orgRouter := router.Group("/orgs").Use(middleware.Protected(service.userCfg.JWTSecret))
+	orgRouter := router.Group("/orgs").Use(middleware.Protected(service.store, service.userCfg.JWTSecret))
 
 	// Get the organizations of the authenticated user
 	orgRouter.Get("/", service.GetUserOrgs())
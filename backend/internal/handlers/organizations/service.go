@@ -10,16 +10,16 @@ import (
 //Service Declaration
 type OrganizationService struct {
 	store     storage.Storage
-	appClient github.GitHubAppClient
+	appClients github.GitHubAppInstallations
 	userCfg   *config.GitHubUserClient
 }
 
 //Service constructor
 func NewOrganizationService(
 	store storage.Storage,
-	appClient github.GitHubAppClient,
+	appClients github.GitHubAppInstallations,
 	userCfg *config.GitHubUserClient,
 ) *OrganizationService {
-	service := &OrganizationService{store: store, appClient: appClient, userCfg: userCfg}
+	service := &OrganizationService{store: store, appClients: appClients, userCfg: userCfg}
 	return service
 }
@@ -14,7 +14,7 @@ func Routes(router fiber.Router, params types.Params) {
 
 func RubricRoutes(router fiber.Router, service *RubricService) fiber.Router {
 
-	route := router.Group("/rubrics").Use(middleware.Protected(service.userCfg.JWTSecret))
+	route := router.Group("/rubrics").Use(middleware.Protected(service.store, service.userCfg.JWTSecret))
 
 	route.Post("/rubric", service.CreateRubric())
 	route.Get("/rubric/:rubric_id", service.GetRubricByID())
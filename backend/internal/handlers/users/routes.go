@@ -9,6 +9,6 @@ import (
 func Routes(router fiber.Router, params types.Params) {
 	service := newUserService(params.Store, &params.UserCfg)
 
-	protected := router.Group("/users").Use(middleware.Protected(service.userCfg.JWTSecret))
+	protected := router.Group("/users").Use(middleware.Protected(service.store, service.userCfg.JWTSecret))
 	protected.Get("/user/:user_name", service.GetUser())
 }
@@ -6,16 +6,16 @@ import (
 )
 
 type WebHookService struct {
-	store     storage.Storage
-	appClient github.GitHubAppClient
+	store      storage.Storage
+	appClients github.GitHubAppInstallations
 }
 
 func newWebHookService(
 	store storage.Storage,
-	appClient github.GitHubAppClient,
+	appClients github.GitHubAppInstallations,
 ) *WebHookService {
 	return &WebHookService{
-		store:     store,
-		appClient: appClient,
+		store:      store,
+		appClients: appClients,
 	}
 }
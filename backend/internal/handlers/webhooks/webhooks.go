@@ -1,13 +1,18 @@
 package webhooks
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/CamPlume1/khoury-classroom/internal/errs"
 	models "github.com/CamPlume1/khoury-classroom/internal/models"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/go-github/github"
+	"github.com/jackc/pgx/v5"
 )
 
 func (s *WebHookService) WebhookHandler(c *fiber.Ctx) error {
@@ -16,6 +21,7 @@ func (s *WebHookService) WebhookHandler(c *fiber.Ctx) error {
 		"pull_request_review_comment": s.PRComment,
 		"pull_request_review_thread":  s.PRThread,
 		"push":                        s.PushEvent,
+		"installation":                s.Installation,
 	}
 	event := c.Get("X-GitHub-Event", "")
 
@@ -27,20 +33,118 @@ func (s *WebHookService) WebhookHandler(c *fiber.Ctx) error {
 	return handler(c)
 }
 
+// Keeps track of which installation of the app to use for each org as the app is installed and uninstalled
+func (s *WebHookService) Installation(c *fiber.Ctx) error {
+	installationEvent := github.InstallationEvent{}
+	if err := c.BodyParser(&installationEvent); err != nil {
+		return err
+	}
+
+	orgName := installationEvent.GetInstallation().GetAccount().GetLogin()
+	if orgName == "" {
+		return errs.BadRequest(errors.New("invalid installation data"))
+	}
+
+	switch installationEvent.GetAction() {
+	case "created", "unsuspend":
+		s.appClients.SetInstallation(orgName, installationEvent.GetInstallation().GetID())
+	case "deleted", "suspend":
+		s.appClients.RemoveInstallation(orgName)
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
 func (s *WebHookService) PR(c *fiber.Ctx) error {
-	println("PR webhook event")
+	prEvent := github.PullRequestEvent{}
+	if err := c.BodyParser(&prEvent); err != nil {
+		return err
+	}
+
+	// Report whether the pull request's latest commit came in before the work's deadline. Statuses stay on their
+	// commit, so only a new head commit needs one.
+	switch prEvent.GetAction() {
+	case "opened", "synchronize":
+		err := s.reportDeadlineStatus(c.Context(), prEvent)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Track students merging or closing starter code updates
+	if prEvent.GetAction() == "closed" && prEvent.PullRequest != nil && prEvent.Repo != nil &&
+		strings.HasPrefix(prEvent.PullRequest.GetHead().GetRef(), "starter-code-update-") {
+		state := models.StarterCodePRClosed
+		if prEvent.PullRequest.GetMerged() {
+			state = models.StarterCodePRMerged
+		}
+
+		err := s.store.UpdateStarterCodeSyncPRState(c.Context(), prEvent.Repo.GetName(), prEvent.PullRequest.GetNumber(), state)
+		if err != nil {
+			return err
+		}
+	}
+
 	return c.SendStatus(fiber.StatusOK)
 }
 
-// todo: finish regrade request handling
+// Sets the deadline-enforcement status required by the student repository's branch ruleset on the pull request's
+// head commit, judged by the work's due date (or the assignment's, without an extension) and when the commit reached
+// GitHub, i.e. when the event was received. Commit dates are set by the student, and the pull request's update time
+// also moves on edits, so neither is used. Pull requests outside of student repositories are ignored.
+func (s *WebHookService) reportDeadlineStatus(ctx context.Context, prEvent github.PullRequestEvent) error {
+	if prEvent.PullRequest == nil || prEvent.Repo == nil || prEvent.Repo.Owner == nil {
+		return errs.BadRequest(errors.New("invalid pull request data"))
+	}
+
+	studentWork, err := s.store.GetWorkByRepoName(ctx, prEvent.Repo.GetName())
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	dueDate := studentWork.UniqueDueDate
+	if dueDate == nil {
+		assignment, err := s.store.GetAssignmentByID(ctx, int64(studentWork.AssignmentOutlineID))
+		if err != nil {
+			return err
+		}
+		dueDate = assignment.MainDueDate
+	}
+
+	eventTime := time.Now().UTC()
+
+	state, description := "success", "This assignment has no deadline"
+	if dueDate != nil {
+		if eventTime.After(*dueDate) {
+			state, description = "failure", fmt.Sprintf("The deadline passed at %s", dueDate.UTC().Format(time.RFC1123))
+		} else {
+			description = fmt.Sprintf("Updated before the deadline at %s", dueDate.UTC().Format(time.RFC1123))
+		}
+	}
+
+	appClient, err := s.appClients.ForOrg(ctx, prEvent.Repo.Owner.GetLogin())
+	if err != nil {
+		return err
+	}
+
+	err = appClient.CreateCommitStatus(ctx, prEvent.Repo.Owner.GetLogin(), prEvent.Repo.GetName(),
+		prEvent.PullRequest.GetHead().GetSHA(), state, "deadline-enforcement", description)
+	if err != nil {
+		return errs.GithubAPIError(err)
+	}
+
+	return nil
+}
+
+// Regrades are requested through the student works API, so comments on the feedback PR need no handling
 func (s *WebHookService) PRComment(c *fiber.Ctx) error {
 	payload := models.WebHookPRComment{}
 	if err := c.BodyParser(&payload); err != nil {
 		return err
 	}
-	if payload.Comment.AuthorAssociation == "COLLABORATOR" {
-		println("regrade request")
-	}
 	return c.SendStatus(fiber.StatusOK)
 }
 
@@ -86,9 +190,29 @@ func (s *WebHookService) baseRepoInitialization(c *fiber.Ctx, pushEvent github.P
 		//@KHO-239
 		return err
 	}
-	if template.MainDueDate != nil {
-		// There is a deadline
-		err = s.appClient.CreateDeadlineEnforcement(c.Context(), template.MainDueDate, *pushEvent.Repo.Organization, *pushEvent.Repo.Name, "main")
+
+	layout := template.RepoLayout
+
+	appClient, err := s.appClients.ForOrg(c.Context(), *pushEvent.Repo.Organization)
+	if err != nil {
+		return err
+	}
+
+	// Workflows and branches are set up on the submission branch, created first if the template uses another default
+	if layout.SubmissionBranch != *pushEvent.Repo.MasterBranch {
+		_, err = appClient.CreateBranch(c.Context(),
+			*pushEvent.Repo.Organization,
+			*pushEvent.Repo.Name,
+			*pushEvent.Repo.MasterBranch,
+			layout.SubmissionBranch)
+		if err != nil {
+			return errs.InternalServerError()
+		}
+	}
+
+	if template.MainDueDate != nil && template.DeadlineWorkflow {
+		// There is a deadline, and the assignment opted into the workflow on top of the app's deadline status
+		err = appClient.CreateDeadlineEnforcement(c.Context(), template.MainDueDate, *pushEvent.Repo.Organization, *pushEvent.Repo.Name, layout.SubmissionBranch)
 		if err != nil {
 			//@KHO-239
 			return err
@@ -97,18 +221,18 @@ func (s *WebHookService) baseRepoInitialization(c *fiber.Ctx, pushEvent github.P
 
 
 	//Create PR Enforcement Action
-	err = s.appClient.CreatePREnforcement(c.Context(), *pushEvent.Repo.Organization, *pushEvent.Repo.Name, "main")
+	err = appClient.CreatePREnforcement(c.Context(), *pushEvent.Repo.Organization, *pushEvent.Repo.Name, layout.SubmissionBranch, layout.FeedbackBranch)
 		if err != nil {
 			return err
 		}
 
 	//Create necessary repo branches
-	repoBranches := []string{"development", "feedback"}
+	repoBranches := append([]string{layout.FeedbackBranch}, layout.WorkingBranches...)
 	for _, branch := range repoBranches {
-		_, err = s.appClient.CreateBranch(c.Context(),
+		_, err = appClient.CreateBranch(c.Context(),
 			*pushEvent.Repo.Organization,
 			*pushEvent.Repo.Name,
-			*pushEvent.Repo.MasterBranch,
+			layout.SubmissionBranch,
 			branch)
 
 		if err != nil {
@@ -116,14 +240,14 @@ func (s *WebHookService) baseRepoInitialization(c *fiber.Ctx, pushEvent github.P
 		}
 	}
 
-	err = s.appClient.CreatePushRuleset(c.Context(),  *pushEvent.Repo.Organization, *pushEvent.Repo.Name)
+	err = appClient.CreatePushRuleset(c.Context(),  *pushEvent.Repo.Organization, *pushEvent.Repo.Name, layout.RestrictedPaths())
 	if err != nil {
 		// @KHO-239
 		return err
 	}
 
 	// Create empty commit (will create a diff that allows feedback PR to be created)
-	err = s.appClient.CreateEmptyCommit(c.Context(), *pushEvent.Repo.Organization, *pushEvent.Repo.Name)
+	err = appClient.CreateEmptyCommit(c.Context(), *pushEvent.Repo.Organization, *pushEvent.Repo.Name, layout.SubmissionBranch)
 	if err != nil {
 		return errs.InternalServerError()
 	}
@@ -140,12 +264,25 @@ func (s *WebHookService) baseRepoInitialization(c *fiber.Ctx, pushEvent github.P
 			return err
 	}
 
-	// Give the student team read access to the repository
-	err = s.appClient.UpdateTeamRepoPermissions(c.Context(), *pushEvent.Repo.Organization, *classroom.StudentTeamName,
-		*pushEvent.Repo.Organization, *pushEvent.Repo.Name, "pull")
+	// The scheduler can release the assignment from now on
+	err = s.store.MarkBaseRepoInitialized(c.Context(), *pushEvent.Repo.ID)
 	if err != nil {
+		return err
+	}
+
+	// Give the student team read access to the repository, unless the assignment is scheduled for a later release
+	if assignmentOutline.IsReleasedAt(time.Now()) {
+		err = appClient.UpdateTeamRepoPermissions(c.Context(), *pushEvent.Repo.Organization, *classroom.StudentTeamName,
+			*pushEvent.Repo.Organization, *pushEvent.Repo.Name, "pull")
+		if err != nil {
 			// @KHO-239
 			return err
+		}
+
+		err = s.store.MarkAssignmentReleased(c.Context(), int64(assignmentOutline.ID))
+		if err != nil {
+			return err
+		}
 	}
 
 	return c.SendStatus(fiber.StatusOK)
@@ -172,12 +309,23 @@ func (s *WebHookService) updateWorkStateOnStudentCommit(c *fiber.Ctx, pushEvent
 			studentWork.LastCommitDate = &lastCommitDate
 		}
 
-		// TODO: Dynamically determine branch names once parameterized
-		// If commiting to main branch, mark as submitted
-		if *pushEvent.Ref == "refs/heads/"+*pushEvent.Repo.DefaultBranch {
+		assignment, err := s.store.GetAssignmentByID(c.Context(), int64(studentWork.AssignmentOutlineID))
+		if err != nil {
+			return err
+		}
+
+		// If commiting to the submission branch, mark as submitted
+		if *pushEvent.Ref == "refs/heads/"+assignment.RepoLayout.SubmissionBranch {
 			studentWork.WorkState = models.WorkStateSubmitted
-		} else if *pushEvent.Ref != "refs/heads/feedback" {
-			// If not committing to main/ or feedback/ branch, increment commit amount
+
+			if !pushEvent.GetDeleted() {
+				err = s.recordSubmission(c.Context(), pushEvent, studentWork, assignment)
+				if err != nil {
+					return err
+				}
+			}
+		} else if *pushEvent.Ref != "refs/heads/"+assignment.RepoLayout.FeedbackBranch {
+			// If not committing to the submission or feedback branch, increment commit amount
 			studentWork.CommitAmount += len(pushEvent.Commits)
 		}
 	}
@@ -191,6 +339,48 @@ func (s *WebHookService) updateWorkStateOnStudentCommit(c *fiber.Ctx, pushEvent
 	return c.SendStatus(fiber.StatusOK)
 }
 
+// Records a push to the submission branch as a submission, judged on time by when it was received, and tags
+// it in the student's repository
+func (s *WebHookService) recordSubmission(ctx context.Context, pushEvent github.PushEvent, studentWork models.StudentWork, assignment models.AssignmentOutline) error {
+	dueDate := studentWork.UniqueDueDate
+	if dueDate == nil {
+		dueDate = assignment.MainDueDate
+	}
+
+	submittedAt := time.Now().UTC()
+	var pushedBy *string
+	if pushEvent.Pusher != nil {
+		pushedBy = pushEvent.Pusher.Name
+	}
+
+	submission, err := s.store.CreateSubmission(ctx, models.Submission{
+		StudentWorkID: studentWork.ID,
+		CommitSHA:     pushEvent.GetAfter(),
+		PushedBy:      pushedBy,
+		SubmittedAt:   submittedAt,
+		DueDate:       dueDate,
+		OnTime:        dueDate == nil || !submittedAt.After(*dueDate),
+	})
+	if err != nil {
+		return err
+	}
+
+	// the submission is kept even if the tag can't be created
+	appClient, err := s.appClients.ForOrg(ctx, *pushEvent.Repo.Organization)
+	if err != nil {
+		slog.Error("Failed to tag submission", "repo", *pushEvent.Repo.Name, "submission", submission.ID, "err", err)
+		return nil
+	}
+	message := fmt.Sprintf("Submission received %s", submittedAt.Format(time.RFC3339))
+	err = appClient.CreateAnnotatedTag(ctx, *pushEvent.Repo.Organization, *pushEvent.Repo.Name, submission.Tag(), submission.CommitSHA, message)
+	if err != nil {
+		slog.Error("Failed to tag submission", "repo", *pushEvent.Repo.Name, "submission", submission.ID, "err", err)
+		return nil
+	}
+
+	return s.store.SetSubmissionTag(ctx, submission.ID, submission.Tag())
+}
+
 func isInitialCommit(pushEvent github.PushEvent) bool {
 	return pushEvent.BaseRef == nil && *pushEvent.Created && pushEvent.GetBefore() == "0000000000000000000000000000000000000000"
 }
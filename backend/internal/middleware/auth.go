@@ -16,6 +16,13 @@ import (
 	"github.com/golang-jwt/jwt"
 )
 
+const (
+	// How long a login lasts without activity
+	JWTLifetime = 24 * time.Hour
+	// JWTs used within this long of expiring are renewed, so active users stay logged in
+	jwtRenewalWindow = 12 * time.Hour
+)
+
 func GenerateJWT(userID string, expirationTime time.Time, secret string) (string, error) {
 	claims := &jwt.StandardClaims{
 		Subject:   userID,
@@ -47,7 +54,29 @@ func ParseJWT(tokenString string, secret string) (*jwt.StandardClaims, error) {
 	return claims, nil
 }
 
-func Protected(secret string) fiber.Handler {
+// Issues a JWT for the user and sets it as the session cookie
+func SetJWTCookie(c *fiber.Ctx, userID string, secret string) error {
+	expirationTime := time.Now().Add(JWTLifetime)
+	jwtToken, err := GenerateJWT(userID, expirationTime, secret)
+	if err != nil {
+		return err
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     "jwt_cookie",
+		Value:    jwtToken,
+		Expires:  expirationTime,
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "None",
+		Path:     "/",
+	})
+	return nil
+}
+
+// Requires a valid JWT whose user still has a session. Sessions end when GitHub's refresh token expires, as the
+// user's GitHub token can't be renewed after that, so the user has to log in again.
+func Protected(store storage.Storage, secret string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Extract and validate JWT token
 		token := c.Cookies("jwt_cookie", "")
@@ -67,6 +96,22 @@ func Protected(secret string) fiber.Handler {
 		}
 		c.Locals("userID", userID)
 
+		active, err := store.IsSessionActive(c.Context(), userID)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "failed to check session"})
+		}
+		if !active {
+			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "session expired"})
+		}
+
+		// Renew the JWT of active users before it runs out
+		if time.Until(time.Unix(claims.ExpiresAt, 0)) < jwtRenewalWindow {
+			err = SetJWTCookie(c, claims.Subject, secret)
+			if err != nil {
+				return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "failed to renew JWT token"})
+			}
+		}
+
 		return c.Next()
 	}
 }
@@ -83,7 +128,7 @@ func GetClient(c *fiber.Ctx, store storage.Storage, userCfg *config.GitHubUserCl
 		return nil, err
 	}
 
-	client, err := userclient.NewFromSession(userCfg.OAuthConfig(), &session)
+	client, err := userclient.NewFromSession(userCfg.OAuthConfig(), store, &session)
 
 	if err != nil {
 		return nil, err
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"context"
+
 	"github.com/CamPlume1/khoury-classroom/internal/config"
 	"github.com/CamPlume1/khoury-classroom/internal/errs"
 	"github.com/CamPlume1/khoury-classroom/internal/github"
@@ -12,7 +14,7 @@ import (
 type Checkable interface {
 	GetStore() storage.Storage
 	GetUserCfg() *config.GitHubUserClient
-	GetAppClient() github.GitHubAppClient
+	GetAppClient(ctx context.Context, orgName string) (github.GitHubAppClient, error)
 }
 
 type RoleChecker[T any] struct {
@@ -61,12 +63,17 @@ func (roleChecker *RoleChecker[T]) checkRole(c *fiber.Ctx, classroomID int64, ro
 
 	// if the user is a student, check if they are in the student team
 	if classroomUser.Role == models.Student {
-		studentTeam, err := roleChecker.GetAppClient().GetTeamByName(c.Context(), classroom.OrgName, *classroom.StudentTeamName)
+		appClient, err := roleChecker.GetAppClient(c.Context(), classroom.OrgName)
+		if err != nil {
+			return models.ClassroomUser{}, err
+		}
+
+		studentTeam, err := appClient.GetTeamByName(c.Context(), classroom.OrgName, *classroom.StudentTeamName)
 		if err != nil { // student team doesn't exist :(
 			return models.ClassroomUser{}, errs.InternalServerError()
 		} else { // student team exists, check if the user is in it
 			var studentIsInStudentTeam = false
-			studentTeamMembers, err := roleChecker.GetAppClient().GetTeamMembers(c.Context(), *studentTeam.ID)
+			studentTeamMembers, err := appClient.GetTeamMembers(c.Context(), *studentTeam.ID)
 			if err != nil {
 				return models.ClassroomUser{}, errs.InternalServerError()
 			}
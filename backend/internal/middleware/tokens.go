@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/github"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+)
+
+// Checks that the authenticated user is on a token's allowlist, only fetching their verified emails when the token
+// is restricted to email domains
+func CheckTokenAllowlist(ctx context.Context, client github.GitHubUserClient, token models.BaseToken, username string) error {
+	if !token.HasAllowlist() {
+		return nil
+	}
+
+	var emails []string
+	if len(token.AllowedEmailDomains) > 0 {
+		var err error
+		emails, err = client.GetVerifiedEmails(ctx)
+		if err != nil {
+			return errs.GithubAPIError(err)
+		}
+	}
+
+	if !token.Allows(username, emails) {
+		return errs.TokenNotAllowedError()
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// A group of students sharing a single repository on a group assignment
+type AssignmentGroup struct {
+	ID                  int32     `json:"id"`
+	AssignmentOutlineID int32     `json:"assignment_outline_id"`
+	Name                string    `json:"name"`
+	JoinCode            string    `json:"join_code,omitempty"`
+	StudentWorkID       *int      `json:"student_work_id,omitempty"`
+	RepoName            *string   `json:"repo_name,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+type AssignmentGroupWithMembers struct {
+	AssignmentGroup
+	Members []User `json:"members"`
+}
+
+type AssignmentGroupRequestBody struct {
+	Name      string  `json:"name"`
+	MemberIDs []int64 `json:"member_ids,omitempty"` // only used when staff set up a group
+}
+
+type AssignmentGroupJoinRequestBody struct {
+	JoinCode string `json:"join_code"`
+}
+
+type AssignmentGroupMemberRequestBody struct {
+	UserID int64 `json:"user_id"`
+}
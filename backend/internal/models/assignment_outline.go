@@ -11,6 +11,7 @@ type AssignmentToken struct {
 
 type AssignmentTokenRequestBody struct {
 	Duration *int `json:"duration,omitempty"`
+	TokenLimitsRequestBody
 }
 
 type AssignmentOutline struct {
@@ -25,6 +26,47 @@ type AssignmentOutline struct {
 	GroupAssignment bool       `json:"group_assignment"`
 	MainDueDate     *time.Time `json:"main_due_date,omitempty"`
 	DefaultScore    int        `json:"default_score"`
+	// group assignment settings: nil max size means groups are unbounded
+	MaxGroupSize     *int `json:"max_group_size,omitempty" db:"max_group_size"`
+	SelfFormedGroups bool `json:"self_formed_groups" db:"self_formed_groups"`
+	// whether the student team has been given access, set once the release time has passed
+	Released   bool       `json:"released" db:"released"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty" db:"archived_at"`
+	RepoLayout RepoLayout `json:"repo_layout"`
+	// whether a deadline enforcement workflow is committed to the repositories, in addition to the
+	// deadline-enforcement status the app reports on pull requests
+	DeadlineWorkflow bool `json:"deadline_workflow" db:"deadline_workflow"`
+}
+
+// Whether the assignment's release time has passed at the given time. Assignments without a release time are
+// due for release immediately. Students only see and accept it once it has actually been released.
+func (a AssignmentOutline) IsReleasedAt(now time.Time) bool {
+	return a.ReleasedAt == nil || !a.ReleasedAt.After(now)
+}
+
+// A change made on GitHub when an assignment is updated, archived or deleted
+type AssignmentSideEffect struct {
+	Action     AssignmentSideEffectAction `json:"action"`
+	Repository string                     `json:"repository"`
+}
+
+type AssignmentSideEffectAction string
+
+const (
+	SideEffectArchiveRepository AssignmentSideEffectAction = "ARCHIVE_REPOSITORY"
+	SideEffectDeleteRepository  AssignmentSideEffectAction = "DELETE_REPOSITORY"
+	SideEffectUpdateDeadline    AssignmentSideEffectAction = "UPDATE_DEADLINE_WORKFLOW"
+)
+
+// The settings of an assignment to change. Settings left out are kept, nullable settings are cleared with null.
+type AssignmentUpdateRequestBody struct {
+	Name             *string             `json:"name"`
+	MainDueDate      Optional[time.Time] `json:"main_due_date"`
+	ReleasedAt       Optional[time.Time] `json:"released_at"`
+	GroupAssignment  *bool               `json:"group_assignment"`
+	DefaultScore     *int                `json:"default_score"`
+	MaxGroupSize     Optional[int]       `json:"max_group_size"`
+	SelfFormedGroups *bool               `json:"self_formed_groups"`
 }
 
 type AssignmentClassroomID struct {
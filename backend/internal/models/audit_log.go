@@ -0,0 +1,31 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditLogAction string
+
+const (
+	AuditLogRoleChanged AuditLogAction = "ROLE_CHANGED"
+)
+
+// A change made to a classroom's members, by the actor to the subject
+type AuditLogEntry struct {
+	ID            int64           `json:"id" db:"id"`
+	ClassroomID   int64           `json:"classroom_id" db:"classroom_id"`
+	ActorUserID   *int64          `json:"actor_user_id" db:"actor_user_id"`
+	SubjectUserID *int64          `json:"subject_user_id" db:"subject_user_id"`
+	Action        AuditLogAction  `json:"action" db:"action"`
+	Details       json.RawMessage `json:"details" db:"details"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+}
+
+// The details of a ROLE_CHANGED entry
+type RoleChangeDetails struct {
+	PreviousRole ClassroomRole `json:"previous_role"`
+	NewRole      ClassroomRole `json:"new_role"`
+	// whether the user's team and org role were changed on GitHub, which is skipped for users not yet in the org
+	GitHubSynced bool `json:"github_synced"`
+}
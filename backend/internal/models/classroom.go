@@ -12,8 +12,18 @@ type Classroom struct {
 	OrgName         string    `json:"org_name"`
 	CreatedAt       time.Time `json:"created_at"`
 	StudentTeamName *string   `json:"student_team_name,omitempty"`
+	// naming templates for the base repositories and student repositories of the classroom's assignments
+	BaseRepoNameTemplate string `json:"base_repo_name_template"`
+	WorkRepoNameTemplate string `json:"work_repo_name_template"`
+	// the team of the classroom's TAs and professors, created once staff are added to it
+	StaffTeamName *string `json:"staff_team_name,omitempty"`
 }
 
+const (
+	DefaultBaseRepoNameTemplate = "{classroom}-{assignment}"
+	DefaultWorkRepoNameTemplate = "{classroom}-{assignment}-{login}"
+)
+
 type ClassroomRole string
 
 const (
@@ -54,4 +64,5 @@ type ClassroomToken struct {
 type ClassroomRoleRequestBody struct {
 	ClassroomRole string `json:"classroom_role" validate:"required,classroomrole"`
 	Duration      *int   `json:"duration,omitempty"` // Duration is optional
+	TokenLimitsRequestBody
 }
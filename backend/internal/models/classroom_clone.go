@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// A classroom created from another one's assignments and rubrics. Rosters are not copied.
+type ClassroomClone struct {
+	ID                int       `json:"id" db:"id"`
+	SourceClassroomID int64     `json:"source_classroom_id" db:"source_classroom_id"`
+	TargetClassroomID int64     `json:"target_classroom_id" db:"target_classroom_id"`
+	DueDateOffsetDays int       `json:"due_date_offset_days" db:"due_date_offset_days"`
+	CreatedBy         *int64    `json:"created_by" db:"created_by"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+}
+
+// Moves a due or release date of the source classroom to the matching date in the new one
+func (c ClassroomClone) ShiftDate(date *time.Time) *time.Time {
+	if date == nil {
+		return nil
+	}
+	shifted := date.AddDate(0, 0, c.DueDateOffsetDays)
+	return &shifted
+}
+
+// The copy of a single assignment into the new classroom, whose base repository is created in the background
+type ClassroomCloneAssignment struct {
+	ID                 int       `json:"id" db:"id"`
+	CloneID            int       `json:"clone_id" db:"clone_id"`
+	SourceAssignmentID int64     `json:"source_assignment_id" db:"source_assignment_id"`
+	TargetRubricID     *int64    `json:"target_rubric_id" db:"target_rubric_id"`
+	TargetAssignmentID *int64    `json:"target_assignment_id" db:"target_assignment_id"`
+	BaseRepoName       *string   `json:"base_repo_name" db:"base_repo_name"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+	Job
+}
+
+type ClassroomCloneRequestBody struct {
+	Name string `json:"name"`
+	// the org of the new classroom, the source classroom's org if not given
+	OrgID   *int64  `json:"org_id,omitempty"`
+	OrgName *string `json:"org_name,omitempty"`
+	// days added to every due and release date, e.g. the length of a term
+	DueDateOffsetDays int `json:"due_date_offset_days"`
+}
+
+// A clone with how far along its assignment copies are
+type ClassroomCloneProgress struct {
+	ClassroomClone
+	Assignments []ClassroomCloneAssignment `json:"assignments"`
+	Counts      map[JobStatus]int          `json:"counts"`
+}
+
+func NewClassroomCloneProgress(clone ClassroomClone, assignments []ClassroomCloneAssignment) ClassroomCloneProgress {
+	return ClassroomCloneProgress{ClassroomClone: clone, Assignments: assignments, Counts: CountJobs(assignments)}
+}
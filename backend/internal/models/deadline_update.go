@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// The propagation of a new due date to a single repository's deadline enforcement workflow
+type DeadlineUpdate struct {
+	ID                  int       `json:"id" db:"id"`
+	AssignmentOutlineID int       `json:"assignment_outline_id" db:"assignment_outline_id"`
+	RepoOwner           string    `json:"repo_owner" db:"repo_owner"`
+	RepoName            string    `json:"repo_name" db:"repo_name"`
+	BranchName          string    `json:"branch_name" db:"branch_name"`
+	DueDate             time.Time `json:"due_date" db:"due_date"`
+	CreatedAt           time.Time `json:"created_at" db:"created_at"`
+	Job
+}
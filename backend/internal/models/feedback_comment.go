@@ -3,12 +3,16 @@ package models
 import "time"
 
 type FeedbackComment struct {
-	StudentWorkID int       `json:"student_work_id"`
-	RubricItemID  int       `json:"rubric_item_id"`
-	TAUsername    string    `json:"github_username" db:"github_username"`
-	PointValue    int       `json:"point_value"`
-	Explanation   string    `json:"explanation"`
-	FilePath      *string   `json:"file_path"`
-	FileLine      *int      `json:"file_line"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID              int       `json:"id"`
+	StudentWorkID   int       `json:"student_work_id"`
+	RubricItemID    int       `json:"rubric_item_id"`
+	TAUsername      string    `json:"github_username" db:"github_username"`
+	PointValue      int       `json:"point_value"`
+	Explanation     string    `json:"explanation"`
+	FilePath        *string   `json:"file_path"`
+	StartLine       *int      `json:"start_line"`
+	FileLine        *int      `json:"file_line"`
+	CommitSHA       *string   `json:"commit_sha"`
+	GitHubCommentID *int64    `json:"github_comment_id" db:"github_comment_id"`
+	CreatedAt       time.Time `json:"created_at"`
 }
@@ -16,3 +16,28 @@ type LineRange struct {
 	Start int `json:"start"`
 	End   int `json:"end"`
 }
+
+// A file changed between two commits, as reported by GitHub's compare API
+type ChangedFile struct {
+	Filename         string  `json:"filename"`
+	SHA              *string `json:"sha,omitempty"`
+	PreviousFilename *string `json:"previous_filename,omitempty"`
+	Status           string  `json:"status"`
+	Patch            *string `json:"patch,omitempty"`
+}
+
+// How a head commit relates to a base commit ("ahead", "behind", "diverged" or "identical") and the files changed
+// between them, as reported by GitHub's compare API
+type CommitComparison struct {
+	Status string        `json:"status"`
+	Files  []ChangedFile `json:"files"`
+}
+
+// A file to write or delete as part of a commit
+type FileChange struct {
+	Path    string
+	Content []byte
+	// the git file mode, e.g. 100755 for executables, regular files when empty
+	Mode    string
+	Deleted bool
+}
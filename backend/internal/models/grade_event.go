@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+type GradeEventType string
+
+const (
+	GradeEventFeedbackCreated GradeEventType = "FEEDBACK_CREATED"
+	GradeEventFeedbackEdited  GradeEventType = "FEEDBACK_EDITED"
+	GradeEventFeedbackDeleted GradeEventType = "FEEDBACK_DELETED"
+	GradeEventRegradeResolved GradeEventType = "REGRADE_RESOLVED"
+	GradeEventGradesPublished GradeEventType = "GRADES_PUBLISHED"
+)
+
+// An entry in the append-only history of a student work's grade
+type GradeEvent struct {
+	ID                int            `json:"id" db:"id"`
+	StudentWorkID     int            `json:"student_work_id" db:"student_work_id"`
+	FeedbackCommentID *int           `json:"feedback_comment_id" db:"feedback_comment_id"`
+	ActorUserID       int64          `json:"actor_user_id" db:"actor_user_id"`
+	ActorUsername     string         `json:"actor_github_username" db:"actor_github_username"`
+	EventType         GradeEventType `json:"event_type" db:"event_type"`
+	BeforeScore       *int           `json:"before_score" db:"before_score"`
+	AfterScore        *int           `json:"after_score" db:"after_score"`
+	Reason            *string        `json:"reason" db:"reason"`
+	CreatedAt         time.Time      `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "PENDING"
+	JobSucceeded JobStatus = "SUCCEEDED"
+	JobFailed    JobStatus = "FAILED"
+)
+
+// Failed jobs are retried until they reach this many attempts
+const MaxJobAttempts = 3
+
+// The kinds of background job, one per queue table
+const (
+	JobKindDeadlineUpdate           = "deadline_update"
+	JobKindStarterCodePR            = "starter_code_pr"
+	JobKindRepoProvision            = "repo_provision"
+	JobKindClassroomCloneAssignment = "classroom_clone_assignment"
+	JobKindJoinApproval             = "join_approval"
+	JobKindRosterImportRow          = "roster_import_row"
+)
+
+// The progress of a background job, embedded in the queue row describing its work
+type Job struct {
+	JobID     int       `json:"job_id" db:"job_id"`
+	Status    JobStatus `json:"status" db:"status"`
+	Attempts  int       `json:"attempts" db:"attempts"`
+	Error     *string   `json:"error" db:"error"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Records the outcome of an attempt at the job, to be saved along with its queue row
+func (j *Job) Finish(err error) {
+	j.Status = JobSucceeded
+	j.Error = nil
+	if err != nil {
+		j.Status = JobFailed
+		message := err.Error()
+		j.Error = &message
+	}
+}
+
+func (j Job) progress() Job {
+	return j
+}
+
+// Counts queued jobs by status, with every status present
+func CountJobs[T interface{ progress() Job }](queued []T) map[JobStatus]int {
+	counts := map[JobStatus]int{
+		JobPending:   0,
+		JobSucceeded: 0,
+		JobFailed:    0,
+	}
+	for _, item := range queued {
+		counts[item.progress().Status]++
+	}
+	return counts
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// How a classroom handles users joining through its links
+type JoinSettings struct {
+	ClassroomID int64 `json:"classroom_id" db:"classroom_id"`
+	// when set, users joining through a link wait in the requested state for a professor to approve them
+	RequireApproval bool `json:"require_approval" db:"require_approval"`
+	// approve users who were imported from a roster, and users with a verified email on one of the domains
+	AutoApproveRoster       bool      `json:"auto_approve_roster" db:"auto_approve_roster"`
+	AutoApproveEmailDomains []string  `json:"auto_approve_email_domains" db:"auto_approve_email_domains"`
+	UpdatedAt               time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// A user waiting in the requested state for approval to join a classroom
+type JoinRequest struct {
+	User
+	Role        ClassroomRole `json:"classroom_role" db:"classroom_role"`
+	ExternalID  *string       `json:"external_id,omitempty" db:"external_id"`
+	RequestedAt time.Time     `json:"requested_at" db:"requested_at"`
+	// the status of the request's latest approval, if it has been approved
+	ApprovalStatus *JobStatus `json:"approval_status,omitempty" db:"approval_status"`
+}
+
+// Filters for the pending join requests of a classroom
+type JoinRequestFilter struct {
+	Role *ClassroomRole
+	// matched against the user's name and GitHub username
+	Search string
+	// only users imported from a roster, or only users who weren't
+	OnRoster *bool
+	// only requests that have or haven't been approved yet
+	Approved *bool
+}
+
+// An approved join request, whose invitation to the org and the classroom's team is sent in the background
+type JoinApproval struct {
+	ID             int64     `json:"id" db:"id"`
+	ClassroomID    int64     `json:"classroom_id" db:"classroom_id"`
+	UserID         int64     `json:"user_id" db:"user_id"`
+	GithubUsername string    `json:"github_username" db:"github_username"`
+	ApprovedBy     *int64    `json:"approved_by" db:"approved_by"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	Job
+}
+
+type JoinRequestsRequestBody struct {
+	UserIDs []int64 `json:"user_ids"`
+}
+
+type JoinRequestResultStatus string
+
+const (
+	JoinRequestQueued        JoinRequestResultStatus = "QUEUED"
+	JoinRequestDenied        JoinRequestResultStatus = "DENIED"
+	JoinRequestAlreadyQueued JoinRequestResultStatus = "ALREADY_QUEUED"
+	JoinRequestNotRequested  JoinRequestResultStatus = "NOT_REQUESTED"
+	JoinRequestFailed        JoinRequestResultStatus = "FAILED"
+)
+
+// What a bulk approval or denial did for one user
+type JoinRequestResult struct {
+	UserID   int64                   `json:"user_id"`
+	Status   JoinRequestResultStatus `json:"status"`
+	Approval *JoinApproval           `json:"approval,omitempty"`
+	Error    *string                 `json:"error,omitempty"`
+}
+
+// Whether one of the emails is on one of the domains. Domains may be given with or without a leading "@".
+func EmailMatchesDomains(emails []string, domains []string) bool {
+	for _, email := range emails {
+		at := strings.LastIndex(email, "@")
+		if at < 0 {
+			continue
+		}
+		for _, domain := range domains {
+			if strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(domain), "@"), email[at+1:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
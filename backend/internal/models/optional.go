@@ -0,0 +1,33 @@
+package models
+
+import "encoding/json"
+
+// A request field that can be left out, sent as null, or sent with a value. Set is false only when the field was
+// left out.
+type Optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	o.Value = &value
+	return nil
+}
+
+// Returns the sent value, or current if the field was left out
+func (o Optional[T]) Or(current *T) *T {
+	if !o.Set {
+		return current
+	}
+	return o.Value
+}
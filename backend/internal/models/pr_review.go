@@ -5,10 +5,20 @@ type PRReviewRequest struct {
 	Comments []PRReviewCommentResponse `json:"comments"`
 }
 
+// A review comment anchored to a whole file (Line is nil), a single line, or a range from StartLine to Line
 type PRReviewComment struct {
-	Path *string `json:"path"`
-	Line *int    `json:"line"`
-	Body string  `json:"body"`
+	Path      *string `json:"path"`
+	Line      *int    `json:"line"`
+	StartLine *int    `json:"start_line,omitempty"`
+	Body      string  `json:"body"`
+}
+
+func (c PRReviewComment) IsFileLevel() bool {
+	return c.Path != nil && c.Line == nil
+}
+
+func (c PRReviewComment) IsMultiLine() bool {
+	return c.StartLine != nil && c.Line != nil && *c.StartLine < *c.Line
 }
 
 type PRReviewCommentAction string
@@ -26,4 +36,8 @@ type PRReviewCommentResponse struct {
 	FeedbackCommentID *int                  `json:"feedback_comment_id"`
 	Points            int                   `json:"points"`
 	TAUsername        string                `json:"ta_username"`
+	Reason            *string               `json:"reason,omitempty"`
+	CommitSHA         *string               `json:"commit_sha,omitempty"`
+	Outdated          bool                  `json:"outdated"`
+	GitHubCommentID   *int64                `json:"github_comment_id,omitempty"`
 }
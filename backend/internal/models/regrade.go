@@ -1,7 +1,43 @@
 package models
 
+import "time"
+
 type Regrade struct {
 	StudentGHUsername string `json:"student_gh_username" db:"student_gh_username"`
 	TAGHUsername      string `json:"ta_gh_username" db:"ta_gh_username"`
 	DueDateID         int32  `json:"due_date_id"`
 }
+
+type RegradeState string
+
+const (
+	RegradeRequested RegradeState = "REGRADE_REQUESTED"
+	RegradeFinalized RegradeState = "REGRADE_FINALIZED"
+)
+
+// A student's request to have one of the feedback comments on their work regraded
+type RegradeRequest struct {
+	ID                int          `json:"id" db:"id"`
+	FeedbackCommentID int          `json:"feedback_comment_id" db:"feedback_comment_id"`
+	StudentWorkID     int          `json:"student_work_id" db:"student_work_id"`
+	State             RegradeState `json:"regrade_state" db:"regrade_state"`
+	StudentComment    string       `json:"student_comment" db:"student_comment"`
+	RequestedBy       *int64       `json:"requested_by" db:"requested_by"`
+	ResolvedBy        *int64       `json:"resolved_by" db:"resolved_by"`
+	Resolution        *string      `json:"resolution" db:"resolution"`
+	ResolvedAt        *time.Time   `json:"resolved_at" db:"resolved_at"`
+	CreatedAt         time.Time    `json:"created_at" db:"created_at"`
+}
+
+type RegradeRequestBody struct {
+	FeedbackCommentID int    `json:"feedback_comment_id"`
+	Comment           string `json:"comment"`
+}
+
+// How staff resolve a regrade request. The feedback comment keeps its points unless new ones are given.
+type RegradeResolutionBody struct {
+	Reason string `json:"reason"`
+	Points *int   `json:"points,omitempty"`
+	// replaces the comment's explanation along with its points
+	Body *string `json:"body,omitempty"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Paths students can never push changes to, since they hold the deadline and PR enforcement workflows
+var DefaultProtectedPaths = []string{".github/**/*"}
+
+// The branches and protected paths set up in an assignment's base repository and student forks
+type RepoLayout struct {
+	// the branch students submit to, which the feedback pull request is opened from
+	SubmissionBranch string `json:"submission_branch"`
+	// the branch the feedback pull request targets, students cannot open pull requests against it
+	FeedbackBranch string `json:"feedback_branch"`
+	// extra branches created for students to work on
+	WorkingBranches []string `json:"working_branches"`
+	// paths students cannot push changes to, on top of DefaultProtectedPaths
+	ProtectedPaths []string `json:"protected_paths"`
+}
+
+// The layout used when an assignment doesn't configure one
+func DefaultRepoLayout() RepoLayout {
+	return RepoLayout{
+		SubmissionBranch: "main",
+		FeedbackBranch:   "feedback",
+		WorkingBranches:  []string{"development"},
+		ProtectedPaths:   []string{},
+	}
+}
+
+// Fills in the default for any part of the layout left unset
+func (l RepoLayout) WithDefaults() RepoLayout {
+	defaults := DefaultRepoLayout()
+	if l.SubmissionBranch == "" {
+		l.SubmissionBranch = defaults.SubmissionBranch
+	}
+	if l.FeedbackBranch == "" {
+		l.FeedbackBranch = defaults.FeedbackBranch
+	}
+	if l.WorkingBranches == nil {
+		l.WorkingBranches = defaults.WorkingBranches
+	}
+	if l.ProtectedPaths == nil {
+		l.ProtectedPaths = defaults.ProtectedPaths
+	}
+	return l
+}
+
+// Checks that every branch name is usable and distinct
+func (l RepoLayout) Validate() error {
+	seen := map[string]bool{}
+	for _, branch := range l.Branches() {
+		if branch == "" || strings.ContainsAny(branch, " ~^:?*[\\") || strings.Contains(branch, "..") ||
+			strings.HasPrefix(branch, "/") || strings.HasSuffix(branch, "/") {
+			return fmt.Errorf("invalid branch name %q", branch)
+		}
+		if seen[branch] {
+			return fmt.Errorf("branch %q is used more than once", branch)
+		}
+		seen[branch] = true
+	}
+
+	for _, path := range l.ProtectedPaths {
+		if strings.TrimSpace(path) == "" {
+			return errors.New("protected paths cannot be empty")
+		}
+	}
+
+	return nil
+}
+
+// All branches of the layout, submission branch first
+func (l RepoLayout) Branches() []string {
+	return append([]string{l.SubmissionBranch, l.FeedbackBranch}, l.WorkingBranches...)
+}
+
+// Paths protected from student pushes, including the workflow directory
+func (l RepoLayout) RestrictedPaths() []string {
+	return append(append([]string{}, DefaultProtectedPaths...), l.ProtectedPaths...)
+}
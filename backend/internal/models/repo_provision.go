@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// The creation of a single student's repository ahead of time, made by the app instead of an accept link
+type RepoProvision struct {
+	ID                  int       `json:"id" db:"id"`
+	AssignmentOutlineID int       `json:"assignment_outline_id" db:"assignment_outline_id"`
+	UserID              int64     `json:"user_id" db:"user_id"`
+	GithubUsername      string    `json:"github_username" db:"github_username"`
+	GithubUserID        int64     `json:"github_user_id" db:"github_user_id"`
+	RepoName            *string   `json:"repo_name" db:"repo_name"`
+	CreatedAt           time.Time `json:"created_at" db:"created_at"`
+	Job
+}
@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// Upper bound on the rows of a single roster import, each of which takes several GitHub API calls once it runs
+const MaxRosterImportRows = 1000
+
+// A row of a roster CSV
+type RosterEntry struct {
+	Row            int           `json:"row"`
+	FirstName      string        `json:"first_name"`
+	LastName       string        `json:"last_name"`
+	GithubUsername string        `json:"github_username"`
+	ExternalID     *string       `json:"external_id,omitempty"`
+	Role           ClassroomRole `json:"classroom_role"`
+}
+
+type RosterImportStatus string
+
+const (
+	// the user was added to the classroom and invited to the organization
+	RosterImportInvited RosterImportStatus = "INVITED"
+	// the user was already in the classroom, their role is only ever upgraded
+	RosterImportAlreadyMember RosterImportStatus = "ALREADY_MEMBER"
+	// the row repeats a GitHub username from an earlier row
+	RosterImportSkipped RosterImportStatus = "SKIPPED"
+	RosterImportFailed  RosterImportStatus = "FAILED"
+)
+
+// The outcome of importing a roster row. On a dry run, it is the outcome the import would have.
+type RosterImportResult struct {
+	RosterEntry
+	Status      RosterImportStatus `json:"status"`
+	UserCreated bool               `json:"user_created"`
+	Error       *string            `json:"error,omitempty"`
+	User        *ClassroomUser     `json:"user,omitempty"`
+}
+
+// A roster CSV imported into a classroom in the background
+type RosterImport struct {
+	ID          int       `json:"id" db:"id"`
+	ClassroomID int64     `json:"classroom_id" db:"classroom_id"`
+	DryRun      bool      `json:"dry_run" db:"dry_run"`
+	CreatedBy   *int64    `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// A row of an import still to be run, or its outcome once it has. Rows repeating an earlier row are queued with
+// their outcome already set.
+type RosterImportRow struct {
+	ID        int                 `json:"id" db:"id"`
+	ImportID  int                 `json:"import_id" db:"import_id"`
+	Entry     RosterEntry         `json:"entry" db:"entry"`
+	Result    *RosterImportResult `json:"result" db:"result"`
+	CreatedAt time.Time           `json:"created_at" db:"created_at"`
+	Job
+}
+
+// An import with how far along its rows are, and the outcomes of those that have run
+type RosterImportProgress struct {
+	RosterImport
+	Rows   []RosterImportRow          `json:"rows"`
+	Counts map[JobStatus]int          `json:"counts"`
+	Status map[RosterImportStatus]int `json:"status_counts"`
+}
+
+func NewRosterImportProgress(rosterImport RosterImport, rows []RosterImportRow) RosterImportProgress {
+	statuses := map[RosterImportStatus]int{
+		RosterImportInvited:       0,
+		RosterImportAlreadyMember: 0,
+		RosterImportSkipped:       0,
+		RosterImportFailed:        0,
+	}
+	for _, row := range rows {
+		if row.Result != nil {
+			statuses[row.Result.Status]++
+		}
+	}
+	return RosterImportProgress{RosterImport: rosterImport, Rows: rows, Counts: CountJobs(rows), Status: statuses}
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// A lab section within a classroom, optionally mirrored by a GitHub team in the classroom's org
+type Section struct {
+	ID          int64     `json:"id" db:"id"`
+	ClassroomID int64     `json:"classroom_id" db:"classroom_id"`
+	Name        string    `json:"name" db:"name"`
+	TeamID      *int64    `json:"team_id,omitempty" db:"team_id"`
+	TeamName    *string   `json:"team_name,omitempty" db:"team_name"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// A student or TA in a section, with their role in the classroom
+type SectionMember struct {
+	User
+	SectionID int64         `json:"section_id"`
+	Role      ClassroomRole `json:"classroom_role"`
+}
+
+type SectionRequestBody struct {
+	Name string `json:"name"`
+	// whether to create a GitHub team for the section's members
+	CreateTeam bool `json:"create_team"`
+}
+
+type SectionMembersRequestBody struct {
+	UserIDs []int64 `json:"user_ids"`
+}
+
+// Splits a section's members into its TAs, the section's default graders, and its students
+func SplitSectionMembers(members []SectionMember) (tas []SectionMember, students []SectionMember) {
+	tas, students = []SectionMember{}, []SectionMember{}
+	for _, member := range members {
+		if member.Role == Student {
+			students = append(students, member)
+		} else {
+			tas = append(tas, member)
+		}
+	}
+	return tas, students
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strconv"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -11,15 +12,53 @@ type Session struct {
 	AccessToken  string `json:"access_token"`
 	TokenType    string `json:"token_type"`
 	RefreshToken string `json:"refresh_token"`
-	ExpiresIn    int64  `json:"expires_in"`
+	// when the access token expires, nil if it doesn't
+	ExpiresAt *time.Time `json:"expires_at"`
+	// when the refresh token expires, after which the user has to log in again
+	RefreshTokenExpiresAt *time.Time `json:"refresh_token_expires_at"`
+}
+
+// Builds a session from the token GitHub issued, keeping the expiry GitHub reported
+func NewSession(githubUserID int64, token *oauth2.Token) Session {
+	session := Session{
+		GitHubUserID: githubUserID,
+		AccessToken:  token.AccessToken,
+		TokenType:    token.TokenType,
+		RefreshToken: token.RefreshToken,
+	}
+	if !token.Expiry.IsZero() {
+		expiresAt := token.Expiry.UTC()
+		session.ExpiresAt = &expiresAt
+	}
+	if seconds := tokenExtraSeconds(token, "refresh_token_expires_in"); seconds > 0 {
+		refreshTokenExpiresAt := time.Now().UTC().Add(time.Duration(seconds) * time.Second)
+		session.RefreshTokenExpiresAt = &refreshTokenExpiresAt
+	}
+	return session
 }
 
 func (sess *Session) CreateToken() oauth2.Token {
-	return oauth2.Token{
+	token := oauth2.Token{
 		AccessToken:  sess.AccessToken,
 		TokenType:    sess.TokenType,
 		RefreshToken: sess.RefreshToken,
-		Expiry:       time.Now().Add(time.Duration(sess.ExpiresIn) * time.Second),
-		ExpiresIn:    int64(sess.ExpiresIn),
 	}
+	if sess.ExpiresAt != nil {
+		token.Expiry = *sess.ExpiresAt
+	}
+	return token
+}
+
+// Reads a number of seconds GitHub sends alongside the token, which may be decoded as a number or a string
+func tokenExtraSeconds(token *oauth2.Token, key string) int64 {
+	switch value := token.Extra(key).(type) {
+	case float64:
+		return int64(value)
+	case int64:
+		return value
+	case string:
+		seconds, _ := strconv.ParseInt(value, 10, 64)
+		return seconds
+	}
+	return 0
 }
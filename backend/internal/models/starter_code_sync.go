@@ -0,0 +1,54 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// A starter code update pushed from an assignment's base repository to the student forks
+type StarterCodeSync struct {
+	ID                  int       `json:"id" db:"id"`
+	AssignmentOutlineID int       `json:"assignment_outline_id" db:"assignment_outline_id"`
+	BaseCommitSHA       string    `json:"base_commit_sha" db:"base_commit_sha"`
+	HeadCommitSHA       string    `json:"head_commit_sha" db:"head_commit_sha"`
+	Title               string    `json:"title" db:"title"`
+	CreatedByUserID     int64     `json:"created_by_user_id" db:"created_by_user_id"`
+	CreatedAt           time.Time `json:"created_at" db:"created_at"`
+}
+
+// Whether a starter code pull request was merged or closed once opened
+type StarterCodePRState string
+
+const (
+	StarterCodePROpen   StarterCodePRState = "OPEN"
+	StarterCodePRMerged StarterCodePRState = "MERGED"
+	StarterCodePRClosed StarterCodePRState = "CLOSED"
+)
+
+// The pull request opened in a single student fork for a starter code update
+type StarterCodeSyncPR struct {
+	ID            int                 `json:"id" db:"id"`
+	SyncID        int                 `json:"sync_id" db:"sync_id"`
+	StudentWorkID int                 `json:"student_work_id" db:"student_work_id"`
+	RepoName      string              `json:"repo_name" db:"repo_name"`
+	PRNumber      *int                `json:"pr_number" db:"pr_number"`
+	PRURL         *string             `json:"pr_url" db:"pr_url"`
+	PRState       *StarterCodePRState `json:"pr_state" db:"pr_state"`
+	Job
+}
+
+// A starter code update with the rollout status of each fork
+type StarterCodeSyncWithPRs struct {
+	StarterCodeSync
+	PullRequests []StarterCodeSyncPR `json:"pull_requests"`
+}
+
+type StarterCodeSyncRequestBody struct {
+	BaseCommitSHA string `json:"base_commit_sha"`
+	Title         string `json:"title,omitempty"`
+}
+
+// Name of the branch a starter code update is committed to in each fork
+func (s StarterCodeSync) BranchName() string {
+	return fmt.Sprintf("starter-code-update-%d", s.ID)
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// A push to a work's submission branch. Timeliness is judged by when the server received the push,
+// not by the commit's own timestamp.
+type Submission struct {
+	ID                 int        `json:"id" db:"id"`
+	StudentWorkID      int        `json:"student_work_id" db:"student_work_id"`
+	CommitSHA          string     `json:"commit_sha" db:"commit_sha"`
+	PushedBy           *string    `json:"pushed_by" db:"pushed_by"`
+	SubmittedAt        time.Time  `json:"submitted_at" db:"submitted_at"`
+	DueDate            *time.Time `json:"due_date" db:"due_date"`
+	OnTime             bool       `json:"on_time" db:"on_time"`
+	TagName            *string    `json:"tag_name" db:"tag_name"`
+	SelectedForGrading bool       `json:"selected_for_grading" db:"selected_for_grading"`
+	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+}
+
+// The name of the git tag marking the submission in the student's repository
+func (s Submission) Tag() string {
+	return fmt.Sprintf("submission-%d", s.ID)
+}
@@ -1,9 +1,100 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+)
 
 type BaseToken struct {
 	Token     string     `json:"token"`
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 	CreatedAt time.Time  `json:"created_at"`
+	// nil max uses means the token can be used by any number of users
+	MaxUses  *int `json:"max_uses,omitempty"`
+	UseCount int  `json:"use_count"`
+	Revoked  bool `json:"revoked"`
+	// when either list is set, only users with one of the GitHub usernames or a verified email on one of the
+	// domains can use the token
+	AllowedUsernames    []string `json:"allowed_usernames,omitempty"`
+	AllowedEmailDomains []string `json:"allowed_email_domains,omitempty"`
+	CreatedBy           *int64   `json:"created_by,omitempty"`
+}
+
+// Limits on who can use a new token and how many times, shared by the token request bodies
+type TokenLimitsRequestBody struct {
+	MaxUses             *int     `json:"max_uses,omitempty"`
+	AllowedUsernames    []string `json:"allowed_usernames,omitempty"`
+	AllowedEmailDomains []string `json:"allowed_email_domains,omitempty"`
+}
+
+func (body TokenLimitsRequestBody) HasLimits() bool {
+	return body.MaxUses != nil || len(body.AllowedUsernames) > 0 || len(body.AllowedEmailDomains) > 0
+}
+
+// Copies the limits onto a new token, dropping blank allowlist entries
+func (body TokenLimitsRequestBody) Apply(token *BaseToken) error {
+	if body.MaxUses != nil && *body.MaxUses < 1 {
+		return errors.New("max uses must be at least 1")
+	}
+
+	token.MaxUses = body.MaxUses
+	token.AllowedUsernames = trimAllowlist(body.AllowedUsernames)
+	token.AllowedEmailDomains = trimAllowlist(body.AllowedEmailDomains)
+	return nil
+}
+
+func trimAllowlist(entries []string) []string {
+	var trimmed []string
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			trimmed = append(trimmed, entry)
+		}
+	}
+	return trimmed
+}
+
+// Returns why the token can't be used right now, or nil if it can. Whether the token has uses left is checked when
+// it's redeemed, since users who have used it before can keep using it.
+func (t BaseToken) Usable(now time.Time) error {
+	if t.Revoked {
+		return errs.RevokedTokenError()
+	}
+	if t.ExpiresAt != nil && t.ExpiresAt.Before(now) {
+		return errs.ExpiredTokenError()
+	}
+	return nil
+}
+
+func (t BaseToken) UsedUp() bool {
+	return t.MaxUses != nil && t.UseCount >= *t.MaxUses
+}
+
+func (t BaseToken) HasAllowlist() bool {
+	return len(t.AllowedUsernames) > 0 || len(t.AllowedEmailDomains) > 0
+}
+
+// Whether a user with the given GitHub username and verified emails is on the token's allowlist
+func (t BaseToken) Allows(username string, emails []string) bool {
+	if !t.HasAllowlist() {
+		return true
+	}
+
+	for _, allowed := range t.AllowedUsernames {
+		if strings.EqualFold(allowed, username) {
+			return true
+		}
+	}
+	return EmailMatchesDomains(emails, t.AllowedEmailDomains)
+}
+
+// A use of a classroom or assignment token
+type TokenRedemption struct {
+	ID        int64     `json:"id" db:"id"`
+	Token     string    `json:"token" db:"token"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
@@ -18,6 +18,28 @@ type StudentWork struct {
 	CommitAmount             int        `json:"commit_amount" db:"commit_amount"`
 	FirstCommitDate          *time.Time `json:"first_commit_date" db:"first_commit_date"`
 	LastCommitDate           *time.Time `json:"last_commit_date" db:"last_commit_date"`
+	// set once the due date passes and the students lose push access, unless a professor exempted the work
+	LockedAt        *time.Time `json:"locked_at" db:"locked_at"`
+	LockedCommitSHA *string    `json:"locked_commit_sha" db:"locked_commit_sha"`
+	LockExempt      bool       `json:"lock_exempt" db:"lock_exempt"`
+}
+
+// Locking a work is retried until it reaches this many attempts
+const MaxWorkLockAttempts = 3
+
+// A student work whose due date has passed, with what's needed to lock its repository
+type LockableWork struct {
+	StudentWorkID    int       `db:"student_work_id"`
+	OrgName          string    `db:"org_name"`
+	RepoName         string    `db:"repo_name"`
+	SubmissionBranch string    `db:"submission_branch"`
+	DueDate          time.Time `db:"due_date"`
+	Contributors     []string  `db:"contributors"`
+}
+
+// Optionally gives an unlocked work a new due date to be locked at, instead of leaving it unlocked
+type UnlockWorkRequestBody struct {
+	DueDate *time.Time `json:"due_date"`
 }
 
 type WorkState string
@@ -75,6 +97,12 @@ type StudentWorkWithContributors struct {
 	Contributors []IWorkContributor `json:"contributors"`
 }
 
+// a student's view of their own work: scores are only filled in once grades are published
+type StudentFacingWork struct {
+	StudentWorkWithContributors
+	RepoURL string `json:"repo_url"`
+}
+
 type PaginatedStudentWorkWithContributors struct {
 	PaginatedStudentWork
 	Contributors []IWorkContributor `json:"contributors"`
@@ -110,6 +138,10 @@ func (w *StudentWorkWithContributors) AddContributor(contributor IWorkContributo
 	w.Contributors = append(w.Contributors, contributor)
 }
 
+func (w StudentWork) GradesPublished() bool {
+	return w.WorkState == WorkStateGradePublished && w.GradesPublishedTimestamp != nil
+}
+
 func (w *PaginatedStudentWorkWithContributors) AddContributor(contributor IWorkContributor) {
 	w.Contributors = append(w.Contributors, contributor)
 }
@@ -0,0 +1,38 @@
+package provisioning
+
+import (
+	"context"
+
+	"github.com/CamPlume1/khoury-classroom/internal/github"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/storage"
+)
+
+// Invites a classroom member to the org for their role and marks them as invited. Students are invited through the
+// student team, while TAs and professors are made org admins and added to the staff team.
+func InviteToOrganization(ctx context.Context, store storage.Storage, client github.GitHubBaseClient, classroom models.Classroom, classroomRole models.ClassroomRole, user models.User) (models.ClassroomUser, error) {
+	if classroomRole == models.Student {
+		studentTeam, err := client.GetTeamByName(ctx, classroom.OrgName, *classroom.StudentTeamName)
+		if err != nil {
+			return models.ClassroomUser{}, err
+		}
+
+		err = client.AddTeamMember(ctx, studentTeam.GetID(), user.GithubUsername, nil)
+		if err != nil {
+			return models.ClassroomUser{}, err
+		}
+	} else {
+		err := client.SetUserMembershipInOrg(ctx, classroom.OrgName, user.GithubUsername, "admin")
+		if err != nil {
+			return models.ClassroomUser{}, err
+		}
+
+		// Add them to the staff team, which has access to the classroom's repositories
+		err = AddStaffTeamMember(ctx, store, client, classroom, user.GithubUsername, classroomRole)
+		if err != nil {
+			return models.ClassroomUser{}, err
+		}
+	}
+
+	return store.ModifyUserStatus(ctx, classroom.ID, models.UserStatusOrgInvited, *user.ID)
+}
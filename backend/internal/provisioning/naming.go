@@ -0,0 +1,54 @@
+package provisioning
+
+import (
+	"context"
+
+	"github.com/CamPlume1/khoury-classroom/internal/github"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/storage"
+	"github.com/CamPlume1/khoury-classroom/internal/utils"
+)
+
+// Picks a free name for a new base repository from the classroom's naming template
+func BaseRepoName(ctx context.Context, store storage.Storage, client github.GitHubBaseClient, classroom models.Classroom, assignmentName string) (string, error) {
+	name := utils.RenderRepoName(classroom.BaseRepoNameTemplate, repoNameValues(classroom, assignmentName, ""))
+
+	return utils.ResolveRepoNameCollision(ctx, name, func(ctx context.Context, candidate string) (bool, error) {
+		taken, err := store.IsRepoNameTaken(ctx, candidate)
+		if err != nil || taken {
+			return taken, err
+		}
+
+		repo, _ := client.GetRepository(ctx, classroom.OrgName, candidate)
+		return repo != nil, nil
+	})
+}
+
+// Picks a free name for a new student repository from the classroom's naming template. The owner is the
+// student's login, or the group name on group assignments.
+func WorkRepoName(ctx context.Context, store storage.Storage, client github.GitHubBaseClient, classroom models.Classroom, assignment models.AssignmentOutline, baseRepo models.AssignmentBaseRepo, owner string) (string, error) {
+	name := utils.RenderRepoName(classroom.WorkRepoNameTemplate, repoNameValues(classroom, assignment.Name, owner))
+
+	return utils.ResolveRepoNameCollision(ctx, name, func(ctx context.Context, candidate string) (bool, error) {
+		taken, err := store.IsRepoNameTaken(ctx, candidate)
+		if err != nil || taken {
+			return taken, err
+		}
+
+		// a fork of the base repository left behind by an acceptance that never finished is picked up again
+		repo, _ := client.GetRepository(ctx, classroom.OrgName, candidate)
+		if repo == nil {
+			return false, nil
+		}
+		return !repo.GetFork() || repo.GetParent().GetFullName() != baseRepo.BaseRepoOwner+"/"+baseRepo.BaseRepoName, nil
+	})
+}
+
+func repoNameValues(classroom models.Classroom, assignmentName, owner string) map[string]string {
+	return map[string]string{
+		utils.RepoNameOrg:        classroom.OrgName,
+		utils.RepoNameClassroom:  classroom.Name,
+		utils.RepoNameAssignment: assignmentName,
+		utils.RepoNameLogin:      owner,
+	}
+}
@@ -0,0 +1,111 @@
+package provisioning
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/github"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/storage"
+	gh "github.com/google/go-github/github"
+)
+
+// Forks the base repository into the classroom's org under the given name and sets the fork up for grading,
+// recording the student work for the given GitHub user. A fork that already exists is picked up instead, and every
+// setup step is run again on it, skipping what is already done, so the setup can be retried after a partial
+// failure. Returns whether the fork already existed.
+func SetUpWorkRepo(ctx context.Context, store storage.Storage, client github.GitHubBaseClient, classroom models.Classroom,
+	assignment models.AssignmentOutline, baseRepo models.AssignmentBaseRepo, repoName string, githubUserID int64,
+) (models.StudentWork, *gh.Repository, bool, error) {
+	// Check if fork already exists
+	studentWorkRepo, _ := client.GetRepository(ctx, classroom.OrgName, repoName)
+	alreadyExists := studentWorkRepo != nil
+	if !alreadyExists {
+		var err error
+		studentWorkRepo, err = forkBaseRepo(ctx, client, classroom, baseRepo, repoName)
+		if err != nil {
+			return models.StudentWork{}, nil, false, err
+		}
+	}
+
+	//KHO-239
+	err := client.CreateBranchRuleset(ctx, classroom.OrgName, repoName,
+		assignment.RepoLayout.SubmissionBranch, assignment.RepoLayout.FeedbackBranch)
+	if err != nil {
+		return models.StudentWork{}, nil, false, errs.CriticalGithubError()
+	}
+
+	// Remove student team's access to forked repo
+	err = client.RemoveRepoFromTeam(ctx, classroom.OrgName, *classroom.StudentTeamName, classroom.OrgName, *studentWorkRepo.Name)
+	if err != nil {
+		return models.StudentWork{}, nil, false, errs.GithubAPIError(err)
+	}
+
+	// Create initial feedback pull request
+	err = client.CreateFeedbackPR(ctx, classroom.OrgName, *studentWorkRepo.Name,
+		assignment.RepoLayout.SubmissionBranch, assignment.RepoLayout.FeedbackBranch)
+	if err != nil {
+		return models.StudentWork{}, nil, false, errs.GithubAPIError(err)
+	}
+
+	// Insert into DB
+	studentWork, err := store.GetWorkByRepoName(ctx, *studentWorkRepo.Name)
+	if err != nil {
+		// Recover from the case where the student work does not exist, but the repo does exist
+		studentWork, err = store.CreateStudentWork(ctx, assignment.ID, githubUserID, repoName, models.WorkStateAccepted, assignment.MainDueDate)
+		if err != nil {
+			return models.StudentWork{}, nil, false, errs.InternalServerError()
+		}
+	} else if studentWork.WorkState == models.WorkStateNotAccepted {
+		// Recover from the case where the workstate is out of sync with the github state (repo exists but student work is not accepted)
+		updatedStudentWork := studentWork
+		updatedStudentWork.WorkState = models.WorkStateAccepted
+		_, err = store.UpdateStudentWork(ctx, updatedStudentWork)
+		if err != nil {
+			return models.StudentWork{}, nil, false, errs.InternalServerError()
+		}
+	}
+
+	// TODO Here: Enable Github Actions on student repo.
+
+	return studentWork, studentWorkRepo, alreadyExists, nil
+}
+
+// Forks the base repository into the classroom's org, waiting until the fork is ready to be set up
+func forkBaseRepo(ctx context.Context, client github.GitHubBaseClient, classroom models.Classroom,
+	baseRepo models.AssignmentBaseRepo, repoName string,
+) (*gh.Repository, error) {
+	err := client.ForkRepository(ctx,
+		baseRepo.BaseRepoOwner,
+		baseRepo.BaseRepoName,
+		classroom.OrgName,
+		repoName)
+	if err != nil {
+		return nil, errs.GithubAPIError(err)
+	}
+
+	// TODO HERE: insert repo name to fork_queue table, quit
+	// listen for webhook repository creation: if repo name in fork_queue table, create PR and remove read rights etc
+
+	// Wait to perform actions on the fork until it is finished initializing
+	initialDelay := 1 * time.Second
+	maxDelay := 30 * time.Second
+
+	for {
+		studentWorkRepo, _ := client.GetRepository(ctx, classroom.OrgName, repoName)
+		if studentWorkRepo != nil {
+			if client.CheckForkIsReady(ctx, studentWorkRepo) {
+				return studentWorkRepo, nil
+			}
+		}
+
+		if initialDelay > maxDelay {
+			return nil, errs.GithubAPIError(errors.New("fork unsuccessful, please try again later"))
+		}
+
+		time.Sleep(initialDelay)
+		initialDelay *= 2
+	}
+}
@@ -0,0 +1,64 @@
+package provisioning
+
+import (
+	"context"
+
+	"github.com/CamPlume1/khoury-classroom/internal/github"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/storage"
+)
+
+// Moves a member between the student and staff teams and sets their org role for their new classroom role. Changes
+// between TA and professor only update their role on the staff team.
+func SyncGitHubRole(ctx context.Context, store storage.Storage, client github.GitHubBaseClient, classroom models.Classroom, classroomUser models.ClassroomUser, classroomRole models.ClassroomRole) error {
+	wasStaff := classroomUser.Role != models.Student
+	isStaff := classroomRole != models.Student
+	if wasStaff && isStaff {
+		return AddStaffTeamMember(ctx, store, client, classroom, classroomUser.GithubUsername, classroomRole)
+	}
+
+	studentTeam, err := client.GetTeamByName(ctx, classroom.OrgName, *classroom.StudentTeamName)
+	if err != nil {
+		return err
+	}
+
+	if isStaff {
+		err = client.SetUserMembershipInOrg(ctx, classroom.OrgName, classroomUser.GithubUsername, "admin")
+		if err != nil {
+			return err
+		}
+		err = AddStaffTeamMember(ctx, store, client, classroom, classroomUser.GithubUsername, classroomRole)
+		if err != nil {
+			return err
+		}
+		return client.RemoveTeamMember(ctx, classroom.OrgName, studentTeam.GetID(), classroomUser.GithubUsername)
+	}
+
+	// Demote on GitHub in the reverse order, so a failure partway never leaves the user with less access than
+	// either of their roles
+	staffTeam, err := EnsureStaffTeam(ctx, store, client, classroom)
+	if err != nil {
+		return err
+	}
+	err = client.AddTeamMember(ctx, studentTeam.GetID(), classroomUser.GithubUsername, nil)
+	if err != nil {
+		return err
+	}
+	err = client.RemoveTeamMember(ctx, classroom.OrgName, staffTeam.GetID(), classroomUser.GithubUsername)
+	if err != nil {
+		return err
+	}
+
+	// Staff of another classroom in the org still need to be admins
+	memberships, err := store.GetUserClassroomsInOrg(ctx, classroom.OrgID, *classroomUser.ID)
+	if err != nil {
+		return err
+	}
+	for _, membership := range memberships {
+		if membership.ClassroomID != classroom.ID && membership.Role != models.Student && membership.Status != models.UserStatusRemoved {
+			return nil
+		}
+	}
+
+	return client.SetUserMembershipInOrg(ctx, classroom.OrgName, classroomUser.GithubUsername, "member")
+}
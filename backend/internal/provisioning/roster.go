@@ -0,0 +1,100 @@
+package provisioning
+
+import (
+	"context"
+	"errors"
+
+	"github.com/CamPlume1/khoury-classroom/internal/github"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+// Adds a roster row's user to the classroom and invites them to the organization, creating the user if they have
+// never signed in. Users already in the classroom keep their status and only have their role upgraded, on GitHub
+// too once they are in the org. On a dry run, nothing is changed and the result is the outcome the import would have.
+func ImportRosterEntry(ctx context.Context, store storage.Storage, client github.GitHubBaseClient, classroom models.Classroom, entry models.RosterEntry, dryRun bool) models.RosterImportResult {
+	result := models.RosterImportResult{RosterEntry: entry, Status: models.RosterImportInvited}
+	fail := func(message string) models.RosterImportResult {
+		result.Status = models.RosterImportFailed
+		result.Error = &message
+		return result
+	}
+
+	githubUser, err := client.GetUser(ctx, entry.GithubUsername)
+	if err != nil || githubUser == nil {
+		return fail("GitHub user not found")
+	}
+	result.GithubUsername = githubUser.GetLogin()
+
+	user, err := store.GetUserByGitHubID(ctx, githubUser.GetID())
+	if errors.Is(err, pgx.ErrNoRows) {
+		result.UserCreated = true
+		if dryRun {
+			return result
+		}
+
+		user, err = store.CreateUser(ctx, models.User{
+			FirstName:      entry.FirstName,
+			LastName:       entry.LastName,
+			GithubUsername: githubUser.GetLogin(),
+			GithubUserID:   githubUser.GetID(),
+		})
+	}
+	if err != nil {
+		return fail("failed to save user")
+	}
+
+	role := entry.Role
+	existing, err := store.GetUserInClassroom(ctx, classroom.ID, *user.ID)
+	inClassroom := err == nil
+	if inClassroom {
+		// never downgrade a user through an import
+		if existing.Role.Compare(role) > 0 {
+			role = existing.Role
+		}
+		result.Role = role
+		result.User = &existing
+
+		if existing.Status == models.UserStatusActive || existing.Status == models.UserStatusOrgInvited {
+			result.Status = models.RosterImportAlreadyMember
+		}
+	}
+	if dryRun {
+		return result
+	}
+
+	if !inClassroom {
+		_, err = store.AddUserToClassroom(ctx, classroom.ID, string(role), models.UserStatusRequested, *user.ID)
+	} else if role != existing.Role {
+		// members already in the org get their GitHub access from the new role now, the rest once invited
+		if result.Status == models.RosterImportAlreadyMember {
+			err = SyncGitHubRole(ctx, store, client, classroom, existing, role)
+			if err != nil {
+				return fail("failed to update the user's role on GitHub")
+			}
+		}
+		_, err = store.ModifyUserRole(ctx, classroom.ID, string(role), *user.ID)
+	}
+	if err != nil {
+		return fail("failed to add user to classroom")
+	}
+
+	err = store.MarkOnClassroomRoster(ctx, classroom.ID, *user.ID, entry.ExternalID)
+	if err != nil {
+		return fail("failed to save roster membership")
+	}
+
+	var classroomUser models.ClassroomUser
+	if result.Status == models.RosterImportAlreadyMember {
+		classroomUser, err = store.GetUserInClassroom(ctx, classroom.ID, *user.ID)
+	} else {
+		classroomUser, err = InviteToOrganization(ctx, store, client, classroom, role, user)
+	}
+	if err != nil {
+		return fail("failed to invite user to the organization")
+	}
+	result.User = &classroomUser
+
+	return result
+}
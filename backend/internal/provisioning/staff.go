@@ -0,0 +1,168 @@
+package provisioning
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/CamPlume1/khoury-classroom/internal/github"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/storage"
+	gh "github.com/google/go-github/github"
+)
+
+const (
+	// Staff maintain the base repositories, e.g. to fix starter code, but only read student repositories
+	StaffBaseRepoPermission = "maintain"
+	StaffWorkRepoPermission = "pull"
+)
+
+// Name of the GitHub team of a classroom's TAs and professors
+func StaffTeamName(classroom models.Classroom) string {
+	return strings.ReplaceAll(strings.ToLower(classroom.Name), " ", "-") + "-staff"
+}
+
+func StaffTeamDescription(classroom models.Classroom) string {
+	return "The TAs and professors of " + classroom.OrgName + " - " + classroom.Name + ".\n\nAutomatically generated by Khoury Classroom."
+}
+
+// Gets the classroom's staff team, creating it and recording it on the classroom the first time it's needed. This is
+// the only place staff teams are created: when a classroom is set up, for classrooms made before staff teams existed,
+// and for classrooms whose team has since been deleted on GitHub.
+func EnsureStaffTeam(ctx context.Context, store storage.Storage, client github.GitHubBaseClient, classroom models.Classroom) (*gh.Team, error) {
+	if classroom.StaffTeamName != nil {
+		team, err := client.GetTeamByName(ctx, classroom.OrgName, *classroom.StaffTeamName)
+		if err == nil {
+			return team, nil
+		}
+	}
+
+	// a team already using the name wasn't created for this classroom, and may give others access to its repositories
+	if StaffTeamTaken(ctx, client, classroom) {
+		return nil, fmt.Errorf("team %s already exists in %s and is not the classroom's staff team", StaffTeamName(classroom), classroom.OrgName)
+	}
+
+	description := StaffTeamDescription(classroom)
+	team, err := client.CreateTeam(ctx, classroom.OrgName, StaffTeamName(classroom), &description, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.SetClassroomStaffTeam(ctx, classroom.ID, team.GetSlug())
+	if err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+// Whether the org already has a team with the name the classroom's staff team would be created with, other than the
+// team recorded on the classroom
+func StaffTeamTaken(ctx context.Context, client github.GitHubBaseClient, classroom models.Classroom) bool {
+	team, err := client.GetTeamByName(ctx, classroom.OrgName, StaffTeamName(classroom))
+	if err != nil || team == nil {
+		return false
+	}
+	return classroom.StaffTeamName == nil || team.GetSlug() != *classroom.StaffTeamName
+}
+
+// Adds a TA or professor to the classroom's staff team. Professors maintain the team. Adding someone already on
+// the team updates their team role.
+func AddStaffTeamMember(ctx context.Context, store storage.Storage, client github.GitHubBaseClient, classroom models.Classroom, githubUsername string, role models.ClassroomRole) error {
+	team, err := EnsureStaffTeam(ctx, store, client, classroom)
+	if err != nil {
+		return err
+	}
+
+	return client.AddTeamMember(ctx, team.GetID(), githubUsername, staffTeamMembership(role))
+}
+
+func staffTeamMembership(role models.ClassroomRole) *gh.TeamAddTeamMembershipOptions {
+	if role == models.Professor {
+		return &gh.TeamAddTeamMembershipOptions{Role: "maintainer"}
+	}
+	return &gh.TeamAddTeamMembershipOptions{Role: "member"}
+}
+
+// Gives the classroom's staff team access to one of its repositories
+func GrantStaffAccess(ctx context.Context, store storage.Storage, client github.GitHubBaseClient, classroom models.Classroom, repoName string, permission string) error {
+	team, err := EnsureStaffTeam(ctx, store, client, classroom)
+	if err != nil {
+		return err
+	}
+
+	return client.UpdateTeamRepoPermissions(ctx, classroom.OrgName, team.GetSlug(), classroom.OrgName, repoName, permission)
+}
+
+// What a staff access repair did for a classroom. Failures are collected rather than stopping the repair.
+type StaffAccessReport struct {
+	ClassroomID int64    `json:"classroom_id"`
+	TeamName    string   `json:"team_name"`
+	Members     int      `json:"members"`
+	BaseRepos   int      `json:"base_repos"`
+	WorkRepos   int      `json:"work_repos"`
+	Failures    []string `json:"failures"`
+}
+
+// Creates the classroom's staff team if needed, adds every TA and professor in the org to it, and grants it
+// access to every base repository and student repository of the classroom's assignments
+func RepairStaffAccess(ctx context.Context, store storage.Storage, client github.GitHubBaseClient, classroom models.Classroom) (StaffAccessReport, error) {
+	report := StaffAccessReport{ClassroomID: classroom.ID, Failures: []string{}}
+
+	team, err := EnsureStaffTeam(ctx, store, client, classroom)
+	if err != nil {
+		return report, err
+	}
+	report.TeamName = team.GetSlug()
+
+	users, err := store.GetUsersInClassroom(ctx, classroom.ID)
+	if err != nil {
+		return report, err
+	}
+	for _, user := range users {
+		if user.Role == models.Student || (user.Status != models.UserStatusActive && user.Status != models.UserStatusOrgInvited) {
+			continue
+		}
+		err = client.AddTeamMember(ctx, team.GetID(), user.GithubUsername, staffTeamMembership(user.Role))
+		if err != nil {
+			report.Failures = append(report.Failures, fmt.Sprintf("member %s: %v", user.GithubUsername, err))
+			continue
+		}
+		report.Members++
+	}
+
+	assignments, err := store.GetAssignmentsInClassroom(ctx, classroom.ID)
+	if err != nil {
+		return report, err
+	}
+	for _, assignment := range assignments {
+		baseRepo, err := store.GetBaseRepoByID(ctx, assignment.BaseRepoID)
+		if err != nil {
+			return report, err
+		}
+		err = client.UpdateTeamRepoPermissions(ctx, classroom.OrgName, report.TeamName, classroom.OrgName, baseRepo.BaseRepoName, StaffBaseRepoPermission)
+		if err != nil {
+			report.Failures = append(report.Failures, fmt.Sprintf("base repo %s: %v", baseRepo.BaseRepoName, err))
+		} else {
+			report.BaseRepos++
+		}
+
+		works, err := store.GetWorks(ctx, int(classroom.ID), int(assignment.ID), nil)
+		if err != nil {
+			return report, err
+		}
+		for _, work := range works {
+			if work.WorkState == models.WorkStateNotAccepted || work.RepoName == "" {
+				continue
+			}
+			err = client.UpdateTeamRepoPermissions(ctx, classroom.OrgName, report.TeamName, classroom.OrgName, work.RepoName, StaffWorkRepoPermission)
+			if err != nil {
+				report.Failures = append(report.Failures, fmt.Sprintf("student repo %s: %v", work.RepoName, err))
+				continue
+			}
+			report.WorkRepos++
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/CamPlume1/khoury-classroom/internal/github"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/provisioning"
+	"github.com/jackc/pgx/v5"
+)
+
+// Number of assignments copied per run, each creating a base repository from its template
+const cloneBatchSize = 10
+
+// Copies the assignments queued by classroom clones into their new classrooms
+func (s *Scheduler) cloneAssignments(ctx context.Context) error {
+	pending, err := s.store.GetPendingClassroomCloneAssignments(ctx, cloneBatchSize)
+	if err != nil {
+		return err
+	}
+
+	clones := make(map[int]models.ClassroomClone)
+	for _, item := range pending {
+		clone, ok := clones[item.CloneID]
+		if !ok {
+			clone, err = s.store.GetClassroomCloneByID(ctx, item.CloneID)
+			if err != nil {
+				return err
+			}
+			clones[item.CloneID] = clone
+		}
+
+		err := s.cloneAssignment(ctx, clone, &item)
+		if err != nil {
+			slog.Error("Failed to clone assignment", "clone", clone.ID, "assignment", item.SourceAssignmentID, "err", err)
+		}
+		item.Finish(err)
+
+		err = s.store.CompleteClassroomCloneAssignment(ctx, item)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Creates a base repository from the source assignment's template in the new classroom's org, and a copy of the
+// assignment with its dates shifted by the clone's offset
+func (s *Scheduler) cloneAssignment(ctx context.Context, clone models.ClassroomClone, item *models.ClassroomCloneAssignment) error {
+	source, err := s.store.GetAssignmentByID(ctx, item.SourceAssignmentID)
+	if err != nil {
+		return err
+	}
+	classroom, err := s.store.GetClassroomByID(ctx, clone.TargetClassroomID)
+	if err != nil {
+		return err
+	}
+
+	// An earlier attempt may have created the assignment before failing to record it
+	existing, err := s.store.GetAssignmentByNameAndClassroomID(ctx, source.Name, classroom.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if existing != nil {
+		targetAssignmentID := int64(existing.ID)
+		item.TargetAssignmentID = &targetAssignmentID
+		return nil
+	}
+
+	template, err := s.store.GetAssignmentTemplateByID(ctx, source.TemplateID)
+	if err != nil {
+		return err
+	}
+
+	appClient, err := s.appClients.ForOrg(ctx, classroom.OrgName)
+	if err != nil {
+		return err
+	}
+
+	baseRepo, err := s.cloneBaseRepo(ctx, appClient, classroom, template, source.Name, item)
+	if err != nil {
+		return err
+	}
+	err = provisioning.GrantStaffAccess(ctx, s.store, appClient, classroom, baseRepo.BaseRepoName, provisioning.StaffBaseRepoPermission)
+	if err != nil {
+		return err
+	}
+
+	assignment, err := s.store.CreateAssignment(ctx, models.AssignmentOutline{
+		TemplateID:       source.TemplateID,
+		BaseRepoID:       baseRepo.BaseID,
+		Name:             source.Name,
+		ClassroomID:      classroom.ID,
+		RubricID:         item.TargetRubricID,
+		GroupAssignment:  source.GroupAssignment,
+		MainDueDate:      clone.ShiftDate(source.MainDueDate),
+		DefaultScore:     source.DefaultScore,
+		MaxGroupSize:     source.MaxGroupSize,
+		SelfFormedGroups: source.SelfFormedGroups,
+		ReleasedAt:       clone.ShiftDate(source.ReleasedAt),
+		RepoLayout:       source.RepoLayout,
+		DeadlineWorkflow: source.DeadlineWorkflow,
+	})
+	if err != nil {
+		return err
+	}
+
+	targetAssignmentID := int64(assignment.ID)
+	item.TargetAssignmentID = &targetAssignmentID
+	return nil
+}
+
+// Creates the base repository of an assignment copy from its template. The repository's name is saved on the queue
+// row before it is created, so a retry after a failure picks up the repository an earlier attempt created.
+func (s *Scheduler) cloneBaseRepo(ctx context.Context, appClient github.GitHubAppClient, classroom models.Classroom, template models.AssignmentTemplate, assignmentName string, item *models.ClassroomCloneAssignment) (*models.AssignmentBaseRepo, error) {
+	if item.BaseRepoName == nil {
+		baseRepoName, err := provisioning.BaseRepoName(ctx, s.store, appClient, classroom, assignmentName)
+		if err != nil {
+			return nil, err
+		}
+		err = s.store.SetClassroomCloneBaseRepoName(ctx, item.ID, baseRepoName)
+		if err != nil {
+			return nil, err
+		}
+		item.BaseRepoName = &baseRepoName
+	}
+
+	// the name was free when it was picked, so a repository with it was created by an earlier attempt
+	baseRepo := &models.AssignmentBaseRepo{BaseRepoOwner: classroom.OrgName, BaseRepoName: *item.BaseRepoName}
+	repo, _ := appClient.GetRepository(ctx, classroom.OrgName, *item.BaseRepoName)
+	if repo != nil {
+		baseRepo.BaseID = repo.GetID()
+	} else {
+		var err error
+		baseRepo, err = appClient.CreateRepoFromTemplate(ctx, template.TemplateRepoOwner, template.TemplateRepoName, classroom.OrgName, *item.BaseRepoName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := s.store.CreateBaseRepo(ctx, *baseRepo)
+	if err != nil {
+		return nil, err
+	}
+	return baseRepo, nil
+}
@@ -0,0 +1,39 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Number of repositories updated per run, to stay well under GitHub's rate limits
+const deadlineUpdateBatchSize = 50
+
+// Re-renders the deadline enforcement workflow in repositories whose due date has changed
+func (s *Scheduler) propagateDeadlines(ctx context.Context) error {
+	updates, err := s.store.GetPendingDeadlineUpdates(ctx, deadlineUpdateBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, update := range updates {
+		appClient, err := s.appClients.ForOrg(ctx, update.RepoOwner)
+		if err == nil {
+			// repositories set up before the app could bypass their rulesets would reject the workflow commit
+			err = appClient.AllowAppRulesetBypass(ctx, update.RepoOwner, update.RepoName)
+		}
+		if err == nil {
+			err = appClient.CreateDeadlineEnforcement(ctx, &update.DueDate, update.RepoOwner, update.RepoName, update.BranchName)
+		}
+		if err != nil {
+			slog.Error("Failed to update deadline", "repo", update.RepoOwner+"/"+update.RepoName, "err", err)
+		}
+		update.Finish(err)
+
+		err = s.store.CompleteJob(ctx, update.Job)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/provisioning"
+)
+
+// Number of approved join requests invited per run, each making a few GitHub calls
+const joinApprovalBatchSize = 20
+
+// Invites the users whose join requests were approved to the org and their classroom's team
+func (s *Scheduler) approveJoinRequests(ctx context.Context) error {
+	pending, err := s.store.GetPendingJoinApprovals(ctx, joinApprovalBatchSize)
+	if err != nil {
+		return err
+	}
+
+	classrooms := make(map[int64]models.Classroom)
+	for _, approval := range pending {
+		classroom, ok := classrooms[approval.ClassroomID]
+		if !ok {
+			classroom, err = s.store.GetClassroomByID(ctx, approval.ClassroomID)
+			if err != nil {
+				return err
+			}
+			classrooms[approval.ClassroomID] = classroom
+		}
+
+		err := s.approveJoinRequest(ctx, classroom, approval)
+		if err != nil {
+			slog.Error("Failed to approve join request", "classroom", classroom.ID, "user", approval.GithubUsername, "err", err)
+		}
+		approval.Finish(err)
+
+		err = s.store.CompleteJob(ctx, approval.Job)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Invites the user for their role in the classroom. Users who left the requested state since the approval, e.g.
+// because they were denied or invited some other way, are left alone.
+func (s *Scheduler) approveJoinRequest(ctx context.Context, classroom models.Classroom, approval models.JoinApproval) error {
+	classroomUser, err := s.store.GetUserInClassroom(ctx, classroom.ID, approval.UserID)
+	if err != nil {
+		return err
+	}
+	if classroomUser.Status != models.UserStatusRequested {
+		return nil
+	}
+
+	appClient, err := s.appClients.ForOrg(ctx, classroom.OrgName)
+	if err != nil {
+		return err
+	}
+
+	_, err = provisioning.InviteToOrganization(ctx, s.store, appClient, classroom, classroomUser.Role, classroomUser.User)
+	return err
+}
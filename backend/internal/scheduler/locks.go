@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+)
+
+// Number of repositories locked per run, to stay well under GitHub's rate limits
+const workLockBatchSize = 50
+
+// Makes student repositories read-only once their due date has passed, tagging the commit they were locked at
+func (s *Scheduler) lockWorks(ctx context.Context) error {
+	works, err := s.store.GetWorksToLock(ctx, time.Now().UTC(), workLockBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, work := range works {
+		commitSHA, err := s.lockWork(ctx, work)
+		if err != nil {
+			slog.Error("Failed to lock student work", "repo", work.OrgName+"/"+work.RepoName, "err", err)
+			err = s.store.RecordWorkLockFailure(ctx, work.StudentWorkID, err.Error())
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = s.store.LockStudentWork(ctx, work.StudentWorkID, commitSHA)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Scheduler) lockWork(ctx context.Context, work models.LockableWork) (string, error) {
+	appClient, err := s.appClients.ForOrg(ctx, work.OrgName)
+	if err != nil {
+		return "", err
+	}
+
+	commitSHA, err := appClient.GetBranchHeadSHA(ctx, work.OrgName, work.RepoName, work.SubmissionBranch)
+	if err != nil {
+		return "", err
+	}
+
+	err = appClient.CreateTag(ctx, work.OrgName, work.RepoName, deadlineTagName(work.DueDate), commitSHA)
+	if err != nil {
+		return "", err
+	}
+
+	for _, contributor := range work.Contributors {
+		err = appClient.AssignPermissionToUser(ctx, work.OrgName, work.RepoName, contributor, "pull")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return commitSHA, nil
+}
+
+// Gives students push access again to locked repositories whose due date was moved into the future, e.g. by an
+// extension of the assignment or of the work itself. They are locked again once the new due date passes.
+func (s *Scheduler) reopenWorks(ctx context.Context) error {
+	works, err := s.store.GetWorksToReopen(ctx, time.Now().UTC(), workLockBatchSize)
+	if err != nil {
+		return err
+	}
+
+	// a failed reopen is retried on the next run
+	for _, work := range works {
+		err := s.reopenWork(ctx, work)
+		if err != nil {
+			slog.Error("Failed to reopen student work", "repo", work.OrgName+"/"+work.RepoName, "err", err)
+			continue
+		}
+
+		err = s.store.ReopenStudentWork(ctx, work.StudentWorkID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Scheduler) reopenWork(ctx context.Context, work models.LockableWork) error {
+	appClient, err := s.appClients.ForOrg(ctx, work.OrgName)
+	if err != nil {
+		return err
+	}
+
+	for _, contributor := range work.Contributors {
+		err = appClient.AssignPermissionToUser(ctx, work.OrgName, work.RepoName, contributor, "push")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Tags are named after the due date they mark, so a work locked again after an extension gets a new tag
+func deadlineTagName(dueDate time.Time) string {
+	return "deadline-" + dueDate.UTC().Format("20060102-1504")
+}
@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/CamPlume1/khoury-classroom/internal/github"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/provisioning"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// Number of repositories provisioned per run
+	provisionBatchSize = 20
+	// Number of repositories set up at the same time
+	provisionConcurrency = 4
+	// Rough number of GitHub requests it takes to set up one repository, used to leave headroom under the rate limit
+	requestsPerProvision = 15
+)
+
+// The assignment details shared by every provision of an assignment in a run
+type provisionTarget struct {
+	assignment models.AssignmentOutline
+	classroom  models.Classroom
+	baseRepo   models.AssignmentBaseRepo
+	appClient  github.GitHubAppClient
+}
+
+// Creates the repositories of students queued for provisioning on released assignments
+func (s *Scheduler) provisionRepos(ctx context.Context) error {
+	provisions, err := s.store.GetPendingRepoProvisions(ctx, provisionBatchSize)
+	if err != nil {
+		return err
+	}
+	if len(provisions) == 0 {
+		return nil
+	}
+
+	// Load each assignment once, along with the client of its org's installation
+	targets := make(map[int]*provisionTarget)
+	requests := make(map[github.GitHubAppClient]int)
+	for _, provision := range provisions {
+		target, ok := targets[provision.AssignmentOutlineID]
+		if !ok {
+			target, err = s.loadProvisionTarget(ctx, provision.AssignmentOutlineID)
+			if err != nil {
+				return err
+			}
+			targets[provision.AssignmentOutlineID] = target
+		}
+		requests[target.appClient] += requestsPerProvision
+	}
+
+	// Each installation has its own rate limit. Wait for it to reset rather than fail repositories halfway through
+	// their setup.
+	postponed := make(map[github.GitHubAppClient]bool)
+	for appClient, needed := range requests {
+		remaining, err := appClient.GetRemainingRateLimit(ctx)
+		if err != nil {
+			return err
+		}
+		if remaining < needed {
+			slog.Info("Postponing repository provisioning until the rate limit resets", "remaining", remaining)
+			postponed[appClient] = true
+		}
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, provisionConcurrency)
+	for _, provision := range provisions {
+		target := targets[provision.AssignmentOutlineID]
+		if postponed[target.appClient] {
+			continue
+		}
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(provision models.RepoProvision) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			err := s.provisionRepo(ctx, target, &provision)
+			if err != nil {
+				slog.Error("Failed to provision repository", "user", provision.GithubUsername, "err", err)
+			}
+			provision.Finish(err)
+
+			err = s.store.CompleteRepoProvision(ctx, provision)
+			if err != nil {
+				slog.Error("Failed to record repository provision", "id", provision.ID, "err", err)
+			}
+		}(provision)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func (s *Scheduler) loadProvisionTarget(ctx context.Context, assignmentID int) (*provisionTarget, error) {
+	assignment, err := s.store.GetAssignmentByID(ctx, int64(assignmentID))
+	if err != nil {
+		return nil, err
+	}
+	classroom, err := s.store.GetClassroomByID(ctx, assignment.ClassroomID)
+	if err != nil {
+		return nil, err
+	}
+	baseRepo, err := s.store.GetBaseRepoByID(ctx, assignment.BaseRepoID)
+	if err != nil {
+		return nil, err
+	}
+
+	appClient, err := s.appClients.ForOrg(ctx, classroom.OrgName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provisionTarget{assignment: assignment, classroom: classroom, baseRepo: baseRepo, appClient: appClient}, nil
+}
+
+// Sets up a student's repository the same way accepting the assignment does, then gives the student access
+func (s *Scheduler) provisionRepo(ctx context.Context, target *provisionTarget, provision *models.RepoProvision) error {
+	// Reuse the name of a repository the student accepted in the meantime, or one chosen by an earlier attempt
+	if provision.RepoName == nil {
+		existingWork, err := s.store.GetUserWorkByAssignment(ctx, int64(target.assignment.ID), provision.UserID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		if err == nil {
+			provision.RepoName = &existingWork.RepoName
+		}
+	}
+	if provision.RepoName == nil {
+		repoName, err := provisioning.WorkRepoName(ctx, s.store, target.appClient, target.classroom, target.assignment, target.baseRepo, provision.GithubUsername)
+		if err != nil {
+			return err
+		}
+		provision.RepoName = &repoName
+	}
+
+	_, _, _, err := provisioning.SetUpWorkRepo(ctx, s.store, target.appClient, target.classroom, target.assignment, target.baseRepo,
+		*provision.RepoName, provision.GithubUserID)
+	if err != nil {
+		return err
+	}
+
+	err = provisioning.GrantStaffAccess(ctx, s.store, target.appClient, target.classroom, *provision.RepoName, provisioning.StaffWorkRepoPermission)
+	if err != nil {
+		return err
+	}
+
+	// The fork belongs to the app, so the student is added to it directly
+	return target.appClient.AssignPermissionToUser(ctx, target.classroom.OrgName, *provision.RepoName, provision.GithubUsername, "push")
+}
@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+)
+
+// Gives the student team access to every assignment whose release time has passed. Students find a released
+// assignment in their assignment list; nothing notifies them, as there is no notification channel to send it through.
+func (s *Scheduler) releaseAssignments(ctx context.Context) error {
+	assignments, err := s.store.GetAssignmentsToRelease(ctx)
+	if err != nil {
+		return err
+	}
+
+	// a failed release is retried on the next run
+	for _, assignment := range assignments {
+		if err := s.releaseAssignment(ctx, assignment); err != nil {
+			slog.Error("Failed to release assignment", "assignment_id", assignment.ID, "err", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Scheduler) releaseAssignment(ctx context.Context, assignment models.AssignmentOutline) error {
+	classroom, err := s.store.GetClassroomByID(ctx, assignment.ClassroomID)
+	if err != nil {
+		return err
+	}
+
+	baseRepo, err := s.store.GetBaseRepoByID(ctx, assignment.BaseRepoID)
+	if err != nil {
+		return err
+	}
+
+	appClient, err := s.appClients.ForOrg(ctx, classroom.OrgName)
+	if err != nil {
+		return err
+	}
+
+	err = appClient.UpdateTeamRepoPermissions(ctx, classroom.OrgName, *classroom.StudentTeamName,
+		baseRepo.BaseRepoOwner, baseRepo.BaseRepoName, "pull")
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Released assignment", "assignment_id", assignment.ID, "classroom_id", classroom.ID)
+	return s.store.MarkAssignmentReleased(ctx, int64(assignment.ID))
+}
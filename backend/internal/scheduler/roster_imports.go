@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/CamPlume1/khoury-classroom/internal/provisioning"
+)
+
+// Number of roster rows imported per run, each making several GitHub calls
+const rosterImportBatchSize = 50
+
+// Imports the rows queued by roster imports, adding each user to their classroom and inviting them to the org
+func (s *Scheduler) importRosters(ctx context.Context) error {
+	pending, err := s.store.GetPendingRosterImportRows(ctx, rosterImportBatchSize)
+	if err != nil {
+		return err
+	}
+
+	imports := make(map[int]models.RosterImport)
+	classrooms := make(map[int64]models.Classroom)
+	for _, row := range pending {
+		rosterImport, ok := imports[row.ImportID]
+		if !ok {
+			rosterImport, err = s.store.GetRosterImportByID(ctx, row.ImportID)
+			if err != nil {
+				return err
+			}
+			imports[row.ImportID] = rosterImport
+		}
+		classroom, ok := classrooms[rosterImport.ClassroomID]
+		if !ok {
+			classroom, err = s.store.GetClassroomByID(ctx, rosterImport.ClassroomID)
+			if err != nil {
+				return err
+			}
+			classrooms[rosterImport.ClassroomID] = classroom
+		}
+
+		err := s.importRosterRow(ctx, classroom, rosterImport, &row)
+		if err != nil {
+			slog.Error("Failed to import roster row", "classroom", classroom.ID, "row", row.Entry.Row, "err", err)
+		}
+		row.Finish(err)
+
+		err = s.store.CompleteRosterImportRow(ctx, row)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Imports a roster row, saving its outcome on the row. Rows queued with an outcome, e.g. duplicates, are left as is.
+func (s *Scheduler) importRosterRow(ctx context.Context, classroom models.Classroom, rosterImport models.RosterImport, row *models.RosterImportRow) error {
+	if row.Result != nil && row.Result.Status == models.RosterImportSkipped {
+		return nil
+	}
+
+	appClient, err := s.appClients.ForOrg(ctx, classroom.OrgName)
+	if err != nil {
+		return err
+	}
+
+	result := provisioning.ImportRosterEntry(ctx, s.store, appClient, classroom, row.Entry, rosterImport.DryRun)
+	row.Result = &result
+	if result.Status == models.RosterImportFailed {
+		return errors.New(*result.Error)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/CamPlume1/khoury-classroom/internal/github"
+	"github.com/CamPlume1/khoury-classroom/internal/storage"
+)
+
+// Runs recurring background jobs against the database and GitHub
+type Scheduler struct {
+	store      storage.Storage
+	appClients github.GitHubAppInstallations
+	interval   time.Duration
+}
+
+type job struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+func New(store storage.Storage, appClients github.GitHubAppInstallations) *Scheduler {
+	return &Scheduler{
+		store:      store,
+		appClients: appClients,
+		interval:   time.Minute,
+	}
+}
+
+// Runs every job once per interval until the context is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.runJobs(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) jobs() []job {
+	return []job{
+		{name: "release assignments", run: s.releaseAssignments},
+		{name: "propagate deadlines", run: s.propagateDeadlines},
+		{name: "sync starter code", run: s.syncStarterCode},
+		{name: "provision repositories", run: s.provisionRepos},
+		{name: "reopen works", run: s.reopenWorks},
+		{name: "lock works", run: s.lockWorks},
+		{name: "clone assignments", run: s.cloneAssignments},
+		{name: "approve join requests", run: s.approveJoinRequests},
+		{name: "import rosters", run: s.importRosters},
+	}
+}
+
+// Runs each job that no other server is running. Servers share the database, so a job's lock is held for the
+// whole run to stop two servers picking up the same work.
+func (s *Scheduler) runJobs(ctx context.Context) {
+	for _, job := range s.jobs() {
+		unlock, locked, err := s.store.TryLockJob(ctx, job.name)
+		if err != nil {
+			slog.Error("Failed to lock scheduled job", "job", job.name, "err", err)
+			continue
+		}
+		if !locked {
+			continue
+		}
+
+		if err := job.run(ctx); err != nil {
+			slog.Error("Scheduled job failed", "job", job.name, "err", err)
+		}
+		unlock()
+	}
+}
@@ -0,0 +1,154 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/CamPlume1/khoury-classroom/internal/github"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+)
+
+// Number of starter code pull requests opened per run, to stay well under GitHub's rate limits
+const starterCodeBatchSize = 20
+
+// The file changes of a starter code update, loaded once per run and shared by every fork
+type starterCodeUpdate struct {
+	sync models.StarterCodeSync
+	// the client of the installation on the classroom's org
+	appClient github.GitHubAppClient
+	orgName   string
+	// the submission branch of the assignment's repositories, which the pull requests target
+	branch  string
+	changes []models.FileChange
+}
+
+// Opens a pull request with the starter code changes in every fork still waiting on one
+func (s *Scheduler) syncStarterCode(ctx context.Context) error {
+	pullRequests, err := s.store.GetPendingStarterCodeSyncPRs(ctx, starterCodeBatchSize)
+	if err != nil {
+		return err
+	}
+
+	updates := make(map[int]*starterCodeUpdate)
+	for _, pullRequest := range pullRequests {
+		var err error
+		update, ok := updates[pullRequest.SyncID]
+		if !ok {
+			update, err = s.loadStarterCodeUpdate(ctx, pullRequest.SyncID)
+			if err == nil {
+				updates[pullRequest.SyncID] = update
+			}
+		}
+
+		if err == nil {
+			err = s.openStarterCodePR(ctx, update, &pullRequest)
+		}
+		if err != nil {
+			slog.Error("Failed to open starter code pull request", "repo", pullRequest.RepoName, "err", err)
+		}
+		pullRequest.Finish(err)
+
+		err = s.store.CompleteStarterCodeSyncPR(ctx, pullRequest)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Gets the files changed in the base repository between the update's two commits
+func (s *Scheduler) loadStarterCodeUpdate(ctx context.Context, syncID int) (*starterCodeUpdate, error) {
+	sync, err := s.store.GetStarterCodeSyncByID(ctx, syncID)
+	if err != nil {
+		return nil, err
+	}
+
+	assignment, err := s.store.GetAssignmentByID(ctx, int64(sync.AssignmentOutlineID))
+	if err != nil {
+		return nil, err
+	}
+	classroom, err := s.store.GetClassroomByID(ctx, assignment.ClassroomID)
+	if err != nil {
+		return nil, err
+	}
+	baseRepo, err := s.store.GetBaseRepoByID(ctx, assignment.BaseRepoID)
+	if err != nil {
+		return nil, err
+	}
+
+	appClient, err := s.appClients.ForOrg(ctx, classroom.OrgName)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison, err := appClient.CompareCommits(ctx, baseRepo.BaseRepoOwner, baseRepo.BaseRepoName, sync.BaseCommitSHA, sync.HeadCommitSHA)
+	if err != nil {
+		return nil, err
+	}
+	changedFiles := comparison.Files
+	modes, err := appClient.GetFileModes(ctx, baseRepo.BaseRepoOwner, baseRepo.BaseRepoName, sync.HeadCommitSHA)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []models.FileChange{}
+	for _, file := range changedFiles {
+		// renames remove the file under its old name
+		if file.PreviousFilename != nil {
+			changes = append(changes, models.FileChange{Path: *file.PreviousFilename, Deleted: true})
+		}
+		if file.Status == "removed" {
+			changes = append(changes, models.FileChange{Path: file.Filename, Deleted: true})
+			continue
+		}
+		if file.SHA == nil {
+			return nil, fmt.Errorf("missing blob for %s", file.Filename)
+		}
+
+		content, err := appClient.GetFileBlob(baseRepo.BaseRepoOwner, baseRepo.BaseRepoName, *file.SHA)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, models.FileChange{Path: file.Filename, Content: content, Mode: modes[file.Filename]})
+	}
+
+	return &starterCodeUpdate{sync: sync, appClient: appClient, orgName: classroom.OrgName, branch: assignment.RepoLayout.SubmissionBranch, changes: changes}, nil
+}
+
+// Commits the starter code changes to a branch in the fork and opens a pull request into its submission branch.
+// The branch starts from the starter code the changes were made to, so merging it brings in only the changes and
+// leaves the students' own edits in place, with any conflicts shown on the pull request. A branch or pull request
+// left by an earlier attempt is reused.
+func (s *Scheduler) openStarterCodePR(ctx context.Context, update *starterCodeUpdate, pullRequest *models.StarterCodeSyncPR) error {
+	err := update.appClient.CommitFileChanges(ctx, update.orgName, pullRequest.RepoName, update.sync.BaseCommitSHA,
+		update.sync.BranchName(), update.sync.Title, update.changes)
+	if err != nil {
+		return err
+	}
+
+	pr, err := update.appClient.GetPullRequestByHead(ctx, update.orgName, pullRequest.RepoName, update.sync.BranchName())
+	if err != nil {
+		return err
+	}
+	if pr == nil {
+		pr, err = update.appClient.CreatePullRequest(ctx, update.orgName, pullRequest.RepoName, update.branch,
+			update.sync.BranchName(), update.sync.Title,
+			"Your course staff updated the starter code for this assignment. Review the changes and merge this pull request to bring them into your work.")
+		if err != nil {
+			return err
+		}
+	}
+
+	state := models.StarterCodePROpen
+	if pr.MergedAt != nil {
+		state = models.StarterCodePRMerged
+	} else if pr.GetState() == "closed" {
+		state = models.StarterCodePRClosed
+	}
+	pullRequest.PRNumber = pr.Number
+	pullRequest.PRURL = pr.HTMLURL
+	pullRequest.PRState = &state
+	return nil
+}
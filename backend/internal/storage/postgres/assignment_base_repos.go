@@ -11,6 +11,7 @@ func (db *DB) CreateBaseRepo(ctx context.Context, baseRepo models.AssignmentBase
 	_, err := db.connPool.Exec(ctx, `
 			INSERT INTO assignment_base_repos (base_repo_owner, base_repo_name, base_repo_id)
 			VALUES ($1, $2, $3)
+			ON CONFLICT (base_repo_id) DO NOTHING
 		`,
 		baseRepo.BaseRepoOwner,
 		baseRepo.BaseRepoName,
@@ -42,3 +43,13 @@ func (db *DB) GetBaseRepoByID(ctx context.Context, id int64) (models.AssignmentB
 
 	return baseRepo, nil
 }
+
+// Records that a base repository's branches, workflows and rulesets are set up, so its assignment can be released
+func (db *DB) MarkBaseRepoInitialized(ctx context.Context, id int64) error {
+	_, err := db.connPool.Exec(ctx, `UPDATE assignment_base_repos SET initialized = TRUE WHERE base_repo_id = $1`, id)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
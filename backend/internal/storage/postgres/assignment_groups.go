@@ -0,0 +1,240 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const assignmentGroupFields = `ag.id, ag.assignment_outline_id, ag.name, ag.join_code, ag.student_work_id, ag.created_at,
+	COALESCE((SELECT sw.repo_name FROM student_works sw WHERE sw.id = ag.student_work_id), ag.repo_name) AS repo_name`
+
+func scanAssignmentGroup(row pgx.Row) (models.AssignmentGroup, error) {
+	var group models.AssignmentGroup
+	err := row.Scan(
+		&group.ID,
+		&group.AssignmentOutlineID,
+		&group.Name,
+		&group.JoinCode,
+		&group.StudentWorkID,
+		&group.CreatedAt,
+		&group.RepoName,
+	)
+
+	return group, err
+}
+
+// Creates a group along with its first members, either all of them are saved or none are
+func (db *DB) CreateAssignmentGroup(ctx context.Context, groupData models.AssignmentGroup, memberIDs []int64) (models.AssignmentGroup, error) {
+	var group models.AssignmentGroup
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		var err error
+		group, err = scanAssignmentGroup(tx.QueryRow(ctx, `
+		INSERT INTO assignment_groups AS ag (assignment_outline_id, name, join_code)
+		VALUES ($1, $2, $3)
+		RETURNING `+assignmentGroupFields,
+			groupData.AssignmentOutlineID,
+			groupData.Name,
+			groupData.JoinCode,
+		))
+		if err != nil {
+			return err
+		}
+
+		for _, memberID := range memberIDs {
+			err = insertAssignmentGroupMember(ctx, tx, group, memberID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return models.AssignmentGroup{}, errs.NewDBError(err)
+	}
+
+	return group, nil
+}
+
+func (db *DB) GetAssignmentGroupByID(ctx context.Context, groupID int64) (models.AssignmentGroup, error) {
+	group, err := scanAssignmentGroup(db.connPool.QueryRow(ctx,
+		`SELECT `+assignmentGroupFields+` FROM assignment_groups ag WHERE ag.id = $1`, groupID))
+	if err != nil {
+		return models.AssignmentGroup{}, errs.NewDBError(err)
+	}
+
+	return group, nil
+}
+
+func (db *DB) GetAssignmentGroupByJoinCode(ctx context.Context, assignmentID int64, joinCode string) (models.AssignmentGroup, error) {
+	group, err := scanAssignmentGroup(db.connPool.QueryRow(ctx,
+		`SELECT `+assignmentGroupFields+` FROM assignment_groups ag WHERE ag.assignment_outline_id = $1 AND ag.join_code = $2`,
+		assignmentID, joinCode))
+	if err != nil {
+		return models.AssignmentGroup{}, errs.NewDBError(err)
+	}
+
+	return group, nil
+}
+
+// Gets the group a user belongs to on an assignment (pgx.ErrNoRows if they are not in one)
+func (db *DB) GetUserAssignmentGroup(ctx context.Context, assignmentID int64, userID int64) (models.AssignmentGroup, error) {
+	group, err := scanAssignmentGroup(db.connPool.QueryRow(ctx, `
+	SELECT `+assignmentGroupFields+`
+	FROM assignment_groups ag
+	JOIN assignment_group_members agm ON agm.group_id = ag.id
+	WHERE agm.assignment_outline_id = $1 AND agm.user_id = $2`,
+		assignmentID, userID))
+	if err != nil {
+		return models.AssignmentGroup{}, err
+	}
+
+	return group, nil
+}
+
+func (db *DB) GetAssignmentGroups(ctx context.Context, assignmentID int64) ([]models.AssignmentGroupWithMembers, error) {
+	rows, err := db.connPool.Query(ctx,
+		`SELECT `+assignmentGroupFields+` FROM assignment_groups ag WHERE ag.assignment_outline_id = $1 ORDER BY ag.name`,
+		assignmentID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	groups, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.AssignmentGroup, error) {
+		return scanAssignmentGroup(row)
+	})
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	groupsWithMembers := []models.AssignmentGroupWithMembers{}
+	for _, group := range groups {
+		members, err := db.GetAssignmentGroupMembers(ctx, int64(group.ID))
+		if err != nil {
+			return nil, err
+		}
+		groupsWithMembers = append(groupsWithMembers, models.AssignmentGroupWithMembers{AssignmentGroup: group, Members: members})
+	}
+
+	return groupsWithMembers, nil
+}
+
+func (db *DB) GetAssignmentGroupMembers(ctx context.Context, groupID int64) ([]models.User, error) {
+	rows, err := db.connPool.Query(ctx, `
+	SELECT u.id, u.first_name, u.last_name, u.github_username, u.github_user_id
+	FROM users u
+	JOIN assignment_group_members agm ON agm.user_id = u.id
+	WHERE agm.group_id = $1
+	ORDER BY u.last_name, u.first_name`, groupID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+	defer rows.Close()
+
+	members := []models.User{}
+	for rows.Next() {
+		var member models.User
+		err := rows.Scan(&member.ID, &member.FirstName, &member.LastName, &member.GithubUsername, &member.GithubUserID)
+		if err != nil {
+			return nil, errs.NewDBError(err)
+		}
+		members = append(members, member)
+	}
+
+	if rows.Err() != nil {
+		return nil, errs.NewDBError(rows.Err())
+	}
+
+	return members, nil
+}
+
+func insertAssignmentGroupMember(ctx context.Context, q querier, group models.AssignmentGroup, userID int64) error {
+	_, err := q.Exec(ctx,
+		`INSERT INTO assignment_group_members (group_id, assignment_outline_id, user_id) VALUES ($1, $2, $3)`,
+		group.ID, group.AssignmentOutlineID, userID)
+	return err
+}
+
+// Adds a member to a group, failing with a constraint violation if the group already has the maximum number of
+// members. The group is locked while its members are counted so concurrent joins can't overfill it.
+func (db *DB) AddAssignmentGroupMember(ctx context.Context, group models.AssignmentGroup, userID int64, maxGroupSize *int) error {
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		var memberCount int
+		err := tx.QueryRow(ctx, `
+			SELECT (SELECT COUNT(*) FROM assignment_group_members agm WHERE agm.group_id = ag.id)
+			FROM assignment_groups ag
+			WHERE ag.id = $1
+			FOR UPDATE`, group.ID).Scan(&memberCount)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.EmptyResult()
+		}
+		if err != nil {
+			return err
+		}
+		if maxGroupSize != nil && memberCount >= *maxGroupSize {
+			return errs.ConstraintViolation()
+		}
+
+		return insertAssignmentGroupMember(ctx, tx, group, userID)
+	})
+	if err == errs.EmptyResult() || err == errs.ConstraintViolation() {
+		return err
+	}
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+func (db *DB) RemoveAssignmentGroupMember(ctx context.Context, groupID int64, userID int64) error {
+	result, err := db.connPool.Exec(ctx,
+		`DELETE FROM assignment_group_members WHERE group_id = $1 AND user_id = $2`,
+		groupID, userID)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+	if result.RowsAffected() == 0 {
+		return errs.EmptyResult()
+	}
+
+	return nil
+}
+
+// Names the repository a group's members will share, unless a member already named it. Returns the group's name
+// for it either way.
+func (db *DB) ClaimAssignmentGroupRepoName(ctx context.Context, groupID int64, repoName string) (string, error) {
+	var claimed string
+	err := db.connPool.QueryRow(ctx,
+		`UPDATE assignment_groups SET repo_name = COALESCE(repo_name, $1) WHERE id = $2 RETURNING repo_name`,
+		repoName, groupID).Scan(&claimed)
+	if err != nil {
+		return "", errs.NewDBError(err)
+	}
+
+	return claimed, nil
+}
+
+// Links a group to the repository its members share
+func (db *DB) SetAssignmentGroupWork(ctx context.Context, groupID int64, studentWorkID int) error {
+	_, err := db.connPool.Exec(ctx,
+		`UPDATE assignment_groups SET student_work_id = $1 WHERE id = $2`,
+		studentWorkID, groupID)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+func (db *DB) DeleteAssignmentGroup(ctx context.Context, groupID int64) error {
+	_, err := db.connPool.Exec(ctx, `DELETE FROM assignment_groups WHERE id = $1`, groupID)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
@@ -11,20 +11,104 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
-func (db *DB) CreateAssignmentToken(ctx context.Context, tokenData models.AssignmentToken) (models.AssignmentToken, error) {
-	err := db.connPool.QueryRow(ctx, `
-	INSERT INTO assignment_outline_tokens (assignment_outline_id, token, expires_at)
-	VALUES ($1, $2, $3)
-	RETURNING assignment_outline_id, token, created_at, expires_at`,
-		tokenData.AssignmentID,
-		tokenData.Token,
-		tokenData.ExpiresAt,
-	).Scan(
+const AssignmentOutlineFields = `
+	ao.id,
+	ao.template_id,
+	ao.base_repo_id,
+	ao.created_at,
+	ao.released_at,
+	ao.name,
+	ao.classroom_id,
+	ao.rubric_id,
+	ao.group_assignment,
+	ao.main_due_date,
+	ao.default_score,
+	ao.max_group_size,
+	ao.self_formed_groups,
+	ao.released,
+	ao.archived_at,
+	ao.submission_branch,
+	ao.feedback_branch,
+	ao.working_branches,
+	ao.protected_paths,
+	ao.deadline_workflow
+`
+
+// scans a row selected with AssignmentOutlineFields
+func scanAssignmentOutline(row pgx.Row) (models.AssignmentOutline, error) {
+	var assignmentOutline models.AssignmentOutline
+	err := row.Scan(
+		&assignmentOutline.ID,
+		&assignmentOutline.TemplateID,
+		&assignmentOutline.BaseRepoID,
+		&assignmentOutline.CreatedAt,
+		&assignmentOutline.ReleasedAt,
+		&assignmentOutline.Name,
+		&assignmentOutline.ClassroomID,
+		&assignmentOutline.RubricID,
+		&assignmentOutline.GroupAssignment,
+		&assignmentOutline.MainDueDate,
+		&assignmentOutline.DefaultScore,
+		&assignmentOutline.MaxGroupSize,
+		&assignmentOutline.SelfFormedGroups,
+		&assignmentOutline.Released,
+		&assignmentOutline.ArchivedAt,
+		&assignmentOutline.RepoLayout.SubmissionBranch,
+		&assignmentOutline.RepoLayout.FeedbackBranch,
+		&assignmentOutline.RepoLayout.WorkingBranches,
+		&assignmentOutline.RepoLayout.ProtectedPaths,
+		&assignmentOutline.DeadlineWorkflow,
+	)
+
+	return assignmentOutline, err
+}
+
+const assignmentTokenFields = `assignment_outline_id, token, created_at, expires_at, max_uses, use_count, revoked, allowed_usernames, allowed_email_domains, created_by`
+
+func scanAssignmentToken(row pgx.Row) (models.AssignmentToken, error) {
+	var tokenData models.AssignmentToken
+	err := row.Scan(
 		&tokenData.AssignmentID,
 		&tokenData.Token,
 		&tokenData.CreatedAt,
 		&tokenData.ExpiresAt,
+		&tokenData.MaxUses,
+		&tokenData.UseCount,
+		&tokenData.Revoked,
+		&tokenData.AllowedUsernames,
+		&tokenData.AllowedEmailDomains,
+		&tokenData.CreatedBy,
 	)
+	return tokenData, err
+}
+
+func (db *DB) CreateAssignmentToken(ctx context.Context, tokenData models.AssignmentToken) (models.AssignmentToken, error) {
+	tokenData, err := scanAssignmentToken(db.connPool.QueryRow(ctx, fmt.Sprintf(`
+	INSERT INTO assignment_outline_tokens (assignment_outline_id, token, expires_at, max_uses, allowed_usernames, allowed_email_domains, created_by)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING %s`, assignmentTokenFields),
+		tokenData.AssignmentID,
+		tokenData.Token,
+		tokenData.ExpiresAt,
+		tokenData.MaxUses,
+		tokenData.AllowedUsernames,
+		tokenData.AllowedEmailDomains,
+		tokenData.CreatedBy,
+	))
+
+	if err != nil {
+		return models.AssignmentToken{}, errs.NewDBError(err)
+	}
+
+	return tokenData, nil
+}
+
+func (db *DB) GetAssignmentToken(ctx context.Context, token string) (models.AssignmentToken, error) {
+	tokenData, err := scanAssignmentToken(db.connPool.QueryRow(ctx, fmt.Sprintf(`
+		SELECT %s
+		FROM assignment_outline_tokens
+		WHERE token = $1
+	`, assignmentTokenFields), token))
 
 	if err != nil {
 		return models.AssignmentToken{}, errs.NewDBError(err)
@@ -34,25 +118,12 @@ func (db *DB) CreateAssignmentToken(ctx context.Context, tokenData models.Assign
 }
 
 func (db *DB) GetAssignmentByToken(ctx context.Context, token string) (models.AssignmentOutline, error) {
-	var assignmentOutline models.AssignmentOutline
-	err := db.connPool.QueryRow(ctx, `
-	SELECT ao.*
+	assignmentOutline, err := scanAssignmentOutline(db.connPool.QueryRow(ctx, fmt.Sprintf(`
+	SELECT %s
 	FROM assignment_outlines ao
 	JOIN assignment_outline_tokens aot
 		ON ao.id = aot.assignment_outline_id
-	WHERE aot.token = $1`, token).Scan(
-		&assignmentOutline.ID,
-		&assignmentOutline.TemplateID,
-		&assignmentOutline.BaseRepoID,
-		&assignmentOutline.CreatedAt,
-		&assignmentOutline.ReleasedAt,
-		&assignmentOutline.Name,
-		&assignmentOutline.ClassroomID,
-		&assignmentOutline.RubricID,
-		&assignmentOutline.GroupAssignment,
-		&assignmentOutline.MainDueDate,
-		&assignmentOutline.DefaultScore,
-	)
+	WHERE aot.token = $1`, AssignmentOutlineFields), token))
 	if err != nil {
 		return models.AssignmentOutline{}, errs.NewDBError(err)
 	}
@@ -61,17 +132,11 @@ func (db *DB) GetAssignmentByToken(ctx context.Context, token string) (models.As
 }
 
 func (db *DB) GetPermanentAssignmentTokenByAssignmentID(ctx context.Context, assignmentID int64) (models.AssignmentToken, error) {
-	var tokenData models.AssignmentToken
-	err := db.connPool.QueryRow(ctx, `
-		SELECT assignment_outline_id, token, created_at, expires_at
+	tokenData, err := scanAssignmentToken(db.connPool.QueryRow(ctx, fmt.Sprintf(`
+		SELECT %s
 		FROM assignment_outline_tokens
-		WHERE assignment_outline_id = $1 AND expires_at IS NULL
-	`, assignmentID).Scan(
-		&tokenData.AssignmentID,
-		&tokenData.Token,
-		&tokenData.CreatedAt,
-		&tokenData.ExpiresAt,
-	)
+		WHERE assignment_outline_id = $1 AND %s
+	`, assignmentTokenFields, permanentTokenCondition), assignmentID))
 
 	if err != nil {
 		return models.AssignmentToken{}, errs.NewDBError(err)
@@ -80,30 +145,75 @@ func (db *DB) GetPermanentAssignmentTokenByAssignmentID(ctx context.Context, ass
 	return tokenData, nil
 }
 
+// Gets the assignment's tokens that can still be used, newest first
+func (db *DB) GetActiveAssignmentTokens(ctx context.Context, assignmentID int64) ([]models.AssignmentToken, error) {
+	rows, err := db.connPool.Query(ctx, fmt.Sprintf(`
+		SELECT %s
+		FROM assignment_outline_tokens
+		WHERE assignment_outline_id = $1 AND %s
+		ORDER BY created_at DESC`, assignmentTokenFields, activeTokenCondition), assignmentID, time.Now())
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+	defer rows.Close()
+
+	tokens := []models.AssignmentToken{}
+	for rows.Next() {
+		tokenData, err := scanAssignmentToken(rows)
+		if err != nil {
+			return nil, errs.NewDBError(err)
+		}
+		tokens = append(tokens, tokenData)
+	}
+	if rows.Err() != nil {
+		return nil, errs.NewDBError(rows.Err())
+	}
+
+	return tokens, nil
+}
+
+// Revokes one of the assignment's tokens. Returns pgx.ErrNoRows if the assignment has no such token.
+func (db *DB) RevokeAssignmentToken(ctx context.Context, assignmentID int64, token string) (models.AssignmentToken, error) {
+	return scanAssignmentToken(db.connPool.QueryRow(ctx, fmt.Sprintf(`
+		UPDATE assignment_outline_tokens SET revoked = TRUE
+		WHERE assignment_outline_id = $1 AND token = $2
+		RETURNING %s`, assignmentTokenFields), assignmentID, token))
+}
+
+// Records a user's use of an assignment token, failing if the token can no longer be used
+func (db *DB) RedeemAssignmentToken(ctx context.Context, token string, userID int64) (models.AssignmentToken, error) {
+	var tokenData models.AssignmentToken
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		var err error
+		tokenData, err = scanAssignmentToken(tx.QueryRow(ctx, fmt.Sprintf(`
+			SELECT %s FROM assignment_outline_tokens WHERE token = $1 FOR UPDATE`, assignmentTokenFields), token))
+		if err != nil {
+			return errs.NewDBError(err)
+		}
+
+		return redeemToken(ctx, tx, "assignment_outline_tokens", "assignment_token_redemptions", &tokenData.BaseToken, userID)
+	})
+	if err != nil {
+		return models.AssignmentToken{}, err
+	}
+
+	return tokenData, nil
+}
+
 func (db *DB) GetAssignmentsInClassroom(ctx context.Context, classroomID int64) ([]models.AssignmentOutline, error) {
-	rows, err := db.connPool.Query(ctx, "SELECT * FROM assignment_outlines WHERE classroom_id = $1", classroomID)
+	rows, err := db.connPool.Query(ctx, fmt.Sprintf("SELECT %s FROM assignment_outlines ao WHERE ao.classroom_id = $1", AssignmentOutlineFields), classroomID)
 	if err != nil {
 		return nil, errs.NewDBError(err)
 	}
 
-	return pgx.CollectRows(rows, pgx.RowToStructByName[models.AssignmentOutline])
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.AssignmentOutline, error) {
+		return scanAssignmentOutline(row)
+	})
 }
 
 func (db *DB) GetAssignmentByID(ctx context.Context, assignmentID int64) (models.AssignmentOutline, error) {
-	var assignmentOutline models.AssignmentOutline
-	err := db.connPool.QueryRow(ctx, "SELECT * FROM assignment_outlines WHERE id = $1", assignmentID).Scan(
-		&assignmentOutline.ID,
-		&assignmentOutline.TemplateID,
-		&assignmentOutline.BaseRepoID,
-		&assignmentOutline.CreatedAt,
-		&assignmentOutline.ReleasedAt,
-		&assignmentOutline.Name,
-		&assignmentOutline.ClassroomID,
-		&assignmentOutline.RubricID,
-		&assignmentOutline.GroupAssignment,
-		&assignmentOutline.MainDueDate,
-		&assignmentOutline.DefaultScore,
-	)
+	assignmentOutline, err := scanAssignmentOutline(db.connPool.QueryRow(ctx,
+		fmt.Sprintf("SELECT %s FROM assignment_outlines ao WHERE ao.id = $1", AssignmentOutlineFields), assignmentID))
 	if err != nil {
 		return models.AssignmentOutline{}, errs.NewDBError(err)
 	}
@@ -112,23 +222,12 @@ func (db *DB) GetAssignmentByID(ctx context.Context, assignmentID int64) (models
 }
 
 func (db *DB) CreateAssignment(ctx context.Context, assignmentRequestData models.AssignmentOutline) (models.AssignmentOutline, error) {
-	var assignmentOutline models.AssignmentOutline
-
-	err := db.connPool.QueryRow(ctx, `
-		INSERT INTO assignment_outlines (template_id, base_repo_id, name, classroom_id, rubric_id, group_assignment, main_due_date, default_score)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id,
-			template_id,
-			base_repo_id,
-			created_at,
-			released_at,
-			name,
-			classroom_id,
-			rubric_id,
-			group_assignment,
-			main_due_date,
-			default_score
-	`,
+	assignmentOutline, err := scanAssignmentOutline(db.connPool.QueryRow(ctx, fmt.Sprintf(`
+		INSERT INTO assignment_outlines AS ao (template_id, base_repo_id, name, classroom_id, rubric_id, group_assignment, main_due_date, default_score, max_group_size, self_formed_groups, released_at,
+			submission_branch, feedback_branch, working_branches, protected_paths, deadline_workflow)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		RETURNING %s
+	`, AssignmentOutlineFields),
 		assignmentRequestData.TemplateID,
 		assignmentRequestData.BaseRepoID,
 		assignmentRequestData.Name,
@@ -137,18 +236,15 @@ func (db *DB) CreateAssignment(ctx context.Context, assignmentRequestData models
 		assignmentRequestData.GroupAssignment,
 		assignmentRequestData.MainDueDate,
 		assignmentRequestData.DefaultScore,
-	).Scan(&assignmentOutline.ID,
-		&assignmentOutline.TemplateID,
-		&assignmentOutline.BaseRepoID,
-		&assignmentOutline.CreatedAt,
-		&assignmentOutline.ReleasedAt,
-		&assignmentOutline.Name,
-		&assignmentOutline.ClassroomID,
-		&assignmentOutline.RubricID,
-		&assignmentOutline.GroupAssignment,
-		&assignmentOutline.MainDueDate,
-		&assignmentOutline.DefaultScore,
-	)
+		assignmentRequestData.MaxGroupSize,
+		assignmentRequestData.SelfFormedGroups,
+		assignmentRequestData.ReleasedAt,
+		assignmentRequestData.RepoLayout.SubmissionBranch,
+		assignmentRequestData.RepoLayout.FeedbackBranch,
+		assignmentRequestData.RepoLayout.WorkingBranches,
+		assignmentRequestData.RepoLayout.ProtectedPaths,
+		assignmentRequestData.DeadlineWorkflow,
+	))
 
 	if err != nil {
 		return assignmentOutline, errs.NewDBError(err)
@@ -157,20 +253,8 @@ func (db *DB) CreateAssignment(ctx context.Context, assignmentRequestData models
 }
 
 func (db *DB) GetAssignmentByBaseRepoID(ctx context.Context, baseRepoID int64) (models.AssignmentOutline, error) {
-	var assignmentOutline models.AssignmentOutline
-	err := db.connPool.QueryRow(ctx, "SELECT * FROM assignment_outlines WHERE base_repo_id = $1", baseRepoID).Scan(
-		&assignmentOutline.ID,
-		&assignmentOutline.TemplateID,
-		&assignmentOutline.BaseRepoID,
-		&assignmentOutline.CreatedAt,
-		&assignmentOutline.ReleasedAt,
-		&assignmentOutline.Name,
-		&assignmentOutline.ClassroomID,
-		&assignmentOutline.RubricID,
-		&assignmentOutline.GroupAssignment,
-		&assignmentOutline.MainDueDate,
-		&assignmentOutline.DefaultScore,
-	)
+	assignmentOutline, err := scanAssignmentOutline(db.connPool.QueryRow(ctx,
+		fmt.Sprintf("SELECT %s FROM assignment_outlines ao WHERE ao.base_repo_id = $1", AssignmentOutlineFields), baseRepoID))
 
 	if err != nil {
 		return assignmentOutline, errs.NewDBError(err)
@@ -180,20 +264,8 @@ func (db *DB) GetAssignmentByBaseRepoID(ctx context.Context, baseRepoID int64) (
 }
 
 func (db *DB) GetAssignmentByNameAndClassroomID(ctx context.Context, assignmentName string, classroom int64) (*models.AssignmentOutline, error) {
-	var assignmentOutline models.AssignmentOutline
-	err := db.connPool.QueryRow(ctx, "SELECT * FROM assignment_outlines WHERE name = $1 AND classroom_id = $2", assignmentName, classroom).Scan(
-		&assignmentOutline.ID,
-		&assignmentOutline.TemplateID,
-		&assignmentOutline.BaseRepoID,
-		&assignmentOutline.CreatedAt,
-		&assignmentOutline.ReleasedAt,
-		&assignmentOutline.Name,
-		&assignmentOutline.ClassroomID,
-		&assignmentOutline.RubricID,
-		&assignmentOutline.GroupAssignment,
-		&assignmentOutline.MainDueDate,
-		&assignmentOutline.DefaultScore,
-	)
+	assignmentOutline, err := scanAssignmentOutline(db.connPool.QueryRow(ctx,
+		fmt.Sprintf("SELECT %s FROM assignment_outlines ao WHERE ao.name = $1 AND ao.classroom_id = $2", AssignmentOutlineFields), assignmentName, classroom))
 
 	if err != nil {
 		return nil, err
@@ -203,21 +275,9 @@ func (db *DB) GetAssignmentByNameAndClassroomID(ctx context.Context, assignmentN
 }
 
 func (db *DB) UpdateAssignmentRubric(ctx context.Context, rubricID int64, assignmentID int64) (models.AssignmentOutline, error) {
-	var updatedAssignmentData models.AssignmentOutline
-	err := db.connPool.QueryRow(ctx, `UPDATE assignment_outlines SET rubric_id = $1 WHERE id = $2 
-        RETURNING id, template_id, created_at, released_at, name, classroom_id, rubric_id, group_assignment, main_due_date, default_score`,
-		rubricID, assignmentID).Scan(
-		&updatedAssignmentData.ID,
-		&updatedAssignmentData.TemplateID,
-		&updatedAssignmentData.CreatedAt,
-		&updatedAssignmentData.ReleasedAt,
-		&updatedAssignmentData.Name,
-		&updatedAssignmentData.ClassroomID,
-		&updatedAssignmentData.RubricID,
-		&updatedAssignmentData.GroupAssignment,
-		&updatedAssignmentData.MainDueDate,
-		&updatedAssignmentData.DefaultScore,
-	)
+	updatedAssignmentData, err := scanAssignmentOutline(db.connPool.QueryRow(ctx, fmt.Sprintf(`UPDATE assignment_outlines AS ao SET rubric_id = $1 WHERE ao.id = $2
+        RETURNING %s`, AssignmentOutlineFields),
+		rubricID, assignmentID))
 
 	if err != nil {
 		return models.AssignmentOutline{}, errs.NewDBError(err)
@@ -226,13 +286,166 @@ func (db *DB) UpdateAssignmentRubric(ctx context.Context, rubricID int64, assign
 	return updatedAssignmentData, nil
 }
 
-func (db *DB) GetEarliestCommitDate(ctx context.Context, assignmentID int) (*time.Time, error) {
-	var earliestCommitDate *time.Time
+// Gets the assignments whose release time has passed but have not been released to students yet. Assignments without
+// a release time are due for release immediately, e.g. when their scheduled release is cleared. Assignments whose base
+// repository is still being set up are left until it is ready.
+func (db *DB) GetAssignmentsToRelease(ctx context.Context) ([]models.AssignmentOutline, error) {
+	rows, err := db.connPool.Query(ctx, fmt.Sprintf(`
+		SELECT %s FROM assignment_outlines ao
+		JOIN assignment_base_repos abr ON abr.base_repo_id = ao.base_repo_id
+		WHERE ao.released = FALSE AND ao.archived_at IS NULL AND abr.initialized = TRUE
+			AND (ao.released_at IS NULL OR ao.released_at <= (NOW() AT TIME ZONE 'UTC'))`, AssignmentOutlineFields))
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.AssignmentOutline, error) {
+		return scanAssignmentOutline(row)
+	})
+}
+
+func (db *DB) MarkAssignmentReleased(ctx context.Context, assignmentID int64) error {
+	_, err := db.connPool.Exec(ctx, `UPDATE assignment_outlines SET released = TRUE WHERE id = $1`, assignmentID)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+// Updates the editable settings of an assignment
+func (db *DB) UpdateAssignment(ctx context.Context, assignmentData models.AssignmentOutline) (models.AssignmentOutline, error) {
+	assignmentOutline, err := scanAssignmentOutline(db.connPool.QueryRow(ctx, fmt.Sprintf(`
+		UPDATE assignment_outlines AS ao
+		SET name = $1,
+			main_due_date = $2,
+			released_at = $3,
+			group_assignment = $4,
+			default_score = $5,
+			max_group_size = $6,
+			self_formed_groups = $7
+		WHERE ao.id = $8
+		RETURNING %s`, AssignmentOutlineFields),
+		assignmentData.Name,
+		assignmentData.MainDueDate,
+		assignmentData.ReleasedAt,
+		assignmentData.GroupAssignment,
+		assignmentData.DefaultScore,
+		assignmentData.MaxGroupSize,
+		assignmentData.SelfFormedGroups,
+		assignmentData.ID,
+	))
+	if err != nil {
+		return models.AssignmentOutline{}, errs.NewDBError(err)
+	}
+
+	return assignmentOutline, nil
+}
+
+// Archives an assignment and revokes its tokens so it can no longer be accepted
+func (db *DB) ArchiveAssignment(ctx context.Context, assignmentID int64) (models.AssignmentOutline, error) {
+	var assignmentOutline models.AssignmentOutline
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `UPDATE assignment_outline_tokens SET revoked = TRUE WHERE assignment_outline_id = $1`, assignmentID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM assignment_tokens WHERE assignment_outline_id = $1`, assignmentID)
+		if err != nil {
+			return err
+		}
+
+		assignmentOutline, err = scanAssignmentOutline(tx.QueryRow(ctx, fmt.Sprintf(`
+			UPDATE assignment_outlines AS ao SET archived_at = COALESCE(ao.archived_at, (NOW() AT TIME ZONE 'UTC'))
+			WHERE ao.id = $1
+			RETURNING %s`, AssignmentOutlineFields), assignmentID))
+		return err
+	})
+	if err != nil {
+		return models.AssignmentOutline{}, errs.NewDBError(err)
+	}
+
+	return assignmentOutline, nil
+}
+
+// Checks whether students have started working on an assignment or have been graded on it
+func (db *DB) AssignmentHasStudentActivity(ctx context.Context, assignmentID int64) (bool, error) {
+	var hasActivity bool
 	err := db.connPool.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM student_works sw
+			WHERE sw.assignment_outline_id = $1
+				AND (sw.work_state != $2
+					OR sw.commit_amount > 0
+					OR EXISTS (SELECT 1 FROM feedback_comment fc WHERE fc.student_work_id = sw.id)
+					OR EXISTS (SELECT 1 FROM grade_events ge WHERE ge.student_work_id = sw.id))
+		)`, assignmentID, models.WorkStateAccepted).Scan(&hasActivity)
+	if err != nil {
+		return false, errs.NewDBError(err)
+	}
+
+	return hasActivity, nil
+}
+
+// Gets the repository names of every student work on an assignment
+func (db *DB) GetAssignmentWorkRepoNames(ctx context.Context, assignmentID int64) ([]string, error) {
+	rows, err := db.connPool.Query(ctx, `SELECT repo_name FROM student_works WHERE assignment_outline_id = $1 ORDER BY repo_name`, assignmentID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// Deletes an assignment along with its tokens, groups, queued jobs, untouched student works and base repository record
+func (db *DB) DeleteAssignment(ctx context.Context, assignmentID int64) error {
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		statements := []string{
+			`DELETE FROM assignment_token_redemptions WHERE token IN (SELECT token FROM assignment_outline_tokens WHERE assignment_outline_id = $1)`,
+			`DELETE FROM assignment_outline_tokens WHERE assignment_outline_id = $1`,
+			`DELETE FROM assignment_tokens WHERE assignment_outline_id = $1`,
+			`DELETE FROM assignment_group_members WHERE assignment_outline_id = $1`,
+			`DELETE FROM assignment_groups WHERE assignment_outline_id = $1`,
+			`WITH deleted AS (DELETE FROM deadline_updates WHERE assignment_outline_id = $1 RETURNING job_id) DELETE FROM jobs WHERE id IN (SELECT job_id FROM deleted)`,
+			`WITH deleted AS (DELETE FROM starter_code_sync_prs WHERE sync_id IN (SELECT id FROM starter_code_syncs WHERE assignment_outline_id = $1) RETURNING job_id) DELETE FROM jobs WHERE id IN (SELECT job_id FROM deleted)`,
+			`DELETE FROM starter_code_syncs WHERE assignment_outline_id = $1`,
+			`WITH deleted AS (DELETE FROM repo_provisions WHERE assignment_outline_id = $1 RETURNING job_id) DELETE FROM jobs WHERE id IN (SELECT job_id FROM deleted)`,
+			`WITH deleted AS (DELETE FROM classroom_clone_assignments WHERE source_assignment_id = $1 RETURNING job_id) DELETE FROM jobs WHERE id IN (SELECT job_id FROM deleted)`,
+			`UPDATE classroom_clone_assignments SET target_assignment_id = NULL WHERE target_assignment_id = $1`,
+			`DELETE FROM submissions WHERE student_work_id IN (SELECT id FROM student_works WHERE assignment_outline_id = $1)`,
+			`DELETE FROM work_contributors WHERE student_work_id IN (SELECT id FROM student_works WHERE assignment_outline_id = $1)`,
+			`DELETE FROM student_works WHERE assignment_outline_id = $1`,
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(ctx, statement, assignmentID); err != nil {
+				return err
+			}
+		}
+
+		var baseRepoID *int64
+		err := tx.QueryRow(ctx, `DELETE FROM assignment_outlines WHERE id = $1 RETURNING base_repo_id`, assignmentID).Scan(&baseRepoID)
+		if err != nil {
+			return err
+		}
+		if baseRepoID != nil {
+			_, err = tx.Exec(ctx, `DELETE FROM assignment_base_repos WHERE base_repo_id = $1`, *baseRepoID)
+		}
+		return err
+	})
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+func (db *DB) GetEarliestCommitDate(ctx context.Context, assignmentID int, sectionID *int64) (*time.Time, error) {
+	var earliestCommitDate *time.Time
+	err := db.connPool.QueryRow(ctx, fmt.Sprintf(`
 		SELECT MIN(first_commit_date)
 		FROM student_works
-		WHERE assignment_outline_id = $1
-	`, assignmentID).Scan(&earliestCommitDate)
+		WHERE assignment_outline_id = $1 AND %s
+	`, sectionWorkFilter("$2", "id")), assignmentID, sectionID).Scan(&earliestCommitDate)
 	if err != nil {
 		return nil, err
 	}
@@ -240,13 +453,13 @@ func (db *DB) GetEarliestCommitDate(ctx context.Context, assignmentID int) (*tim
 	return earliestCommitDate, nil
 }
 
-func (db *DB) GetTotalWorkCommits(ctx context.Context, assignmentID int) (int, error) {
+func (db *DB) GetTotalWorkCommits(ctx context.Context, assignmentID int, sectionID *int64) (int, error) {
 	var totalCommits int
-	err := db.connPool.QueryRow(ctx, `
-		SELECT SUM(commit_amount) 
+	err := db.connPool.QueryRow(ctx, fmt.Sprintf(`
+		SELECT COALESCE(SUM(commit_amount), 0)
 		FROM student_works 
-		WHERE assignment_outline_id = $1
-	`, assignmentID).Scan(&totalCommits)
+		WHERE assignment_outline_id = $1 AND %s
+	`, sectionWorkFilter("$2", "id")), assignmentID, sectionID).Scan(&totalCommits)
 	if err != nil {
 		return 0, err
 	}
@@ -254,7 +467,7 @@ func (db *DB) GetTotalWorkCommits(ctx context.Context, assignmentID int) (int, e
 	return totalCommits, nil
 }
 
-func (db *DB) CountWorksByState(ctx context.Context, assignmentID int) (map[models.WorkState]int, error) {
+func (db *DB) CountWorksByState(ctx context.Context, assignmentID int, sectionID *int64) (map[models.WorkState]int, error) {
 	// Initialize the count map with all possible WorkState values set to zero
 	workStateCounts := make(map[models.WorkState]int)
 	for _, state := range models.WorkStateEnum {
@@ -262,10 +475,10 @@ func (db *DB) CountWorksByState(ctx context.Context, assignmentID int) (map[mode
 	}
 
 	// Query for the count of student works by status
-	rows, err := db.connPool.Query(ctx, `SELECT work_state, COUNT(*) AS state_count
+	rows, err := db.connPool.Query(ctx, fmt.Sprintf(`SELECT work_state, COUNT(*) AS state_count
 		FROM student_works
-		WHERE assignment_outline_id = $1
-		GROUP BY work_state;`, assignmentID)
+		WHERE assignment_outline_id = $1 AND %s
+		GROUP BY work_state;`, sectionWorkFilter("$2", "id")), assignmentID, sectionID)
 	if err != nil {
 		return nil, err
 	}
@@ -289,27 +502,13 @@ func (db *DB) CountWorksByState(ctx context.Context, assignmentID int) (map[mode
 	return workStateCounts, nil
 }
 
-func (db *DB) GetAssignmentByRepoName(ctx context.Context, repoName string) (*models.AssignmentOutline, error){
-	var outline models.AssignmentOutline
-	 row := db.connPool.QueryRow(ctx, `SELECT ao.id, ao.template_id, ao.base_repo_id, ao.classroom_id, ao.created_at, ao.released_at, ao.name, ao.rubric_id, ao.group_assignment, ao.default_score, ao.main_due_date
+func (db *DB) GetAssignmentByRepoName(ctx context.Context, repoName string) (*models.AssignmentOutline, error) {
+	outline, err := scanAssignmentOutline(db.connPool.QueryRow(ctx, fmt.Sprintf(`SELECT %s
 			FROM assignment_outlines ao
 			JOIN assignment_base_repos at ON ao.base_repo_id = at.base_repo_id
-			WHERE at.base_repo_name ILIKE $1;`, strings.ToLower(repoName))
-	err := row.Scan(&outline.ID,
-				&outline.TemplateID,
-				&outline.BaseRepoID,
-				&outline.ClassroomID,
-				&outline.CreatedAt,
-				&outline.ReleasedAt,
-				&outline.Name,
-				&outline.RubricID,
-				&outline.GroupAssignment,
-				&outline.DefaultScore,
-				&outline.MainDueDate)																					
+			WHERE at.base_repo_name ILIKE $1;`, AssignmentOutlineFields), strings.ToLower(repoName)))
 	if err != nil {
-		fmt.Println("oof")
-		fmt.Println(err)
 		return nil, err
 	}
 	return &outline, nil
-}
\ No newline at end of file
+}
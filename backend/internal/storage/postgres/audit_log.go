@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+func (db *DB) CreateAuditLogEntry(ctx context.Context, entry models.AuditLogEntry) (models.AuditLogEntry, error) {
+	rows, err := db.connPool.Query(ctx, `
+		INSERT INTO audit_log_entries (classroom_id, actor_user_id, subject_user_id, action, details)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING *`, entry.ClassroomID, entry.ActorUserID, entry.SubjectUserID, entry.Action, entry.Details)
+	if err != nil {
+		return models.AuditLogEntry{}, errs.NewDBError(err)
+	}
+
+	entry, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[models.AuditLogEntry])
+	if err != nil {
+		return models.AuditLogEntry{}, errs.NewDBError(err)
+	}
+
+	return entry, nil
+}
+
+// Gets a classroom's audit log, newest first
+func (db *DB) GetClassroomAuditLog(ctx context.Context, classroomID int64) ([]models.AuditLogEntry, error) {
+	rows, err := db.connPool.Query(ctx, `
+		SELECT * FROM audit_log_entries
+		WHERE classroom_id = $1
+		ORDER BY created_at DESC, id DESC`, classroomID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.AuditLogEntry])
+}
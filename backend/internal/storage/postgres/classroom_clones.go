@@ -0,0 +1,183 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// Records a classroom clone, copying the source classroom's rubrics into the new classroom and queueing a copy of
+// each of its assignments that hasn't been archived
+func (db *DB) QueueClassroomClone(ctx context.Context, clone models.ClassroomClone, targetOrgID int64) (models.ClassroomClone, []models.ClassroomCloneAssignment, error) {
+	var assignments []models.ClassroomCloneAssignment
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			INSERT INTO classroom_clones (source_classroom_id, target_classroom_id, due_date_offset_days, created_by)
+			VALUES ($1, $2, $3, $4)
+			RETURNING *`, clone.SourceClassroomID, clone.TargetClassroomID, clone.DueDateOffsetDays, clone.CreatedBy)
+		if err != nil {
+			return err
+		}
+		clone, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[models.ClassroomClone])
+		if err != nil {
+			return err
+		}
+
+		rows, err = tx.Query(ctx, `SELECT id FROM rubrics WHERE classroom_id = $1 ORDER BY id`, clone.SourceClassroomID)
+		if err != nil {
+			return err
+		}
+		rubricIDs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+		if err != nil {
+			return err
+		}
+
+		// maps each source rubric to its copy, with only the items still in use
+		clonedRubricIDs := make(map[int64]int64)
+		for _, rubricID := range rubricIDs {
+			var clonedRubricID int64
+			err = tx.QueryRow(ctx, `
+				INSERT INTO rubrics (name, org_id, classroom_id, reusable)
+				SELECT name, $2, $3, reusable FROM rubrics WHERE id = $1
+				RETURNING id`, rubricID, targetOrgID, clone.TargetClassroomID).Scan(&clonedRubricID)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(ctx, `
+				INSERT INTO rubric_items (rubric_id, point_value, explanation)
+				SELECT $1, point_value, explanation FROM rubric_items
+				WHERE rubric_id = $2 AND deleted IS NOT TRUE
+				ORDER BY id`, clonedRubricID, rubricID)
+			if err != nil {
+				return err
+			}
+			clonedRubricIDs[rubricID] = clonedRubricID
+		}
+
+		rows, err = tx.Query(ctx, `
+			SELECT id, rubric_id FROM assignment_outlines
+			WHERE classroom_id = $1 AND archived_at IS NULL
+			ORDER BY id`, clone.SourceClassroomID)
+		if err != nil {
+			return err
+		}
+		type sourceAssignment struct {
+			ID       int64  `db:"id"`
+			RubricID *int64 `db:"rubric_id"`
+		}
+		sourceAssignments, err := pgx.CollectRows(rows, pgx.RowToStructByName[sourceAssignment])
+		if err != nil {
+			return err
+		}
+
+		for _, source := range sourceAssignments {
+			var targetRubricID *int64
+			if source.RubricID != nil {
+				if clonedRubricID, ok := clonedRubricIDs[*source.RubricID]; ok {
+					targetRubricID = &clonedRubricID
+				}
+			}
+
+			_, err = tx.Exec(ctx, `
+				INSERT INTO classroom_clone_assignments (job_id, clone_id, source_assignment_id, target_rubric_id)
+				VALUES (create_job($4), $1, $2, $3)`, clone.ID, source.ID, targetRubricID, models.JobKindClassroomCloneAssignment)
+			if err != nil {
+				return err
+			}
+		}
+
+		assignments, err = getClassroomCloneAssignments(ctx, tx, clone.ID)
+		return err
+	})
+	if err != nil {
+		return models.ClassroomClone{}, nil, errs.NewDBError(err)
+	}
+
+	return clone, assignments, nil
+}
+
+func (db *DB) GetClassroomCloneByID(ctx context.Context, cloneID int) (models.ClassroomClone, error) {
+	rows, err := db.connPool.Query(ctx, `SELECT * FROM classroom_clones WHERE id = $1`, cloneID)
+	if err != nil {
+		return models.ClassroomClone{}, errs.NewDBError(err)
+	}
+
+	clone, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.ClassroomClone])
+	if err != nil {
+		return models.ClassroomClone{}, errs.NewDBError(err)
+	}
+
+	return clone, nil
+}
+
+// Gets the clones made from a classroom, newest first
+func (db *DB) GetClassroomClones(ctx context.Context, sourceClassroomID int64) ([]models.ClassroomClone, error) {
+	rows, err := db.connPool.Query(ctx, `
+		SELECT * FROM classroom_clones
+		WHERE source_classroom_id = $1
+		ORDER BY created_at DESC, id DESC`, sourceClassroomID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.ClassroomClone])
+}
+
+func (db *DB) GetClassroomCloneAssignments(ctx context.Context, cloneID int) ([]models.ClassroomCloneAssignment, error) {
+	assignments, err := getClassroomCloneAssignments(ctx, db.connPool, cloneID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return assignments, nil
+}
+
+func getClassroomCloneAssignments(ctx context.Context, q querier, cloneID int) ([]models.ClassroomCloneAssignment, error) {
+	rows, err := q.Query(ctx, `
+		SELECT ca.*, `+jobColumns+`
+		FROM classroom_clone_assignments ca
+		JOIN jobs j ON j.id = ca.job_id
+		WHERE ca.clone_id = $1
+		ORDER BY ca.id`, cloneID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.ClassroomCloneAssignment])
+}
+
+// Gets the assignment copies still to be attempted, oldest first
+func (db *DB) GetPendingClassroomCloneAssignments(ctx context.Context, limit int) ([]models.ClassroomCloneAssignment, error) {
+	return getPendingJobs[models.ClassroomCloneAssignment](ctx, db, `q.*`, `classroom_clone_assignments q`, limit)
+}
+
+// Saves the name picked for an assignment copy's base repository before the repository is created
+func (db *DB) SetClassroomCloneBaseRepoName(ctx context.Context, cloneAssignmentID int, baseRepoName string) error {
+	_, err := db.connPool.Exec(ctx, `UPDATE classroom_clone_assignments SET base_repo_name = $1 WHERE id = $2`,
+		baseRepoName, cloneAssignmentID)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+// Records the outcome of an attempted assignment copy, along with the assignment it created
+func (db *DB) CompleteClassroomCloneAssignment(ctx context.Context, assignment models.ClassroomCloneAssignment) error {
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `UPDATE classroom_clone_assignments SET target_assignment_id = $1 WHERE id = $2`,
+			assignment.TargetAssignmentID, assignment.ID)
+		if err != nil {
+			return err
+		}
+		return completeJob(ctx, tx, assignment.Job)
+	})
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
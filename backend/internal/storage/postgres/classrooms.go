@@ -2,6 +2,8 @@ package postgres
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/CamPlume1/khoury-classroom/internal/errs"
 	"github.com/CamPlume1/khoury-classroom/internal/models"
@@ -10,19 +12,25 @@ import (
 
 func (db *DB) CreateClassroom(ctx context.Context, classroomData models.Classroom) (models.Classroom, error) {
 	err := db.connPool.QueryRow(ctx, `
-	INSERT INTO classrooms (name, org_id, org_name, student_team_name)
-	VALUES ($1, $2, $3, $4)
-	RETURNING id, name, org_id, org_name, created_at, student_team_name`,
+	INSERT INTO classrooms (name, org_id, org_name, student_team_name, base_repo_name_template, work_repo_name_template, staff_team_name)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id, name, org_id, org_name, created_at, student_team_name, base_repo_name_template, work_repo_name_template, staff_team_name`,
 		classroomData.Name,
 		classroomData.OrgID,
 		classroomData.OrgName,
 		classroomData.StudentTeamName,
+		classroomData.BaseRepoNameTemplate,
+		classroomData.WorkRepoNameTemplate,
+		classroomData.StaffTeamName,
 	).Scan(&classroomData.ID,
 		&classroomData.Name,
 		&classroomData.OrgID,
 		&classroomData.OrgName,
 		&classroomData.CreatedAt,
-		&classroomData.StudentTeamName)
+		&classroomData.StudentTeamName,
+		&classroomData.BaseRepoNameTemplate,
+		&classroomData.WorkRepoNameTemplate,
+		&classroomData.StaffTeamName)
 
 	if err != nil {
 		return models.Classroom{}, errs.NewDBError(err)
@@ -34,19 +42,24 @@ func (db *DB) CreateClassroom(ctx context.Context, classroomData models.Classroo
 func (db *DB) UpdateClassroom(ctx context.Context, classroomData models.Classroom) (models.Classroom, error) {
 	err := db.connPool.QueryRow(ctx, `
 	UPDATE classrooms
-	SET name = $1, org_id = $2, org_name = $3, student_team_name = $4
-	WHERE id = $5
-	RETURNING id, name, org_id, org_name, created_at, student_team_name`,
+	SET name = $1, org_id = $2, org_name = $3, student_team_name = $4, base_repo_name_template = $5, work_repo_name_template = $6
+	WHERE id = $7
+	RETURNING id, name, org_id, org_name, created_at, student_team_name, base_repo_name_template, work_repo_name_template, staff_team_name`,
 		classroomData.Name,
 		classroomData.OrgID,
 		classroomData.OrgName,
 		classroomData.StudentTeamName,
+		classroomData.BaseRepoNameTemplate,
+		classroomData.WorkRepoNameTemplate,
 		classroomData.ID).Scan(&classroomData.ID,
 		&classroomData.Name,
 		&classroomData.OrgID,
 		&classroomData.OrgName,
 		&classroomData.CreatedAt,
-		&classroomData.StudentTeamName)
+		&classroomData.StudentTeamName,
+		&classroomData.BaseRepoNameTemplate,
+		&classroomData.WorkRepoNameTemplate,
+		&classroomData.StaffTeamName)
 
 	if err != nil {
 		return models.Classroom{}, errs.NewDBError(err)
@@ -58,7 +71,7 @@ func (db *DB) UpdateClassroom(ctx context.Context, classroomData models.Classroo
 func (db *DB) GetClassroomByID(ctx context.Context, classroomID int64) (models.Classroom, error) {
 	var classroomData models.Classroom
 	err := db.connPool.QueryRow(ctx, `
-	SELECT id, name, org_id, org_name, created_at, student_team_name
+	SELECT id, name, org_id, org_name, created_at, student_team_name, base_repo_name_template, work_repo_name_template, staff_team_name
 	FROM classrooms
 	WHERE id = $1`, classroomID).Scan(
 		&classroomData.ID,
@@ -67,6 +80,9 @@ func (db *DB) GetClassroomByID(ctx context.Context, classroomID int64) (models.C
 		&classroomData.OrgName,
 		&classroomData.CreatedAt,
 		&classroomData.StudentTeamName,
+		&classroomData.BaseRepoNameTemplate,
+		&classroomData.WorkRepoNameTemplate,
+		&classroomData.StaffTeamName,
 	)
 
 	if err != nil {
@@ -76,10 +92,20 @@ func (db *DB) GetClassroomByID(ctx context.Context, classroomID int64) (models.C
 	return classroomData, nil
 }
 
+// Records the GitHub team of a classroom's TAs and professors
+func (db *DB) SetClassroomStaffTeam(ctx context.Context, classroomID int64, staffTeamName string) error {
+	_, err := db.connPool.Exec(ctx, `UPDATE classrooms SET staff_team_name = $1 WHERE id = $2`, staffTeamName, classroomID)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
 func (db *DB) GetClassroomByName(ctx context.Context, classroomName string) (models.Classroom, error) {
 	var classroomData models.Classroom
 	err := db.connPool.QueryRow(ctx, `
-	SELECT id, name, org_id, org_name, created_at, student_team_name
+	SELECT id, name, org_id, org_name, created_at, student_team_name, base_repo_name_template, work_repo_name_template, staff_team_name
 	FROM classrooms
 	WHERE name = $1`, classroomName).Scan(
 		&classroomData.ID,
@@ -88,6 +114,9 @@ func (db *DB) GetClassroomByName(ctx context.Context, classroomName string) (mod
 		&classroomData.OrgName,
 		&classroomData.CreatedAt,
 		&classroomData.StudentTeamName,
+		&classroomData.BaseRepoNameTemplate,
+		&classroomData.WorkRepoNameTemplate,
+		&classroomData.StaffTeamName,
 	)
 
 	if err != nil {
@@ -208,6 +237,20 @@ func (db *DB) ModifyUserStatus(ctx context.Context, classroomID int64, status mo
 	return classroomUser, nil
 }
 
+// Records that a classroom member is on the classroom's roster, along with the ID they are known by outside of
+// GitMarks if the roster gives one
+func (db *DB) MarkOnClassroomRoster(ctx context.Context, classroomID int64, userID int64, externalID *string) error {
+	_, err := db.connPool.Exec(ctx, `
+		UPDATE classroom_membership SET on_roster = TRUE, external_id = COALESCE($1, external_id)
+		WHERE classroom_id = $2 AND user_id = $3`,
+		externalID, classroomID, userID)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
 func (db *DB) GetUsersInClassroom(ctx context.Context, classroomID int64) ([]models.ClassroomUser, error) {
 	rows, err := db.connPool.Query(ctx, `
 	SELECT u.id, u.first_name, u.last_name, u.github_username, u.github_user_id, cm.classroom_id, cm.classroom_role, cm.status, c.name as classroom_name, c.created_at as classroom_created_at, c.org_id, c.org_name
@@ -251,9 +294,22 @@ func (db *DB) GetUserInClassroom(ctx context.Context, classroomID int64, userID
 	return userData, nil
 }
 
+// Gets every classroom, oldest first
+func (db *DB) GetClassrooms(ctx context.Context) ([]models.Classroom, error) {
+	rows, err := db.connPool.Query(ctx, `
+	SELECT id, name, org_id, org_name, created_at, student_team_name, base_repo_name_template, work_repo_name_template, staff_team_name
+	FROM classrooms
+	ORDER BY id`)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.Classroom])
+}
+
 func (db *DB) GetClassroomsInOrg(ctx context.Context, orgID int64) ([]models.Classroom, error) {
 	rows, err := db.connPool.Query(ctx, `
-	SELECT id, name, org_id, org_name, created_at, student_team_name
+	SELECT id, name, org_id, org_name, created_at, student_team_name, base_repo_name_template, work_repo_name_template, staff_team_name
 	FROM classrooms
 	WHERE org_id = $1`, orgID)
 	if err != nil {
@@ -278,22 +334,40 @@ func (db *DB) GetUserClassroomsInOrg(ctx context.Context, orgID int64, userID in
 	return pgx.CollectRows(rows, pgx.RowToStructByName[models.ClassroomUser])
 }
 
-func (db *DB) CreateClassroomToken(ctx context.Context, tokenData models.ClassroomToken) (models.ClassroomToken, error) {
-	err := db.connPool.QueryRow(ctx, `
-	INSERT INTO classroom_tokens (classroom_id, classroom_role, token, expires_at)
-	VALUES ($1, $2, $3, $4)
-	RETURNING classroom_id, classroom_role, token, created_at, expires_at`,
-		tokenData.ClassroomID,
-		tokenData.ClassroomRole,
-		tokenData.Token,
-		tokenData.ExpiresAt,
-	).Scan(
+const classroomTokenFields = `classroom_id, classroom_role, token, created_at, expires_at, max_uses, use_count, revoked, allowed_usernames, allowed_email_domains, created_by`
+
+func scanClassroomToken(row pgx.Row) (models.ClassroomToken, error) {
+	var tokenData models.ClassroomToken
+	err := row.Scan(
 		&tokenData.ClassroomID,
 		&tokenData.ClassroomRole,
 		&tokenData.Token,
 		&tokenData.CreatedAt,
 		&tokenData.ExpiresAt,
+		&tokenData.MaxUses,
+		&tokenData.UseCount,
+		&tokenData.Revoked,
+		&tokenData.AllowedUsernames,
+		&tokenData.AllowedEmailDomains,
+		&tokenData.CreatedBy,
 	)
+	return tokenData, err
+}
+
+func (db *DB) CreateClassroomToken(ctx context.Context, tokenData models.ClassroomToken) (models.ClassroomToken, error) {
+	tokenData, err := scanClassroomToken(db.connPool.QueryRow(ctx, fmt.Sprintf(`
+	INSERT INTO classroom_tokens (classroom_id, classroom_role, token, expires_at, max_uses, allowed_usernames, allowed_email_domains, created_by)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING %s`, classroomTokenFields),
+		tokenData.ClassroomID,
+		tokenData.ClassroomRole,
+		tokenData.Token,
+		tokenData.ExpiresAt,
+		tokenData.MaxUses,
+		tokenData.AllowedUsernames,
+		tokenData.AllowedEmailDomains,
+		tokenData.CreatedBy,
+	))
 
 	if err != nil {
 		return models.ClassroomToken{}, errs.NewDBError(err)
@@ -303,18 +377,11 @@ func (db *DB) CreateClassroomToken(ctx context.Context, tokenData models.Classro
 }
 
 func (db *DB) GetClassroomToken(ctx context.Context, token string) (models.ClassroomToken, error) {
-	var tokenData models.ClassroomToken
-	err := db.connPool.QueryRow(ctx, `
-		SELECT classroom_id, classroom_role, token, created_at, expires_at
+	tokenData, err := scanClassroomToken(db.connPool.QueryRow(ctx, fmt.Sprintf(`
+		SELECT %s
 		FROM classroom_tokens
 		WHERE token = $1
-	`, token).Scan(
-		&tokenData.ClassroomID,
-		&tokenData.ClassroomRole,
-		&tokenData.Token,
-		&tokenData.CreatedAt,
-		&tokenData.ExpiresAt,
-	)
+	`, classroomTokenFields), token))
 
 	if err != nil {
 		return models.ClassroomToken{}, errs.NewDBError(err)
@@ -324,18 +391,11 @@ func (db *DB) GetClassroomToken(ctx context.Context, token string) (models.Class
 }
 
 func (db *DB) GetPermanentClassroomTokenByClassroomIDAndRole(ctx context.Context, classroomID int64, classroomRole models.ClassroomRole) (models.ClassroomToken, error) {
-	var tokenData models.ClassroomToken
-	err := db.connPool.QueryRow(ctx, `
-		SELECT classroom_id, classroom_role, token, created_at, expires_at
+	tokenData, err := scanClassroomToken(db.connPool.QueryRow(ctx, fmt.Sprintf(`
+		SELECT %s
 		FROM classroom_tokens
-		WHERE classroom_id = $1 AND classroom_role = $2 AND expires_at IS NULL
-	`, classroomID, classroomRole).Scan(
-		&tokenData.ClassroomID,
-		&tokenData.ClassroomRole,
-		&tokenData.Token,
-		&tokenData.CreatedAt,
-		&tokenData.ExpiresAt,
-	)
+		WHERE classroom_id = $1 AND classroom_role = $2 AND %s
+	`, classroomTokenFields, permanentTokenCondition), classroomID, classroomRole))
 
 	if err != nil {
 		return models.ClassroomToken{}, errs.NewDBError(err)
@@ -344,13 +404,70 @@ func (db *DB) GetPermanentClassroomTokenByClassroomIDAndRole(ctx context.Context
 	return tokenData, nil
 }
 
-func (db *DB) GetNumberOfStudentsInClassroom(ctx context.Context, classroomID int64) (int, error) {
+// Gets the classroom's tokens that can still be used, newest first
+func (db *DB) GetActiveClassroomTokens(ctx context.Context, classroomID int64) ([]models.ClassroomToken, error) {
+	rows, err := db.connPool.Query(ctx, fmt.Sprintf(`
+		SELECT %s
+		FROM classroom_tokens
+		WHERE classroom_id = $1 AND %s
+		ORDER BY created_at DESC`, classroomTokenFields, activeTokenCondition), classroomID, time.Now())
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+	defer rows.Close()
+
+	tokens := []models.ClassroomToken{}
+	for rows.Next() {
+		tokenData, err := scanClassroomToken(rows)
+		if err != nil {
+			return nil, errs.NewDBError(err)
+		}
+		tokens = append(tokens, tokenData)
+	}
+	if rows.Err() != nil {
+		return nil, errs.NewDBError(rows.Err())
+	}
+
+	return tokens, nil
+}
+
+// Revokes one of the classroom's tokens. Returns pgx.ErrNoRows if the classroom has no such token.
+func (db *DB) RevokeClassroomToken(ctx context.Context, classroomID int64, token string) (models.ClassroomToken, error) {
+	return scanClassroomToken(db.connPool.QueryRow(ctx, fmt.Sprintf(`
+		UPDATE classroom_tokens SET revoked = TRUE
+		WHERE classroom_id = $1 AND token = $2
+		RETURNING %s`, classroomTokenFields), classroomID, token))
+}
+
+// Records a user's use of a classroom token, failing if the token can no longer be used
+func (db *DB) RedeemClassroomToken(ctx context.Context, token string, userID int64) (models.ClassroomToken, error) {
+	var tokenData models.ClassroomToken
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		var err error
+		tokenData, err = scanClassroomToken(tx.QueryRow(ctx, fmt.Sprintf(`
+			SELECT %s FROM classroom_tokens WHERE token = $1 FOR UPDATE`, classroomTokenFields), token))
+		if err != nil {
+			return errs.NewDBError(err)
+		}
+
+		return redeemToken(ctx, tx, "classroom_tokens", "classroom_token_redemptions", &tokenData.BaseToken, userID)
+	})
+	if err != nil {
+		return models.ClassroomToken{}, err
+	}
+
+	return tokenData, nil
+}
+
+// Counts the students in a classroom, or only those in the given section
+func (db *DB) GetNumberOfStudentsInClassroom(ctx context.Context, classroomID int64, sectionID *int64) (int, error) {
 	var count int
 	err := db.connPool.QueryRow(ctx, `
 		SELECT COUNT(*)
-		FROM classroom_membership
-		WHERE (classroom_id = $1 AND classroom_role = 'STUDENT')
-	`, classroomID).Scan(&count)
+		FROM classroom_membership cm
+		WHERE (cm.classroom_id = $1 AND cm.classroom_role = 'STUDENT')
+			AND ($2::int IS NULL OR EXISTS (SELECT 1 FROM section_membership sm WHERE sm.section_id = $2 AND sm.user_id = cm.user_id))
+	`, classroomID, sectionID).Scan(&count)
 
 	if err != nil {
 		return 0, errs.NewDBError(err)
@@ -5,17 +5,37 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/CamPlume1/khoury-classroom/internal/config"
+	"github.com/CamPlume1/khoury-classroom/internal/encryption"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type DB struct {
 	connPool *pgxpool.Pool
+	// encrypts session tokens at rest
+	sessionKeys *encryption.Keyring
+	// when sessions with plaintext tokens stop being accepted
+	sessionPlaintextCutoff time.Time
+}
+
+// Runs queries on either the connection pool or a transaction
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 }
 
 // Establishes a postgres connection pool and returns it for querying
 func New(ctx context.Context, config config.Database) (*DB, error) {
+	sessionKeys, err := encryption.NewKeyring(config.SessionKeys, config.SessionKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session encryption keys: %w", err)
+	}
+
 	connPool, err := pgxpool.New(ctx, config.URL)
 	if err != nil {
 		fmt.Println(err)
@@ -23,7 +43,7 @@ func New(ctx context.Context, config config.Database) (*DB, error) {
 	}
 
 	fmt.Println("Successfully connected to the database!")
-	return &DB{connPool: connPool}, nil
+	return &DB{connPool: connPool, sessionKeys: sessionKeys, sessionPlaintextCutoff: config.SessionPlaintextCutoff}, nil
 }
 
 // Closes the connection pool
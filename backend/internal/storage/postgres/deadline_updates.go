@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// Gets the repository names of the works on an assignment that follow the given due date, i.e. have no extension
+func (db *DB) GetWorkRepoNamesWithDueDate(ctx context.Context, assignmentID int64, dueDate *time.Time) ([]string, error) {
+	rows, err := db.connPool.Query(ctx, `
+		SELECT repo_name FROM student_works
+		WHERE assignment_outline_id = $1 AND unique_due_date IS NOT DISTINCT FROM $2
+		ORDER BY repo_name`, assignmentID, dueDate)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// Moves the works following an assignment's old due date to the new one, and queues a deadline update for
+// the base repository and each of those works on the given branch. Updates from an earlier change still to be
+// attempted, pending or failed with retries left, are replaced so an older due date is never written after this one.
+// Assignments without a deadline workflow only have their works moved.
+func (db *DB) QueueDeadlineUpdates(ctx context.Context, assignmentID int64, baseRepo models.AssignmentBaseRepo, orgName, branchName string, oldDueDate *time.Time, newDueDate time.Time, queueWorkflows bool) ([]models.DeadlineUpdate, error) {
+	updates := []models.DeadlineUpdate{}
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			UPDATE student_works SET unique_due_date = $1, predates_locking = FALSE
+			WHERE assignment_outline_id = $2 AND unique_due_date IS NOT DISTINCT FROM $3
+			RETURNING repo_name`, newDueDate, assignmentID, oldDueDate)
+		if err != nil {
+			return err
+		}
+		repoNames, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil || !queueWorkflows {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			WITH replaced AS (
+				DELETE FROM deadline_updates du USING jobs j
+				WHERE j.id = du.job_id AND du.assignment_outline_id = $1
+					AND (j.status = $2 OR (j.status = $3 AND j.attempts < $4))
+				RETURNING du.job_id
+			)
+			DELETE FROM jobs WHERE id IN (SELECT job_id FROM replaced)`,
+			assignmentID, models.JobPending, models.JobFailed, models.MaxJobAttempts)
+		if err != nil {
+			return err
+		}
+
+		// the base repository lives under its own owner, student repositories under the classroom's org
+		repoOwners := []string{baseRepo.BaseRepoOwner}
+		for range repoNames {
+			repoOwners = append(repoOwners, orgName)
+		}
+		repoNames = append([]string{baseRepo.BaseRepoName}, repoNames...)
+
+		rows, err = tx.Query(ctx, `
+			INSERT INTO deadline_updates (job_id, assignment_outline_id, repo_owner, repo_name, branch_name, due_date)
+			SELECT create_job($1), $2, owner, name, $3, $4 FROM UNNEST($5::text[], $6::text[]) AS repos(owner, name)
+			RETURNING id`, models.JobKindDeadlineUpdate, assignmentID, branchName, newDueDate, repoOwners, repoNames)
+		if err != nil {
+			return err
+		}
+		updateIDs, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return err
+		}
+
+		rows, err = tx.Query(ctx, `
+			SELECT du.*, `+jobColumns+`
+			FROM deadline_updates du
+			JOIN jobs j ON j.id = du.job_id
+			WHERE du.id = ANY($1)
+			ORDER BY du.id`, updateIDs)
+		if err != nil {
+			return err
+		}
+		updates, err = pgx.CollectRows(rows, pgx.RowToStructByName[models.DeadlineUpdate])
+		return err
+	})
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return updates, nil
+}
+
+// Gets the deadline updates still to be attempted, oldest first
+func (db *DB) GetPendingDeadlineUpdates(ctx context.Context, limit int) ([]models.DeadlineUpdate, error) {
+	return getPendingJobs[models.DeadlineUpdate](ctx, db, `q.*`, `deadline_updates q`, limit)
+}
+
+// Gets the deadline updates of an assignment, most recent first
+func (db *DB) GetDeadlineUpdatesByAssignment(ctx context.Context, assignmentID int64) ([]models.DeadlineUpdate, error) {
+	rows, err := db.connPool.Query(ctx, `
+		SELECT du.*, `+jobColumns+`
+		FROM deadline_updates du
+		JOIN jobs j ON j.id = du.job_id
+		WHERE du.assignment_outline_id = $1
+		ORDER BY du.created_at DESC, du.repo_name`, assignmentID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.DeadlineUpdate])
+}
@@ -5,17 +5,20 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
 	"github.com/CamPlume1/khoury-classroom/internal/models"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // gets all feedback comments on a student work
 func (db *DB) GetFeedbackOnWork(ctx context.Context, studentWorkID int) ([]models.PRReviewCommentResponse, error) {
-	query := `SELECT student_work_id, rubric_item_id, github_username, file_path, file_line, fc.created_at, point_value, explanation
+	query := `SELECT fc.id, student_work_id, rubric_item_id, github_username, file_path, start_line, file_line, commit_sha, github_comment_id, fc.created_at, point_value, explanation
 	FROM feedback_comment fc
 	JOIN rubric_items ri ON fc.rubric_item_id = ri.id
 	JOIN users u ON fc.ta_user_id = u.id 
-	WHERE student_work_id = $1`
+	WHERE student_work_id = $1 AND fc.deleted = FALSE
+	ORDER BY fc.created_at, fc.id`
 
 	rows, err := db.connPool.Query(ctx, query, studentWorkID)
 
@@ -33,14 +36,21 @@ func (db *DB) GetFeedbackOnWork(ctx context.Context, studentWorkID int) ([]model
 
 	var formattedFeedback []models.PRReviewCommentResponse
 	for _, feedback := range rawFeedback {
+		feedbackCommentID := feedback.ID
+		rubricItemID := feedback.RubricItemID
 		formattedFeedback = append(formattedFeedback, models.PRReviewCommentResponse{
 			PRReviewComment: models.PRReviewComment{
-				Path: feedback.FilePath,
-				Line: feedback.FileLine,
-				Body: feedback.Explanation,
+				Path:      feedback.FilePath,
+				Line:      feedback.FileLine,
+				StartLine: feedback.StartLine,
+				Body:      feedback.Explanation,
 			},
-			Points:     feedback.PointValue,
-			TAUsername: feedback.TAUsername,
+			FeedbackCommentID: &feedbackCommentID,
+			RubricItemID:      &rubricItemID,
+			CommitSHA:         feedback.CommitSHA,
+			GitHubCommentID:   feedback.GitHubCommentID,
+			Points:            feedback.PointValue,
+			TAUsername:        feedback.TAUsername,
 		})
 	}
 
@@ -48,40 +58,129 @@ func (db *DB) GetFeedbackOnWork(ctx context.Context, studentWorkID int) ([]model
 }
 
 // create a new feedback comment (ad-hoc: also create a rubric item simultaneously)
-func (db *DB) CreateFeedbackComment(ctx context.Context, TAUserID int64, studentWorkID int, comment models.PRReviewCommentResponse) error {
-	_, err := db.connPool.Exec(ctx,
+func createFeedbackComment(ctx context.Context, q querier, TAUserID int64, studentWorkID int, comment models.PRReviewCommentResponse) (int, error) {
+	var feedbackCommentID int
+	err := q.QueryRow(ctx,
 		`WITH ri AS
 			(INSERT INTO rubric_items (point_value, explanation) VALUES ($1, $2) RETURNING id)
 		INSERT INTO feedback_comment
-			(rubric_item_id, file_path, file_line, student_work_id, ta_user_id)
-			VALUES ((SELECT id FROM ri), $3, $4, $5, $6)`,
+			(rubric_item_id, file_path, file_line, student_work_id, ta_user_id, start_line, commit_sha)
+			VALUES ((SELECT id FROM ri), $3, $4, $5, $6, $7, $8)
+		RETURNING id`,
 		comment.Points,
 		comment.Body,
 		comment.Path,
 		comment.Line,
 		studentWorkID,
 		TAUserID,
-	)
+		comment.StartLine,
+		comment.CommitSHA,
+	).Scan(&feedbackCommentID)
 
-	return err
+	return feedbackCommentID, err
 }
 
 // create a new feedback comment (attach existing rubric item)
-func (db *DB) CreateFeedbackCommentFromRubricItem(ctx context.Context, TAUserID int64, studentWorkID int, comment models.PRReviewCommentResponse) error {
+func createFeedbackCommentFromRubricItem(ctx context.Context, q querier, TAUserID int64, studentWorkID int, comment models.PRReviewCommentResponse) (int, error) {
 	if comment.RubricItemID == nil {
-		return errors.New("no rubric item id given")
+		return 0, errors.New("no rubric item id given")
 	}
 
-	_, err := db.connPool.Exec(ctx,
+	var feedbackCommentID int
+	err := q.QueryRow(ctx,
 		`INSERT INTO feedback_comment
-				(rubric_item_id, file_path, file_line, student_work_id, ta_user_id)
-				VALUES ($1, $2, $3, $4, $5)`,
+				(rubric_item_id, file_path, file_line, student_work_id, ta_user_id, start_line, commit_sha)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id`,
 		comment.RubricItemID,
 		comment.Path,
 		comment.Line,
 		studentWorkID,
 		TAUserID,
+		comment.StartLine,
+		comment.CommitSHA,
+	).Scan(&feedbackCommentID)
+
+	return feedbackCommentID, err
+}
+
+// edit an existing feedback comment (ad-hoc: a new rubric item is created when none is given)
+func updateFeedbackComment(ctx context.Context, q querier, studentWorkID int, comment models.PRReviewCommentResponse) error {
+	if comment.FeedbackCommentID == nil {
+		return errors.New("no feedback comment id given")
+	}
+
+	var tag pgconn.CommandTag
+	var err error
+	if comment.RubricItemID == nil {
+		tag, err = q.Exec(ctx,
+			`WITH ri AS
+				(INSERT INTO rubric_items (point_value, explanation) VALUES ($1, $2) RETURNING id)
+			UPDATE feedback_comment
+			SET rubric_item_id = (SELECT id FROM ri), file_path = $3, file_line = $4, start_line = $7,
+				commit_sha = $8
+			WHERE id = $5 AND student_work_id = $6 AND deleted = FALSE`,
+			comment.Points,
+			comment.Body,
+			comment.Path,
+			comment.Line,
+			comment.FeedbackCommentID,
+			studentWorkID,
+			comment.StartLine,
+			comment.CommitSHA,
+		)
+	} else {
+		tag, err = q.Exec(ctx,
+			`UPDATE feedback_comment
+			SET rubric_item_id = $1, file_path = $2, file_line = $3, start_line = $6,
+				commit_sha = $7
+			WHERE id = $4 AND student_work_id = $5 AND deleted = FALSE`,
+			comment.RubricItemID,
+			comment.Path,
+			comment.Line,
+			comment.FeedbackCommentID,
+			studentWorkID,
+			comment.StartLine,
+			comment.CommitSHA,
+		)
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.EmptyResult()
+	}
+
+	return nil
+}
+
+// soft delete a feedback comment so it no longer counts towards the score
+func deleteFeedbackComment(ctx context.Context, q querier, studentWorkID int, feedbackCommentID int) error {
+	tag, err := q.Exec(ctx,
+		`UPDATE feedback_comment SET deleted = TRUE WHERE id = $1 AND student_work_id = $2 AND deleted = FALSE`,
+		feedbackCommentID,
+		studentWorkID,
 	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.EmptyResult()
+	}
+
+	return nil
+}
+
+// record the pull request review comment a feedback comment is posted as on GitHub, nil if it is not posted
+func (db *DB) SetFeedbackGitHubComment(ctx context.Context, feedbackCommentID int, gitHubCommentID *int64) error {
+	_, err := db.connPool.Exec(ctx,
+		`UPDATE feedback_comment SET github_comment_id = $1 WHERE id = $2`,
+		gitHubCommentID,
+		feedbackCommentID,
+	)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
 
-	return err
+	return nil
 }
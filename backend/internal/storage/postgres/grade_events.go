@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// Gets the current manual feedback score of a student work
+func getWorkScore(ctx context.Context, q querier, studentWorkID int) (*int, error) {
+	var score *int
+	err := q.QueryRow(ctx,
+		`SELECT manual_feedback_score FROM student_works_with_scores WHERE id = $1`,
+		studentWorkID,
+	).Scan(&score)
+	return score, err
+}
+
+// Appends an event to a student work's grade history. The after score is read from the work's current score.
+func createGradeEvent(ctx context.Context, q querier, event models.GradeEvent) (models.GradeEvent, error) {
+	err := q.QueryRow(ctx, `
+	INSERT INTO grade_events (student_work_id, feedback_comment_id, actor_user_id, event_type, before_score, after_score, reason)
+	VALUES ($1, $2, $3, $4, $5,
+		(SELECT manual_feedback_score FROM student_works_with_scores WHERE id = $1),
+		$6)
+	RETURNING id, after_score, created_at`,
+		event.StudentWorkID,
+		event.FeedbackCommentID,
+		event.ActorUserID,
+		event.EventType,
+		event.BeforeScore,
+		event.Reason,
+	).Scan(
+		&event.ID,
+		&event.AfterScore,
+		&event.CreatedAt,
+	)
+	return event, err
+}
+
+// Creates, edits or deletes feedback comments on a student work, appending each change to the work's grade history.
+// Either every change is saved along with its event, or none are.
+func (db *DB) ApplyFeedbackChanges(ctx context.Context, TAUserID int64, studentWorkID int, comments []models.PRReviewCommentResponse) ([]models.GradeEvent, error) {
+	events := []models.GradeEvent{}
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		for _, comment := range comments {
+			beforeScore, err := getWorkScore(ctx, tx, studentWorkID)
+			if err != nil {
+				return err
+			}
+
+			event := models.GradeEvent{
+				StudentWorkID:     studentWorkID,
+				FeedbackCommentID: comment.FeedbackCommentID,
+				ActorUserID:       TAUserID,
+				BeforeScore:       beforeScore,
+				Reason:            comment.Reason,
+			}
+			switch comment.Action {
+			case "", models.PRReviewCommentActionCreate:
+				event.EventType = models.GradeEventFeedbackCreated
+				var createdID int
+				if comment.RubricItemID == nil {
+					// create new rubric item and then attach
+					createdID, err = createFeedbackComment(ctx, tx, TAUserID, studentWorkID, comment)
+				} else {
+					// attach rubric item
+					createdID, err = createFeedbackCommentFromRubricItem(ctx, tx, TAUserID, studentWorkID, comment)
+				}
+				event.FeedbackCommentID = &createdID
+			case models.PRReviewCommentActionEdit:
+				event.EventType = models.GradeEventFeedbackEdited
+				err = updateFeedbackComment(ctx, tx, studentWorkID, comment)
+			case models.PRReviewCommentActionDelete:
+				if comment.FeedbackCommentID == nil {
+					return errors.New("no feedback comment id given")
+				}
+				event.EventType = models.GradeEventFeedbackDeleted
+				err = deleteFeedbackComment(ctx, tx, studentWorkID, *comment.FeedbackCommentID)
+			default:
+				return fmt.Errorf("unknown feedback action %q", comment.Action)
+			}
+			if err != nil {
+				return err
+			}
+
+			event, err = createGradeEvent(ctx, tx, event)
+			if err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+		return nil
+	})
+	if err == errs.EmptyResult() {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return events, nil
+}
+
+// Gets the grade history of a student work, oldest first
+func (db *DB) GetGradeEventsByWork(ctx context.Context, studentWorkID int) ([]models.GradeEvent, error) {
+	rows, err := db.connPool.Query(ctx, `
+	SELECT ge.id, ge.student_work_id, ge.feedback_comment_id, ge.actor_user_id, u.github_username AS actor_github_username,
+		ge.event_type, ge.before_score, ge.after_score, ge.reason, ge.created_at
+	FROM grade_events ge
+	JOIN users u ON u.id = ge.actor_user_id
+	WHERE ge.student_work_id = $1
+	ORDER BY ge.created_at, ge.id`, studentWorkID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	defer rows.Close()
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.GradeEvent])
+}
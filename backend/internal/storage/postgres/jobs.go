@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// The columns of a queued row's job, for queries joining the queue table with jobs as j
+const jobColumns = `j.status, j.attempts, j.error, j.updated_at`
+
+// Gets up to limit queued rows whose jobs are still to be attempted, oldest first. from is the queue table aliased
+// as q, along with any tables joined for the rest of the columns.
+func getPendingJobs[T any](ctx context.Context, db *DB, columns string, from string, limit int) ([]T, error) {
+	rows, err := db.connPool.Query(ctx, fmt.Sprintf(`
+		SELECT %s, %s
+		FROM %s
+		JOIN jobs j ON j.id = q.job_id
+		WHERE j.status = $1 OR (j.status = $2 AND j.attempts < $3)
+		ORDER BY j.updated_at, j.id
+		LIMIT $4`, columns, jobColumns, from),
+		models.JobPending, models.JobFailed, models.MaxJobAttempts, limit)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[T])
+}
+
+// Records the outcome of an attempted job
+func completeJob(ctx context.Context, tx pgx.Tx, job models.Job) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE jobs
+		SET status = $1, error = $2, attempts = attempts + 1, updated_at = (NOW() AT TIME ZONE 'UTC')
+		WHERE id = $3`, job.Status, job.Error, job.JobID)
+	return err
+}
+
+// Records the outcome of an attempted job whose queue row has nothing else to save
+func (db *DB) CompleteJob(ctx context.Context, job models.Job) error {
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		return completeJob(ctx, tx, job)
+	})
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+// Takes the lock that lets only one server run a scheduled job at a time, returning false if another server holds
+// it. The lock lives on a connection kept aside until the returned function releases it, so a server that dies
+// while holding it lets it go.
+func (db *DB) TryLockJob(ctx context.Context, name string) (func(), bool, error) {
+	conn, err := db.connPool.Acquire(ctx)
+	if err != nil {
+		return nil, false, errs.NewDBError(err)
+	}
+
+	var locked bool
+	err = conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, name).Scan(&locked)
+	if err != nil {
+		conn.Release()
+		return nil, false, errs.NewDBError(err)
+	}
+	if !locked {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		_, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, name)
+		if err != nil {
+			// closing the connection is the only other way to let the lock go
+			_ = conn.Hijack().Close(context.Background())
+			return
+		}
+		conn.Release()
+	}
+	return unlock, true, nil
+}
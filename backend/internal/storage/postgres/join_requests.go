@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// Gets how a classroom handles users joining through its links, with every rule off if it was never set
+func (db *DB) GetJoinSettings(ctx context.Context, classroomID int64) (models.JoinSettings, error) {
+	rows, err := db.connPool.Query(ctx, `SELECT * FROM classroom_join_settings WHERE classroom_id = $1`, classroomID)
+	if err != nil {
+		return models.JoinSettings{}, errs.NewDBError(err)
+	}
+
+	settings, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.JoinSettings])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.JoinSettings{ClassroomID: classroomID, AutoApproveEmailDomains: []string{}}, nil
+	}
+	if err != nil {
+		return models.JoinSettings{}, errs.NewDBError(err)
+	}
+
+	return settings, nil
+}
+
+func (db *DB) UpdateJoinSettings(ctx context.Context, settings models.JoinSettings) (models.JoinSettings, error) {
+	rows, err := db.connPool.Query(ctx, `
+		INSERT INTO classroom_join_settings (classroom_id, require_approval, auto_approve_roster, auto_approve_email_domains)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (classroom_id) DO UPDATE
+		SET require_approval = EXCLUDED.require_approval,
+			auto_approve_roster = EXCLUDED.auto_approve_roster,
+			auto_approve_email_domains = EXCLUDED.auto_approve_email_domains,
+			updated_at = (NOW() AT TIME ZONE 'UTC')
+		RETURNING *`, settings.ClassroomID, settings.RequireApproval, settings.AutoApproveRoster, settings.AutoApproveEmailDomains)
+	if err != nil {
+		return models.JoinSettings{}, errs.NewDBError(err)
+	}
+
+	settings, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[models.JoinSettings])
+	if err != nil {
+		return models.JoinSettings{}, errs.NewDBError(err)
+	}
+
+	return settings, nil
+}
+
+// Whether the classroom member was imported from a roster
+func (db *DB) IsOnClassroomRoster(ctx context.Context, classroomID int64, userID int64) (bool, error) {
+	var onRoster bool
+	err := db.connPool.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM classroom_membership WHERE classroom_id = $1 AND user_id = $2 AND on_roster)`,
+		classroomID, userID).Scan(&onRoster)
+	if err != nil {
+		return false, errs.NewDBError(err)
+	}
+
+	return onRoster, nil
+}
+
+// Gets the classroom's members waiting in the requested state, oldest request first, along with the status of
+// any approval still being sent
+func (db *DB) GetJoinRequests(ctx context.Context, classroomID int64, filter models.JoinRequestFilter) ([]models.JoinRequest, error) {
+	rows, err := db.connPool.Query(ctx, `
+		SELECT u.id, u.first_name, u.last_name, u.github_username, u.github_user_id, cm.classroom_role, cm.external_id,
+			cm.created_at AS requested_at, ja.status AS approval_status
+		FROM classroom_membership cm
+		JOIN users u ON u.id = cm.user_id
+		LEFT JOIN LATERAL (
+			SELECT j.status FROM join_approvals a
+			JOIN jobs j ON j.id = a.job_id
+			WHERE a.classroom_id = cm.classroom_id AND a.user_id = cm.user_id AND j.status != $3
+			ORDER BY a.id DESC LIMIT 1
+		) ja ON TRUE
+		WHERE cm.classroom_id = $1 AND cm.status = $2
+			AND ($4::USER_ROLE IS NULL OR cm.classroom_role = $4)
+			AND ($5 = '' OR u.github_username ILIKE '%' || $5 || '%'
+				OR (COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')) ILIKE '%' || $5 || '%')
+			AND ($6::BOOLEAN IS NULL OR cm.on_roster = $6)
+			AND ($7::BOOLEAN IS NULL OR (ja.status IS NOT NULL) = $7)
+		ORDER BY cm.created_at, u.id`,
+		classroomID, models.UserStatusRequested, models.JobSucceeded,
+		filter.Role, filter.Search, filter.OnRoster, filter.Approved)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.JoinRequest])
+}
+
+// Queues an approval for each of the users still in the requested state who isn't already queued
+func (db *DB) QueueJoinApprovals(ctx context.Context, classroomID int64, userIDs []int64, approvedBy int64) ([]models.JoinApproval, error) {
+	var approvals []models.JoinApproval
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			INSERT INTO join_approvals (job_id, classroom_id, user_id, approved_by)
+			SELECT create_job($8), cm.classroom_id, cm.user_id, $3 FROM classroom_membership cm
+			WHERE cm.classroom_id = $1 AND cm.user_id = ANY($2) AND cm.status = $4
+				AND NOT EXISTS (SELECT 1 FROM join_approvals ja
+					JOIN jobs j ON j.id = ja.job_id
+					WHERE ja.classroom_id = cm.classroom_id AND ja.user_id = cm.user_id
+						AND (j.status = $5 OR (j.status = $6 AND j.attempts < $7)))
+			RETURNING id`,
+			classroomID, userIDs, approvedBy, models.UserStatusRequested,
+			models.JobPending, models.JobFailed, models.MaxJobAttempts, models.JobKindJoinApproval)
+		if err != nil {
+			return err
+		}
+		approvalIDs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+		if err != nil {
+			return err
+		}
+
+		rows, err = tx.Query(ctx, `
+			SELECT ja.*, u.github_username, `+jobColumns+`
+			FROM join_approvals ja
+			JOIN users u ON u.id = ja.user_id
+			JOIN jobs j ON j.id = ja.job_id
+			WHERE ja.id = ANY($1)
+			ORDER BY u.github_username`, approvalIDs)
+		if err != nil {
+			return err
+		}
+		approvals, err = pgx.CollectRows(rows, pgx.RowToStructByName[models.JoinApproval])
+		return err
+	})
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return approvals, nil
+}
+
+// Gets the approvals made in a classroom, newest first
+func (db *DB) GetJoinApprovals(ctx context.Context, classroomID int64) ([]models.JoinApproval, error) {
+	rows, err := db.connPool.Query(ctx, `
+		SELECT ja.*, u.github_username, `+jobColumns+`
+		FROM join_approvals ja
+		JOIN users u ON u.id = ja.user_id
+		JOIN jobs j ON j.id = ja.job_id
+		WHERE ja.classroom_id = $1
+		ORDER BY ja.created_at DESC, ja.id DESC`, classroomID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.JoinApproval])
+}
+
+// Gets the approvals still to be sent, oldest first
+func (db *DB) GetPendingJoinApprovals(ctx context.Context, limit int) ([]models.JoinApproval, error) {
+	return getPendingJobs[models.JoinApproval](ctx, db, `q.*, u.github_username`, `
+		join_approvals q
+		JOIN users u ON u.id = q.user_id`, limit)
+}
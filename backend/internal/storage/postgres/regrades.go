@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const regradeRequestColumns = `rr.id, rr.feedback_comment_id, fc.student_work_id, rr.regrade_state, rr.student_comment,
+	rr.requested_by, rr.resolved_by, rr.resolution, rr.resolved_at, rr.created_at`
+
+// Requests a regrade of a feedback comment on a student work. Comments with a regrade still open can't be requested
+// again until it is resolved.
+func (db *DB) CreateRegradeRequest(ctx context.Context, studentWorkID int, feedbackCommentID int, requestedBy int64, comment string) (models.RegradeRequest, error) {
+	rows, err := db.connPool.Query(ctx, `
+		WITH created AS (
+			INSERT INTO regrade_requests (feedback_comment_id, regrade_state, student_comment, requested_by)
+			SELECT fc.id, $3, $4, $5 FROM feedback_comment fc
+			WHERE fc.id = $1 AND fc.student_work_id = $2 AND fc.deleted = FALSE
+				AND NOT EXISTS (SELECT 1 FROM regrade_requests
+					WHERE feedback_comment_id = fc.id AND regrade_state = $3)
+			RETURNING *
+		)
+		SELECT `+regradeRequestColumns+`
+		FROM created rr
+		JOIN feedback_comment fc ON fc.id = rr.feedback_comment_id`,
+		feedbackCommentID, studentWorkID, models.RegradeRequested, comment, requestedBy)
+	if err != nil {
+		return models.RegradeRequest{}, errs.NewDBError(err)
+	}
+
+	request, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RegradeRequest])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.RegradeRequest{}, errs.EmptyResult()
+	}
+	if err != nil {
+		return models.RegradeRequest{}, errs.NewDBError(err)
+	}
+
+	return request, nil
+}
+
+// Gets the regrade requests on a student work's feedback, oldest first
+func (db *DB) GetRegradeRequestsByWork(ctx context.Context, studentWorkID int) ([]models.RegradeRequest, error) {
+	rows, err := db.connPool.Query(ctx, `
+		SELECT `+regradeRequestColumns+`
+		FROM regrade_requests rr
+		JOIN feedback_comment fc ON fc.id = rr.feedback_comment_id
+		WHERE fc.student_work_id = $1
+		ORDER BY rr.created_at, rr.id`, studentWorkID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.RegradeRequest])
+}
+
+// Resolves an open regrade request on a student work, giving its feedback comment new points if any are given, and
+// appends the resolution to the work's grade history. Either all of it is saved or none of it is.
+func (db *DB) ResolveRegradeRequest(ctx context.Context, studentWorkID int, regradeID int, resolverUserID int64, resolution models.RegradeResolutionBody) (models.RegradeRequest, models.GradeEvent, error) {
+	var request models.RegradeRequest
+	var event models.GradeEvent
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			SELECT `+regradeRequestColumns+`
+			FROM regrade_requests rr
+			JOIN feedback_comment fc ON fc.id = rr.feedback_comment_id
+			WHERE rr.id = $1 AND fc.student_work_id = $2 AND rr.regrade_state = $3
+			FOR UPDATE OF rr`, regradeID, studentWorkID, models.RegradeRequested)
+		if err != nil {
+			return err
+		}
+		request, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RegradeRequest])
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.EmptyResult()
+		}
+		if err != nil {
+			return err
+		}
+
+		beforeScore, err := getWorkScore(ctx, tx, studentWorkID)
+		if err != nil {
+			return err
+		}
+
+		// regraded points are given through a new ad-hoc rubric item, like an edited comment
+		if resolution.Points != nil {
+			_, err = tx.Exec(ctx, `
+				WITH ri AS (
+					INSERT INTO rubric_items (point_value, explanation)
+					SELECT $1, COALESCE($2, ri.explanation)
+					FROM feedback_comment fc JOIN rubric_items ri ON ri.id = fc.rubric_item_id
+					WHERE fc.id = $3
+					RETURNING id
+				)
+				UPDATE feedback_comment SET rubric_item_id = (SELECT id FROM ri) WHERE id = $3`,
+				*resolution.Points, resolution.Body, request.FeedbackCommentID)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tx.QueryRow(ctx, `
+			UPDATE regrade_requests
+			SET regrade_state = $1, resolved_by = $2, resolution = $3, resolved_at = (NOW() AT TIME ZONE 'UTC')
+			WHERE id = $4
+			RETURNING regrade_state, resolved_by, resolution, resolved_at`,
+			models.RegradeFinalized, resolverUserID, resolution.Reason, request.ID,
+		).Scan(&request.State, &request.ResolvedBy, &request.Resolution, &request.ResolvedAt)
+		if err != nil {
+			return err
+		}
+
+		feedbackCommentID := request.FeedbackCommentID
+		event, err = createGradeEvent(ctx, tx, models.GradeEvent{
+			StudentWorkID:     studentWorkID,
+			FeedbackCommentID: &feedbackCommentID,
+			ActorUserID:       resolverUserID,
+			EventType:         models.GradeEventRegradeResolved,
+			BeforeScore:       beforeScore,
+			Reason:            &resolution.Reason,
+		})
+		return err
+	})
+	if err == errs.EmptyResult() {
+		return models.RegradeRequest{}, models.GradeEvent{}, err
+	}
+	if err != nil {
+		return models.RegradeRequest{}, models.GradeEvent{}, errs.NewDBError(err)
+	}
+
+	return request, event, nil
+}
@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// Queues a repository provision for every active student in the classroom who has no work on the assignment
+// and isn't already queued
+func (db *DB) QueueRepoProvisions(ctx context.Context, assignmentID int64, classroomID int64) ([]models.RepoProvision, error) {
+	var provisions []models.RepoProvision
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			INSERT INTO repo_provisions (job_id, assignment_outline_id, user_id)
+			SELECT create_job($5), $1, cm.user_id FROM classroom_membership cm
+			WHERE cm.classroom_id = $2 AND cm.classroom_role = $3 AND cm.status = $4
+				AND NOT EXISTS (SELECT 1 FROM work_contributors wc
+					JOIN student_works sw ON sw.id = wc.student_work_id
+					WHERE wc.user_id = cm.user_id AND sw.assignment_outline_id = $1)
+				AND NOT EXISTS (SELECT 1 FROM repo_provisions rp
+					WHERE rp.assignment_outline_id = $1 AND rp.user_id = cm.user_id)
+			ON CONFLICT (assignment_outline_id, user_id) DO NOTHING
+			RETURNING id`, assignmentID, classroomID, models.Student, models.UserStatusActive, models.JobKindRepoProvision)
+		if err != nil {
+			return err
+		}
+		provisionIDs, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return err
+		}
+
+		rows, err = tx.Query(ctx, `
+			SELECT rp.*, u.github_username, u.github_user_id, `+jobColumns+`
+			FROM repo_provisions rp
+			JOIN users u ON u.id = rp.user_id
+			JOIN jobs j ON j.id = rp.job_id
+			WHERE rp.id = ANY($1)
+			ORDER BY u.github_username`, provisionIDs)
+		if err != nil {
+			return err
+		}
+		provisions, err = pgx.CollectRows(rows, pgx.RowToStructByName[models.RepoProvision])
+		return err
+	})
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return provisions, nil
+}
+
+// Gets the provisions still to be attempted on released assignments, oldest first
+func (db *DB) GetPendingRepoProvisions(ctx context.Context, limit int) ([]models.RepoProvision, error) {
+	return getPendingJobs[models.RepoProvision](ctx, db, `q.*, u.github_username, u.github_user_id`, `
+		repo_provisions q
+		JOIN users u ON u.id = q.user_id
+		JOIN assignment_outlines ao ON ao.id = q.assignment_outline_id AND ao.released AND ao.archived_at IS NULL`, limit)
+}
+
+// Records the outcome of an attempted provision, along with the repository name chosen for it
+func (db *DB) CompleteRepoProvision(ctx context.Context, provision models.RepoProvision) error {
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `UPDATE repo_provisions SET repo_name = $1 WHERE id = $2`, provision.RepoName, provision.ID)
+		if err != nil {
+			return err
+		}
+		return completeJob(ctx, tx, provision.Job)
+	})
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+// Gets the provisions of an assignment, by student
+func (db *DB) GetRepoProvisionsByAssignment(ctx context.Context, assignmentID int64) ([]models.RepoProvision, error) {
+	rows, err := db.connPool.Query(ctx, `
+		SELECT rp.*, u.github_username, u.github_user_id, `+jobColumns+`
+		FROM repo_provisions rp
+		JOIN users u ON u.id = rp.user_id
+		JOIN jobs j ON j.id = rp.job_id
+		WHERE rp.assignment_outline_id = $1
+		ORDER BY u.github_username`, assignmentID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.RepoProvision])
+}
+
+// Requeues the failed provisions of an assignment with a fresh set of attempts
+func (db *DB) RetryRepoProvisions(ctx context.Context, assignmentID int64) (int64, error) {
+	tag, err := db.connPool.Exec(ctx, `
+		UPDATE jobs
+		SET status = $1, attempts = 0, error = NULL, updated_at = (NOW() AT TIME ZONE 'UTC')
+		FROM repo_provisions rp
+		WHERE rp.job_id = jobs.id AND rp.assignment_outline_id = $2 AND jobs.status = $3`, models.JobPending, assignmentID, models.JobFailed)
+	if err != nil {
+		return 0, errs.NewDBError(err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// Records a roster import and queues each of its rows. Rows given with an outcome, e.g. skipped duplicates, keep it
+// and are only marked done once their job runs.
+func (db *DB) QueueRosterImport(ctx context.Context, rosterImport models.RosterImport, rows []models.RosterImportRow) (models.RosterImport, []models.RosterImportRow, error) {
+	var queued []models.RosterImportRow
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		created, err := tx.Query(ctx, `
+			INSERT INTO roster_imports (classroom_id, dry_run, created_by)
+			VALUES ($1, $2, $3)
+			RETURNING *`, rosterImport.ClassroomID, rosterImport.DryRun, rosterImport.CreatedBy)
+		if err != nil {
+			return err
+		}
+		rosterImport, err = pgx.CollectOneRow(created, pgx.RowToStructByName[models.RosterImport])
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			_, err = tx.Exec(ctx, `
+				INSERT INTO roster_import_rows (job_id, import_id, entry, result)
+				VALUES (create_job($1), $2, $3, $4)`, models.JobKindRosterImportRow, rosterImport.ID, row.Entry, row.Result)
+			if err != nil {
+				return err
+			}
+		}
+
+		queued, err = getRosterImportRows(ctx, tx, rosterImport.ID)
+		return err
+	})
+	if err != nil {
+		return models.RosterImport{}, nil, errs.NewDBError(err)
+	}
+
+	return rosterImport, queued, nil
+}
+
+func (db *DB) GetRosterImportByID(ctx context.Context, importID int) (models.RosterImport, error) {
+	rows, err := db.connPool.Query(ctx, `SELECT * FROM roster_imports WHERE id = $1`, importID)
+	if err != nil {
+		return models.RosterImport{}, errs.NewDBError(err)
+	}
+
+	rosterImport, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RosterImport])
+	if err != nil {
+		return models.RosterImport{}, errs.NewDBError(err)
+	}
+
+	return rosterImport, nil
+}
+
+func (db *DB) GetRosterImportRows(ctx context.Context, importID int) ([]models.RosterImportRow, error) {
+	rows, err := getRosterImportRows(ctx, db.connPool, importID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return rows, nil
+}
+
+func getRosterImportRows(ctx context.Context, q querier, importID int) ([]models.RosterImportRow, error) {
+	rows, err := q.Query(ctx, `
+		SELECT r.*, `+jobColumns+`
+		FROM roster_import_rows r
+		JOIN jobs j ON j.id = r.job_id
+		WHERE r.import_id = $1
+		ORDER BY r.id`, importID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.RosterImportRow])
+}
+
+// Gets the roster rows still to be imported, oldest first
+func (db *DB) GetPendingRosterImportRows(ctx context.Context, limit int) ([]models.RosterImportRow, error) {
+	return getPendingJobs[models.RosterImportRow](ctx, db, `q.*`, `roster_import_rows q`, limit)
+}
+
+// Records the outcome of an attempted roster row import
+func (db *DB) CompleteRosterImportRow(ctx context.Context, row models.RosterImportRow) error {
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `UPDATE roster_import_rows SET result = $1 WHERE id = $2`, row.Result, row.ID)
+		if err != nil {
+			return err
+		}
+		return completeJob(ctx, tx, row.Job)
+	})
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
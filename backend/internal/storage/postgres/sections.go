@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// Restricts a query on student works to those with a contributor in the section bound to the given
+// parameter, or leaves it unrestricted if the parameter is NULL
+func sectionWorkFilter(param string, workIDColumn string) string {
+	return fmt.Sprintf(`(%[1]s::int IS NULL OR %[2]s IN (
+		SELECT swc.student_work_id FROM work_contributors swc
+		JOIN section_membership ssm ON ssm.user_id = swc.user_id
+		WHERE ssm.section_id = %[1]s))`, param, workIDColumn)
+}
+
+func (db *DB) CreateSection(ctx context.Context, section models.Section) (models.Section, error) {
+	rows, err := db.connPool.Query(ctx, `
+		INSERT INTO sections (classroom_id, name)
+		VALUES ($1, $2)
+		RETURNING *`, section.ClassroomID, section.Name)
+	if err != nil {
+		return models.Section{}, errs.NewDBError(err)
+	}
+
+	createdSection, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Section])
+	if err != nil {
+		return models.Section{}, errs.NewDBError(err)
+	}
+
+	return createdSection, nil
+}
+
+// Records the GitHub team mirroring a section
+func (db *DB) SetSectionTeam(ctx context.Context, sectionID int64, teamID int64, teamName string) (models.Section, error) {
+	rows, err := db.connPool.Query(ctx, `
+		UPDATE sections SET team_id = $1, team_name = $2
+		WHERE id = $3
+		RETURNING *`, teamID, teamName, sectionID)
+	if err != nil {
+		return models.Section{}, errs.NewDBError(err)
+	}
+
+	section, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Section])
+	if err != nil {
+		return models.Section{}, errs.NewDBError(err)
+	}
+
+	return section, nil
+}
+
+func (db *DB) GetSectionsInClassroom(ctx context.Context, classroomID int64) ([]models.Section, error) {
+	rows, err := db.connPool.Query(ctx, `SELECT * FROM sections WHERE classroom_id = $1 ORDER BY name`, classroomID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.Section])
+}
+
+func (db *DB) GetSectionByID(ctx context.Context, sectionID int64) (models.Section, error) {
+	rows, err := db.connPool.Query(ctx, `SELECT * FROM sections WHERE id = $1`, sectionID)
+	if err != nil {
+		return models.Section{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Section])
+}
+
+// Gets the sections of a classroom that a user belongs to
+func (db *DB) GetUserSectionsInClassroom(ctx context.Context, classroomID int64, userID int64) ([]models.Section, error) {
+	rows, err := db.connPool.Query(ctx, `
+		SELECT s.* FROM sections s
+		JOIN section_membership sm ON sm.section_id = s.id
+		WHERE s.classroom_id = $1 AND sm.user_id = $2
+		ORDER BY s.name`, classroomID, userID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.Section])
+}
+
+// Deletes a section along with its memberships
+func (db *DB) DeleteSection(ctx context.Context, sectionID int64) error {
+	_, err := db.connPool.Exec(ctx, `DELETE FROM sections WHERE id = $1`, sectionID)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+// Gets the members of a section with their role in its classroom, TAs first
+func (db *DB) GetSectionMembers(ctx context.Context, sectionID int64) ([]models.SectionMember, error) {
+	rows, err := db.connPool.Query(ctx, `
+		SELECT u.id, u.first_name, u.last_name, u.github_username, u.github_user_id, sm.section_id, cm.classroom_role
+		FROM section_membership sm
+		JOIN sections s ON s.id = sm.section_id
+		JOIN users u ON u.id = sm.user_id
+		JOIN classroom_membership cm ON cm.user_id = sm.user_id AND cm.classroom_id = s.classroom_id
+		WHERE sm.section_id = $1
+		ORDER BY cm.classroom_role = 'STUDENT', u.last_name, u.first_name`, sectionID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.SectionMember, error) {
+		var member models.SectionMember
+		err := row.Scan(
+			&member.ID,
+			&member.FirstName,
+			&member.LastName,
+			&member.GithubUsername,
+			&member.GithubUserID,
+			&member.SectionID,
+			&member.Role,
+		)
+		return member, err
+	})
+}
+
+// Adds a classroom member to a section. Students can only be in one section of a classroom, which the schema can't
+// express because roles live in classroom_membership, so the member's classroom membership is locked while checking.
+func (db *DB) AddSectionMember(ctx context.Context, sectionID int64, userID int64) error {
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		var inOtherSection bool
+		err := tx.QueryRow(ctx, `
+			SELECT cm.classroom_role = 'STUDENT' AND EXISTS (
+				SELECT 1 FROM section_membership sm
+				JOIN sections other ON other.id = sm.section_id
+				WHERE sm.user_id = cm.user_id AND other.classroom_id = s.classroom_id AND other.id <> s.id)
+			FROM sections s
+			JOIN classroom_membership cm ON cm.classroom_id = s.classroom_id AND cm.user_id = $2
+			WHERE s.id = $1
+			FOR UPDATE OF cm`, sectionID, userID).Scan(&inOtherSection)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.EmptyResult()
+		}
+		if err != nil {
+			return err
+		}
+		if inOtherSection {
+			return errs.ConstraintViolation()
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO section_membership (section_id, user_id)
+			VALUES ($1, $2)
+			ON CONFLICT (section_id, user_id) DO NOTHING`, sectionID, userID)
+		return err
+	})
+	if err == errs.EmptyResult() || err == errs.ConstraintViolation() {
+		return err
+	}
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+func (db *DB) RemoveSectionMember(ctx context.Context, sectionID int64, userID int64) error {
+	_, err := db.connPool.Exec(ctx, `DELETE FROM section_membership WHERE section_id = $1 AND user_id = $2`, sectionID, userID)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
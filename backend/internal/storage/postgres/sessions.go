@@ -3,38 +3,149 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/CamPlume1/khoury-classroom/internal/encryption"
 	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/jackc/pgx/v5"
 )
 
+// Tokens are encrypted with a data key per session, bound to the session and column they belong to
+func sessionTokenAAD(githubUserID int64, column string) string {
+	return fmt.Sprintf("sessions:%d:%s", githubUserID, column)
+}
+
+// Encrypts a session's tokens under a new data key
+func (db *DB) sealSessionTokens(githubUserID int64, accessToken string, refreshToken string) (string, string, encryption.SealedDataKey, error) {
+	dataKey, sealedKey, err := db.sessionKeys.NewDataKey()
+	if err != nil {
+		return "", "", encryption.SealedDataKey{}, err
+	}
+
+	encryptedAccessToken, err := encryption.Encrypt(dataKey, accessToken, sessionTokenAAD(githubUserID, "access_token"))
+	if err != nil {
+		return "", "", encryption.SealedDataKey{}, err
+	}
+	encryptedRefreshToken, err := encryption.Encrypt(dataKey, refreshToken, sessionTokenAAD(githubUserID, "refresh_token"))
+	if err != nil {
+		return "", "", encryption.SealedDataKey{}, err
+	}
+
+	return encryptedAccessToken, encryptedRefreshToken, sealedKey, nil
+}
+
 func (db *DB) CreateSession(ctx context.Context, sessionData models.Session) error {
-	_, err := db.connPool.Exec(ctx,
-		`INSERT INTO sessions (github_user_id, access_token, token_type, refresh_token, expires_in)
-        VALUES ($1, $2, $3, $4, $5)
+	return db.saveSession(ctx, db.connPool, sessionData)
+}
+
+func (db *DB) saveSession(ctx context.Context, q querier, sessionData models.Session) error {
+	accessToken, refreshToken, sealedKey, err := db.sealSessionTokens(sessionData.GitHubUserID, sessionData.AccessToken, sessionData.RefreshToken)
+	if err != nil {
+		return fmt.Errorf("error encrypting session %d: %w", sessionData.GitHubUserID, err)
+	}
+
+	_, err = q.Exec(ctx,
+		`INSERT INTO sessions (github_user_id, access_token, token_type, refresh_token, expires_at, refresh_token_expires_at, key_id, encrypted_data_key)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
         ON CONFLICT (github_user_id) DO UPDATE
         SET access_token = EXCLUDED.access_token,
             token_type = EXCLUDED.token_type,
             refresh_token = EXCLUDED.refresh_token,
-            expires_in = EXCLUDED.expires_in`,
+            expires_at = EXCLUDED.expires_at,
+            refresh_token_expires_at = EXCLUDED.refresh_token_expires_at,
+            key_id = EXCLUDED.key_id,
+            encrypted_data_key = EXCLUDED.encrypted_data_key,
+            updated_at = (NOW() AT TIME ZONE 'UTC')`,
 		sessionData.GitHubUserID,
-		sessionData.AccessToken,
+		accessToken,
 		sessionData.TokenType,
-		sessionData.RefreshToken,
-		sessionData.ExpiresIn,
+		refreshToken,
+		sessionData.ExpiresAt,
+		sessionData.RefreshTokenExpiresAt,
+		sealedKey.KeyID,
+		sealedKey.DataKey,
 	)
 	if err != nil {
-		fmt.Println("Error while creating sessions", err)
-		return err
+		return fmt.Errorf("error saving session %d: %w", sessionData.GitHubUserID, err)
 	}
 
 	return nil
 }
 
 func (db *DB) GetSession(ctx context.Context, githubuserid int64) (models.Session, error) {
-	row := db.connPool.QueryRow(ctx, "SELECT github_user_id, access_token, token_type, refresh_token, expires_in FROM sessions WHERE github_user_id = $1", githubuserid)
+	return db.getSession(ctx, db.connPool, githubuserid, "")
+}
+
+// Reads and decrypts a session, with a locking clause such as FOR UPDATE appended to the query if one is given
+func (db *DB) getSession(ctx context.Context, q querier, githubuserid int64, lock string) (models.Session, error) {
+	row := q.QueryRow(ctx, "SELECT github_user_id, access_token, token_type, refresh_token, expires_at, refresh_token_expires_at, key_id, encrypted_data_key FROM sessions WHERE github_user_id = $1 "+lock, githubuserid)
+
+	var session models.Session
+	var keyID, encryptedDataKey *string
+	err := row.Scan(&session.GitHubUserID, &session.AccessToken, &session.TokenType, &session.RefreshToken, &session.ExpiresAt, &session.RefreshTokenExpiresAt, &keyID, &encryptedDataKey)
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	// sessions stored before encryption are encrypted now, or thrown away once plaintext tokens are no longer accepted
+	if keyID == nil || encryptedDataKey == nil {
+		if !time.Now().Before(db.sessionPlaintextCutoff) {
+			_, err = q.Exec(ctx, "DELETE FROM sessions WHERE github_user_id = $1 AND key_id IS NULL", githubuserid)
+			if err != nil {
+				return models.Session{}, err
+			}
+			return models.Session{}, fmt.Errorf("session %d holds plaintext tokens past the cutoff", githubuserid)
+		}
+
+		_, err = db.encryptPlaintextSession(ctx, q, storedSessionKey{
+			GitHubUserID: githubuserid,
+			AccessToken:  session.AccessToken,
+			RefreshToken: session.RefreshToken,
+		})
+		if err != nil {
+			return models.Session{}, err
+		}
+		return session, nil
+	}
+
+	dataKey, err := db.sessionKeys.OpenDataKey(encryption.SealedDataKey{KeyID: *keyID, DataKey: *encryptedDataKey})
+	if err != nil {
+		return models.Session{}, fmt.Errorf("error decrypting data key for session %d: %w", githubuserid, err)
+	}
+	session.AccessToken, err = encryption.Decrypt(dataKey, session.AccessToken, sessionTokenAAD(githubuserid, "access_token"))
+	if err != nil {
+		return models.Session{}, fmt.Errorf("error decrypting access token for session %d: %w", githubuserid, err)
+	}
+	session.RefreshToken, err = encryption.Decrypt(dataKey, session.RefreshToken, sessionTokenAAD(githubuserid, "refresh_token"))
+	if err != nil {
+		return models.Session{}, fmt.Errorf("error decrypting refresh token for session %d: %w", githubuserid, err)
+	}
+
+	return session, nil
+}
 
+// Refreshes a session's tokens while holding a lock on its row, so only one request at a time refreshes a user's
+// session across every server, as GitHub rotates the refresh token on each refresh. The refresh is skipped if
+// another request refreshed the session while this one waited for the lock.
+func (db *DB) RefreshSession(ctx context.Context, githubUserID int64, refresh func(models.Session) (models.Session, error)) (models.Session, error) {
 	var session models.Session
-	err := row.Scan(&session.GitHubUserID, &session.AccessToken, &session.TokenType, &session.RefreshToken, &session.ExpiresIn)
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		var err error
+		session, err = db.getSession(ctx, tx, githubUserID, "FOR UPDATE")
+		if err != nil {
+			return err
+		}
+		token := session.CreateToken()
+		if token.Valid() {
+			return nil
+		}
+
+		session, err = refresh(session)
+		if err != nil {
+			return err
+		}
+		return db.saveSession(ctx, tx, session)
+	})
 	if err != nil {
 		return models.Session{}, err
 	}
@@ -42,6 +153,20 @@ func (db *DB) GetSession(ctx context.Context, githubuserid int64) (models.Sessio
 	return session, nil
 }
 
+// Whether the user has a session whose refresh token hasn't expired, so their GitHub token can still be renewed
+func (db *DB) IsSessionActive(ctx context.Context, githubUserID int64) (bool, error) {
+	var active bool
+	err := db.connPool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM sessions WHERE github_user_id = $1
+            AND (refresh_token_expires_at IS NULL OR refresh_token_expires_at > (NOW() AT TIME ZONE 'UTC')))`,
+		githubUserID).Scan(&active)
+	if err != nil {
+		return false, err
+	}
+
+	return active, nil
+}
+
 func (db *DB) DeleteSession(ctx context.Context, githubuserid int64) error {
 	_, err := db.connPool.Exec(ctx, "DELETE FROM sessions WHERE github_user_id = $1", githubuserid)
 	if err != nil {
@@ -50,3 +175,103 @@ func (db *DB) DeleteSession(ctx context.Context, githubuserid int64) error {
 
 	return nil
 }
+
+type storedSessionKey struct {
+	GitHubUserID     int64   `db:"github_user_id"`
+	AccessToken      string  `db:"access_token"`
+	RefreshToken     string  `db:"refresh_token"`
+	KeyID            *string `db:"key_id"`
+	EncryptedDataKey *string `db:"encrypted_data_key"`
+}
+
+// Brings every session under the active master key, encrypting plaintext sessions and re-encrypting the data keys
+// of sessions sealed with an older key. Tokens already encrypted are left untouched. Returns the number of sessions
+// encrypted and rewrapped.
+func (db *DB) RotateSessionKeys(ctx context.Context) (int, int, error) {
+	rows, err := db.connPool.Query(ctx,
+		`SELECT github_user_id, access_token, refresh_token, key_id, encrypted_data_key
+        FROM sessions
+        WHERE key_id IS DISTINCT FROM $1 OR encrypted_data_key IS NULL`,
+		db.sessionKeys.ActiveKeyID())
+	if err != nil {
+		return 0, 0, err
+	}
+	sessions, err := pgx.CollectRows(rows, pgx.RowToStructByName[storedSessionKey])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	encrypted, rewrapped := 0, 0
+	for _, stored := range sessions {
+		if stored.KeyID == nil || stored.EncryptedDataKey == nil {
+			count, err := db.encryptPlaintextSession(ctx, db.connPool, stored)
+			if err != nil {
+				return encrypted, rewrapped, err
+			}
+			encrypted += count
+			continue
+		}
+
+		sealedKey, err := db.sessionKeys.Rewrap(encryption.SealedDataKey{KeyID: *stored.KeyID, DataKey: *stored.EncryptedDataKey})
+		if err != nil {
+			return encrypted, rewrapped, fmt.Errorf("error rewrapping data key for session %d: %w", stored.GitHubUserID, err)
+		}
+
+		tag, err := db.connPool.Exec(ctx,
+			`UPDATE sessions
+            SET key_id = $2, encrypted_data_key = $3
+            WHERE github_user_id = $1 AND key_id = $4 AND encrypted_data_key = $5`,
+			stored.GitHubUserID, sealedKey.KeyID, sealedKey.DataKey, *stored.KeyID, *stored.EncryptedDataKey)
+		if err != nil {
+			return encrypted, rewrapped, err
+		}
+		rewrapped += int(tag.RowsAffected())
+	}
+
+	return encrypted, rewrapped, nil
+}
+
+// Encrypts every session still holding plaintext tokens. Returns the number of sessions encrypted.
+func (db *DB) EncryptPlaintextSessions(ctx context.Context) (int, error) {
+	rows, err := db.connPool.Query(ctx,
+		`SELECT github_user_id, access_token, refresh_token, key_id, encrypted_data_key
+        FROM sessions
+        WHERE key_id IS NULL OR encrypted_data_key IS NULL`)
+	if err != nil {
+		return 0, err
+	}
+	sessions, err := pgx.CollectRows(rows, pgx.RowToStructByName[storedSessionKey])
+	if err != nil {
+		return 0, err
+	}
+
+	encrypted := 0
+	for _, stored := range sessions {
+		count, err := db.encryptPlaintextSession(ctx, db.connPool, stored)
+		if err != nil {
+			return encrypted, err
+		}
+		encrypted += count
+	}
+
+	return encrypted, nil
+}
+
+// Encrypts the tokens of a session stored in plaintext, unless the session was written since it was read
+func (db *DB) encryptPlaintextSession(ctx context.Context, q querier, stored storedSessionKey) (int, error) {
+	accessToken, refreshToken, sealedKey, err := db.sealSessionTokens(stored.GitHubUserID, stored.AccessToken, stored.RefreshToken)
+	if err != nil {
+		return 0, fmt.Errorf("error encrypting session %d: %w", stored.GitHubUserID, err)
+	}
+
+	tag, err := q.Exec(ctx,
+		`UPDATE sessions
+        SET access_token = $2, refresh_token = $3, key_id = $4, encrypted_data_key = $5
+        WHERE github_user_id = $1 AND key_id IS NULL AND access_token = $6 AND refresh_token IS NOT DISTINCT FROM $7`,
+		stored.GitHubUserID, accessToken, refreshToken, sealedKey.KeyID, sealedKey.DataKey, stored.AccessToken, stored.RefreshToken)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// Creates a starter code update along with a pending pull request for every student work on the assignment
+func (db *DB) CreateStarterCodeSync(ctx context.Context, syncData models.StarterCodeSync) (models.StarterCodeSyncWithPRs, error) {
+	var sync models.StarterCodeSyncWithPRs
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			INSERT INTO starter_code_syncs (assignment_outline_id, base_commit_sha, head_commit_sha, title, created_by_user_id)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING *`,
+			syncData.AssignmentOutlineID,
+			syncData.BaseCommitSHA,
+			syncData.HeadCommitSHA,
+			syncData.Title,
+			syncData.CreatedByUserID,
+		)
+		if err != nil {
+			return err
+		}
+		sync.StarterCodeSync, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[models.StarterCodeSync])
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO starter_code_sync_prs (job_id, sync_id, student_work_id, repo_name)
+			SELECT create_job($3), $1, id, repo_name FROM student_works WHERE assignment_outline_id = $2`,
+			sync.ID, syncData.AssignmentOutlineID, models.JobKindStarterCodePR)
+		if err != nil {
+			return err
+		}
+
+		sync.PullRequests, err = getStarterCodeSyncPRs(ctx, tx, sync.ID)
+		return err
+	})
+	if err != nil {
+		return models.StarterCodeSyncWithPRs{}, errs.NewDBError(err)
+	}
+
+	return sync, nil
+}
+
+func (db *DB) GetStarterCodeSyncByID(ctx context.Context, syncID int) (models.StarterCodeSync, error) {
+	rows, err := db.connPool.Query(ctx, `SELECT * FROM starter_code_syncs WHERE id = $1`, syncID)
+	if err != nil {
+		return models.StarterCodeSync{}, errs.NewDBError(err)
+	}
+
+	sync, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.StarterCodeSync])
+	if err != nil {
+		return models.StarterCodeSync{}, errs.NewDBError(err)
+	}
+
+	return sync, nil
+}
+
+// Gets the starter code updates of an assignment with the status of each fork's pull request, most recent first
+func (db *DB) GetStarterCodeSyncsByAssignment(ctx context.Context, assignmentID int64) ([]models.StarterCodeSyncWithPRs, error) {
+	rows, err := db.connPool.Query(ctx, `
+		SELECT * FROM starter_code_syncs
+		WHERE assignment_outline_id = $1
+		ORDER BY created_at DESC`, assignmentID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	syncs, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.StarterCodeSync])
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	syncsWithPRs := []models.StarterCodeSyncWithPRs{}
+	for _, sync := range syncs {
+		pullRequests, err := getStarterCodeSyncPRs(ctx, db.connPool, sync.ID)
+		if err != nil {
+			return nil, errs.NewDBError(err)
+		}
+
+		syncsWithPRs = append(syncsWithPRs, models.StarterCodeSyncWithPRs{StarterCodeSync: sync, PullRequests: pullRequests})
+	}
+
+	return syncsWithPRs, nil
+}
+
+func getStarterCodeSyncPRs(ctx context.Context, q querier, syncID int) ([]models.StarterCodeSyncPR, error) {
+	rows, err := q.Query(ctx, `
+		SELECT pr.*, `+jobColumns+`
+		FROM starter_code_sync_prs pr
+		JOIN jobs j ON j.id = pr.job_id
+		WHERE pr.sync_id = $1
+		ORDER BY pr.repo_name`, syncID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.StarterCodeSyncPR])
+}
+
+// Gets the starter code pull requests still to be opened, oldest first
+func (db *DB) GetPendingStarterCodeSyncPRs(ctx context.Context, limit int) ([]models.StarterCodeSyncPR, error) {
+	return getPendingJobs[models.StarterCodeSyncPR](ctx, db, `q.*`, `starter_code_sync_prs q`, limit)
+}
+
+// Records the outcome of an attempt to open a starter code pull request
+func (db *DB) CompleteStarterCodeSyncPR(ctx context.Context, pullRequest models.StarterCodeSyncPR) error {
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			UPDATE starter_code_sync_prs
+			SET pr_number = $1, pr_url = $2, pr_state = $3
+			WHERE id = $4`,
+			pullRequest.PRNumber,
+			pullRequest.PRURL,
+			pullRequest.PRState,
+			pullRequest.ID,
+		)
+		if err != nil {
+			return err
+		}
+		return completeJob(ctx, tx, pullRequest.Job)
+	})
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+// Updates the state of an open starter code pull request once it is merged or closed
+func (db *DB) UpdateStarterCodeSyncPRState(ctx context.Context, repoName string, prNumber int, state models.StarterCodePRState) error {
+	_, err := db.connPool.Exec(ctx, `
+		UPDATE starter_code_sync_prs
+		SET pr_state = $1
+		WHERE repo_name = $2 AND pr_number = $3 AND pr_state = $4`,
+		state, repoName, prNumber, models.StarterCodePROpen)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
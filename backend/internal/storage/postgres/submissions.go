@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+func (db *DB) CreateSubmission(ctx context.Context, submission models.Submission) (models.Submission, error) {
+	rows, err := db.connPool.Query(ctx, `
+		INSERT INTO submissions (student_work_id, commit_sha, pushed_by, submitted_at, due_date, on_time)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING *`,
+		submission.StudentWorkID,
+		submission.CommitSHA,
+		submission.PushedBy,
+		submission.SubmittedAt,
+		submission.DueDate,
+		submission.OnTime,
+	)
+	if err != nil {
+		return models.Submission{}, errs.NewDBError(err)
+	}
+
+	created, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Submission])
+	if err != nil {
+		return models.Submission{}, errs.NewDBError(err)
+	}
+
+	return created, nil
+}
+
+// Records the tag created for a submission
+func (db *DB) SetSubmissionTag(ctx context.Context, submissionID int, tagName string) error {
+	_, err := db.connPool.Exec(ctx, `UPDATE submissions SET tag_name = $1 WHERE id = $2`, tagName, submissionID)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+// Gets the submissions of a work, most recent first
+func (db *DB) GetSubmissionsByWork(ctx context.Context, studentWorkID int) ([]models.Submission, error) {
+	rows, err := db.connPool.Query(ctx, `
+		SELECT * FROM submissions
+		WHERE student_work_id = $1
+		ORDER BY submitted_at DESC, id DESC`, studentWorkID)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.Submission])
+}
+
+// Gets the submission graders picked for a work
+func (db *DB) GetSelectedSubmission(ctx context.Context, studentWorkID int) (models.Submission, error) {
+	rows, err := db.connPool.Query(ctx, `
+		SELECT * FROM submissions WHERE student_work_id = $1 AND selected_for_grading`, studentWorkID)
+	if err != nil {
+		return models.Submission{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Submission])
+}
+
+// Makes a submission the one graded for its work, replacing any earlier pick
+func (db *DB) SelectSubmissionForGrading(ctx context.Context, studentWorkID int, submissionID int) (models.Submission, error) {
+	var selected models.Submission
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			UPDATE submissions SET selected_for_grading = FALSE
+			WHERE student_work_id = $1 AND selected_for_grading`, studentWorkID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, `
+			UPDATE submissions SET selected_for_grading = TRUE
+			WHERE id = $1 AND student_work_id = $2
+			RETURNING *`, submissionID, studentWorkID)
+		if err != nil {
+			return err
+		}
+		selected, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Submission])
+		return err
+	})
+	if err != nil {
+		return models.Submission{}, err
+	}
+
+	return selected, nil
+}
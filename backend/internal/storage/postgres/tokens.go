@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CamPlume1/khoury-classroom/internal/errs"
+	"github.com/CamPlume1/khoury-classroom/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// Condition on a token table for tokens that are not revoked, expired as of $2, or used up
+const activeTokenCondition = `NOT revoked AND (expires_at IS NULL OR expires_at > $2) AND (max_uses IS NULL OR use_count < max_uses)`
+
+// Condition on a token table for tokens without an expiry or limits, which are reused for permanent links
+const permanentTokenCondition = `expires_at IS NULL AND NOT revoked AND max_uses IS NULL
+	AND COALESCE(cardinality(allowed_usernames), 0) = 0 AND COALESCE(cardinality(allowed_email_domains), 0) = 0`
+
+// Records a use of a token, counting it towards the token's max uses unless the user has used the token before.
+// The token's row must already be locked by the transaction.
+func redeemToken(ctx context.Context, tx pgx.Tx, tokenTable, redemptionTable string, token *models.BaseToken, userID int64) error {
+	err := token.Usable(time.Now())
+	if err != nil {
+		return err
+	}
+
+	var usedBefore bool
+	err = tx.QueryRow(ctx, fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE token = $1 AND user_id = $2)`, redemptionTable),
+		token.Token, userID).Scan(&usedBefore)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	if !usedBefore {
+		if token.UsedUp() {
+			return errs.TokenUsedUpError()
+		}
+		_, err = tx.Exec(ctx, fmt.Sprintf(`UPDATE %s SET use_count = use_count + 1 WHERE token = $1`, tokenTable), token.Token)
+		if err != nil {
+			return errs.NewDBError(err)
+		}
+		token.UseCount++
+	}
+
+	_, err = tx.Exec(ctx, fmt.Sprintf(`INSERT INTO %s (token, user_id) VALUES ($1, $2)`, redemptionTable), token.Token, userID)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
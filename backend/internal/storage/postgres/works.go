@@ -26,6 +26,9 @@ const DesiredFields = `
 	sw.commit_amount,
 	sw.first_commit_date,
 	sw.last_commit_date,
+	sw.locked_at,
+	sw.locked_commit_sha,
+	sw.lock_exempt,
 	u.first_name,
 	u.last_name,
 	u.github_username
@@ -69,14 +72,14 @@ func formatWorks[T models.IStudentWork, F models.IFormattedStudentWork](rawWorks
 }
 
 // Get all student works from an assignment
-func (db *DB) GetWorks(ctx context.Context, classroomID int, assignmentID int) ([]*models.StudentWorkWithContributors, error) {
+func (db *DB) GetWorks(ctx context.Context, classroomID int, assignmentID int, sectionID *int64) ([]*models.StudentWorkWithContributors, error) {
 	query := fmt.Sprintf(`
 SELECT %s FROM %s
-WHERE classroom_id = $1 AND assignment_outline_id = $2
+WHERE classroom_id = $1 AND assignment_outline_id = $2 AND %s
 ORDER BY u.last_name, u.first_name;
-`, DesiredFields, JoinedTable)
+`, DesiredFields, JoinedTable, sectionWorkFilter("$3", "sw.id"))
 
-	rows, err := db.connPool.Query(ctx, query, classroomID, assignmentID)
+	rows, err := db.connPool.Query(ctx, query, classroomID, assignmentID, sectionID)
 
 	if err != nil {
 		fmt.Println("Error in query ", err)
@@ -221,19 +224,15 @@ func (db *DB) UpdateStudentWork(ctx context.Context, studentWork models.StudentW
 		SET assignment_outline_id = $1,
 			repo_name = $2,
 			unique_due_date = $3,
-			manual_feedback_score = $4,
-			auto_grader_score = $5,
-			grades_published_timestamp = $6,
-			work_state = $7,
-			commit_amount = $8,
-			first_commit_date = $9,
-			last_commit_date = $10
-		WHERE id = $11
+			grades_published_timestamp = $4,
+			work_state = $5,
+			commit_amount = $6,
+			first_commit_date = $7,
+			last_commit_date = $8
+		WHERE id = $9
 	`, studentWork.AssignmentOutlineID,
 		studentWork.RepoName,
 		studentWork.UniqueDueDate,
-		studentWork.ManualFeedbackScore,
-		studentWork.AutoGraderScore,
 		studentWork.GradesPublishedTimestamp,
 		studentWork.WorkState,
 		studentWork.CommitAmount,
@@ -248,3 +247,282 @@ func (db *DB) UpdateStudentWork(ctx context.Context, studentWork models.StudentW
 
 	return studentWork, nil
 }
+
+// Get the student work a user contributes to on an assignment
+func (db *DB) GetUserWorkByAssignment(ctx context.Context, assignmentID int64, userID int64) (models.StudentWork, error) {
+	query := fmt.Sprintf(`
+SELECT %s FROM %s
+WHERE sw.assignment_outline_id = $1
+	AND sw.id IN (SELECT student_work_id FROM work_contributors WHERE user_id = $2)
+`, DesiredFields, JoinedTable)
+
+	rows, err := db.connPool.Query(ctx, query, assignmentID, userID)
+	if err != nil {
+		return models.StudentWork{}, err
+	}
+
+	defer rows.Close()
+
+	work, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RawStudentWork])
+	if err != nil {
+		return models.StudentWork{}, err
+	}
+
+	return work.StudentWork, nil
+}
+
+// Whether a repository name is already used by a student work or base repository
+func (db *DB) IsRepoNameTaken(ctx context.Context, repoName string) (bool, error) {
+	var taken bool
+	err := db.connPool.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM student_works WHERE repo_name = $1)
+			OR EXISTS (SELECT 1 FROM assignment_base_repos WHERE base_repo_name = $1)
+			OR EXISTS (SELECT 1 FROM classroom_clone_assignments WHERE base_repo_name = $1)`, repoName).Scan(&taken)
+	if err != nil {
+		return false, errs.NewDBError(err)
+	}
+
+	return taken, nil
+}
+
+// Get all student works a user contributes to, across the classrooms they have not been removed from
+func (db *DB) GetWorksByUserID(ctx context.Context, userID int64) ([]*models.StudentWorkWithContributors, error) {
+	query := fmt.Sprintf(`
+SELECT %s FROM %s
+WHERE sw.id IN (SELECT student_work_id FROM work_contributors WHERE user_id = $1)
+	AND EXISTS (SELECT 1 FROM classroom_membership cm
+		WHERE cm.classroom_id = ao.classroom_id AND cm.user_id = $1 AND cm.status != $2)
+ORDER BY sw.unique_due_date, sw.id;
+`, DesiredFields, JoinedTable)
+
+	rows, err := db.connPool.Query(ctx, query, userID, models.UserStatusRemoved)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	rawWorks, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.RawStudentWork])
+	if err != nil {
+		return nil, err
+	}
+
+	return formatWorks(rawWorks, func(work models.RawStudentWork) *models.StudentWorkWithContributors {
+		return &models.StudentWorkWithContributors{StudentWork: work.StudentWork, Contributors: []models.IWorkContributor{}}
+	}), nil
+}
+
+// Get a single student work by ID, regardless of classroom or assignment
+func (db *DB) GetWorkByID(ctx context.Context, studentWorkID int) (*models.StudentWorkWithContributors, error) {
+	query := fmt.Sprintf(`
+SELECT %s FROM %s
+WHERE sw.id = $1
+ORDER BY u.last_name, u.first_name;
+`, DesiredFields, JoinedTable)
+
+	rows, err := db.connPool.Query(ctx, query, studentWorkID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	rawWorks, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.RawStudentWork])
+	if err != nil {
+		return nil, err
+	}
+
+	formatted := formatWorks(rawWorks, func(work models.RawStudentWork) *models.StudentWorkWithContributors {
+		return &models.StudentWorkWithContributors{StudentWork: work.StudentWork, Contributors: []models.IWorkContributor{}}
+	})
+
+	if len(formatted) == 0 {
+		return nil, errs.EmptyResult()
+	}
+
+	return formatted[0], nil
+}
+
+// Check whether a user is a contributor on a student work
+func (db *DB) IsWorkContributor(ctx context.Context, studentWorkID int, userID int64) (bool, error) {
+	var exists bool
+	err := db.connPool.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM work_contributors WHERE student_work_id = $1 AND user_id = $2)`,
+		studentWorkID, userID,
+	).Scan(&exists)
+	if err != nil {
+		return false, errs.NewDBError(err)
+	}
+
+	return exists, nil
+}
+
+// Mark a student work's grades as published
+func (db *DB) PublishWorkGrades(ctx context.Context, studentWorkID int, publisherUserID int64) (time.Time, error) {
+	var publishedAt time.Time
+	err := pgx.BeginFunc(ctx, db.connPool, func(tx pgx.Tx) error {
+		score, err := getWorkScore(ctx, tx, studentWorkID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(ctx, `
+			UPDATE student_works
+			SET work_state = $1, grades_published_timestamp = (NOW() AT TIME ZONE 'UTC')
+			WHERE id = $2
+			RETURNING grades_published_timestamp
+		`, models.WorkStateGradePublished, studentWorkID).Scan(&publishedAt)
+		if err != nil {
+			return err
+		}
+
+		_, err = createGradeEvent(ctx, tx, models.GradeEvent{
+			StudentWorkID: studentWorkID,
+			ActorUserID:   publisherUserID,
+			EventType:     models.GradeEventGradesPublished,
+			BeforeScore:   score,
+		})
+		return err
+	})
+	if err != nil {
+		return time.Time{}, errs.NewDBError(err)
+	}
+
+	return publishedAt, nil
+}
+
+// Add a user as a contributor on a student work, doing nothing if they already are one
+func (db *DB) AddWorkContributor(ctx context.Context, studentWorkID int, userID int64) error {
+	_, err := db.connPool.Exec(ctx,
+		`INSERT INTO work_contributors (user_id, student_work_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		userID, studentWorkID,
+	)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+// Remove a user from the contributors of a student work
+func (db *DB) RemoveWorkContributor(ctx context.Context, studentWorkID int, userID int64) error {
+	_, err := db.connPool.Exec(ctx,
+		`DELETE FROM work_contributors WHERE user_id = $1 AND student_work_id = $2`,
+		userID, studentWorkID,
+	)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+// Get the unlocked works whose due date, including any extension, has passed
+func (db *DB) GetWorksToLock(ctx context.Context, now time.Time, limit int) ([]models.LockableWork, error) {
+	rows, err := db.connPool.Query(ctx, `
+		SELECT sw.id AS student_work_id, c.org_name, sw.repo_name, ao.submission_branch,
+			COALESCE(sw.unique_due_date, ao.main_due_date) AS due_date,
+			ARRAY(SELECT u.github_username FROM work_contributors wc JOIN users u ON u.id = wc.user_id
+				WHERE wc.student_work_id = sw.id) AS contributors
+		FROM student_works sw
+		JOIN assignment_outlines ao ON ao.id = sw.assignment_outline_id
+		JOIN classrooms c ON c.id = ao.classroom_id
+		WHERE sw.locked_at IS NULL AND NOT sw.lock_exempt AND NOT sw.predates_locking AND sw.lock_attempts < $1
+			AND ao.archived_at IS NULL
+			AND COALESCE(sw.unique_due_date, ao.main_due_date) <= $2
+		ORDER BY due_date, sw.id
+		LIMIT $3`, models.MaxWorkLockAttempts, now, limit)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.LockableWork])
+}
+
+// Records that a work's repository was locked at the given commit
+func (db *DB) LockStudentWork(ctx context.Context, studentWorkID int, commitSHA string) error {
+	_, err := db.connPool.Exec(ctx, `
+		UPDATE student_works
+		SET locked_at = (NOW() AT TIME ZONE 'UTC'), locked_commit_sha = $1, lock_error = NULL
+		WHERE id = $2`, commitSHA, studentWorkID)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+// Records a failed attempt at locking a work's repository
+func (db *DB) RecordWorkLockFailure(ctx context.Context, studentWorkID int, errorMessage string) error {
+	_, err := db.connPool.Exec(ctx, `
+		UPDATE student_works SET lock_attempts = lock_attempts + 1, lock_error = $1 WHERE id = $2`,
+		errorMessage, studentWorkID)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+// Unlocks a work. With a new due date the work is locked again once it passes, otherwise it stays unlocked.
+func (db *DB) UnlockStudentWork(ctx context.Context, studentWorkID int, dueDate *time.Time) error {
+	_, err := db.connPool.Exec(ctx, `
+		UPDATE student_works
+		SET locked_at = NULL,
+			lock_exempt = $1::timestamp IS NULL,
+			predates_locking = predates_locking AND $1::timestamp IS NULL,
+			unique_due_date = COALESCE($1, unique_due_date),
+			lock_attempts = 0,
+			lock_error = NULL
+		WHERE id = $2`, dueDate, studentWorkID)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+// Gets the locked works whose due date was moved back into the future, e.g. by an extension
+func (db *DB) GetWorksToReopen(ctx context.Context, now time.Time, limit int) ([]models.LockableWork, error) {
+	rows, err := db.connPool.Query(ctx, `
+		SELECT sw.id AS student_work_id, c.org_name, sw.repo_name, ao.submission_branch,
+			COALESCE(sw.unique_due_date, ao.main_due_date) AS due_date,
+			ARRAY(SELECT u.github_username FROM work_contributors wc JOIN users u ON u.id = wc.user_id
+				WHERE wc.student_work_id = sw.id) AS contributors
+		FROM student_works sw
+		JOIN assignment_outlines ao ON ao.id = sw.assignment_outline_id
+		JOIN classrooms c ON c.id = ao.classroom_id
+		WHERE sw.locked_at IS NOT NULL AND ao.archived_at IS NULL
+			AND COALESCE(sw.unique_due_date, ao.main_due_date) > $1
+		ORDER BY due_date, sw.id
+		LIMIT $2`, now, limit)
+	if err != nil {
+		return nil, errs.NewDBError(err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.LockableWork])
+}
+
+// Records that a locked work's repository was reopened for its new due date, to be locked again once it passes
+func (db *DB) ReopenStudentWork(ctx context.Context, studentWorkID int) error {
+	_, err := db.connPool.Exec(ctx, `
+		UPDATE student_works
+		SET locked_at = NULL, lock_attempts = 0, lock_error = NULL
+		WHERE id = $1`, studentWorkID)
+	if err != nil {
+		return errs.NewDBError(err)
+	}
+
+	return nil
+}
+
+// Lets the scheduler lock the works that were already past due when locking was introduced. Returns how many works
+// it applies to.
+func (db *DB) BackfillWorkLocks(ctx context.Context) (int64, error) {
+	tag, err := db.connPool.Exec(ctx, `UPDATE student_works SET predates_locking = FALSE WHERE predates_locking`)
+	if err != nil {
+		return 0, errs.NewDBError(err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -10,12 +10,25 @@ import (
 type Storage interface {
 	Close(context.Context)
 	FeedbackComment
+	GradeEvent
+	Regrade
 	Works
 	Test
 	Session
 	Classroom
+	Section
+	JoinRequest
+	AuditLog
 	User
 	AssignmentOutline
+	AssignmentGroup
+	Job
+	DeadlineUpdate
+	StarterCodeSync
+	RepoProvision
+	ClassroomClone
+	RosterImport
+	Submission
 	Rubric
 	AssignmentTemplate
 	AssignmentBaseRepo
@@ -23,17 +36,42 @@ type Storage interface {
 
 type FeedbackComment interface {
 	GetFeedbackOnWork(ctx context.Context, studentWorkID int) ([]models.PRReviewCommentResponse, error)
-	CreateFeedbackComment(ctx context.Context, TAUserID int64, studentWorkID int, comment models.PRReviewCommentResponse) error
-	CreateFeedbackCommentFromRubricItem(ctx context.Context, TAUserID int64, studentWorkID int, comment models.PRReviewCommentResponse) error
+	ApplyFeedbackChanges(ctx context.Context, TAUserID int64, studentWorkID int, comments []models.PRReviewCommentResponse) ([]models.GradeEvent, error)
+	SetFeedbackGitHubComment(ctx context.Context, feedbackCommentID int, gitHubCommentID *int64) error
+}
+
+type GradeEvent interface {
+	GetGradeEventsByWork(ctx context.Context, studentWorkID int) ([]models.GradeEvent, error)
+}
+
+type Regrade interface {
+	CreateRegradeRequest(ctx context.Context, studentWorkID int, feedbackCommentID int, requestedBy int64, comment string) (models.RegradeRequest, error)
+	GetRegradeRequestsByWork(ctx context.Context, studentWorkID int) ([]models.RegradeRequest, error)
+	ResolveRegradeRequest(ctx context.Context, studentWorkID int, regradeID int, resolverUserID int64, resolution models.RegradeResolutionBody) (models.RegradeRequest, models.GradeEvent, error)
 }
 
 type Works interface {
-	GetWorks(ctx context.Context, classroomID int, assignmentID int) ([]*models.StudentWorkWithContributors, error)
+	GetWorks(ctx context.Context, classroomID int, assignmentID int, sectionID *int64) ([]*models.StudentWorkWithContributors, error)
 	GetWork(ctx context.Context, classroomID int, assignmentID int, studentWorkID int) (*models.PaginatedStudentWorkWithContributors, error)
 	CreateStudentWork(ctx context.Context, assignmentOutlineID int32, gitHubUserID int64, repoName string, workState models.WorkState, dueDate *time.Time) (models.StudentWork, error)
 
 	UpdateStudentWork(ctx context.Context, UpdateStudentWork models.StudentWork) (models.StudentWork, error)
 	GetWorkByRepoName(ctx context.Context, repoName string) (models.StudentWork, error)
+	GetUserWorkByAssignment(ctx context.Context, assignmentID int64, userID int64) (models.StudentWork, error)
+	IsRepoNameTaken(ctx context.Context, repoName string) (bool, error)
+	GetWorksToLock(ctx context.Context, now time.Time, limit int) ([]models.LockableWork, error)
+	LockStudentWork(ctx context.Context, studentWorkID int, commitSHA string) error
+	RecordWorkLockFailure(ctx context.Context, studentWorkID int, errorMessage string) error
+	UnlockStudentWork(ctx context.Context, studentWorkID int, dueDate *time.Time) error
+	GetWorksToReopen(ctx context.Context, now time.Time, limit int) ([]models.LockableWork, error)
+	ReopenStudentWork(ctx context.Context, studentWorkID int) error
+	BackfillWorkLocks(ctx context.Context) (int64, error)
+	GetWorksByUserID(ctx context.Context, userID int64) ([]*models.StudentWorkWithContributors, error)
+	GetWorkByID(ctx context.Context, studentWorkID int) (*models.StudentWorkWithContributors, error)
+	IsWorkContributor(ctx context.Context, studentWorkID int, userID int64) (bool, error)
+	PublishWorkGrades(ctx context.Context, studentWorkID int, publisherUserID int64) (time.Time, error)
+	AddWorkContributor(ctx context.Context, studentWorkID int, userID int64) error
+	RemoveWorkContributor(ctx context.Context, studentWorkID int, userID int64) error
 }
 
 type Test interface {
@@ -43,7 +81,11 @@ type Test interface {
 type Session interface {
 	CreateSession(ctx context.Context, sessionData models.Session) error
 	GetSession(ctx context.Context, gitHubUserID int64) (models.Session, error)
+	RefreshSession(ctx context.Context, gitHubUserID int64, refresh func(models.Session) (models.Session, error)) (models.Session, error)
+	IsSessionActive(ctx context.Context, gitHubUserID int64) (bool, error)
 	DeleteSession(ctx context.Context, gitHubUserID int64) error
+	RotateSessionKeys(ctx context.Context) (int, int, error)
+	EncryptPlaintextSessions(ctx context.Context) (int, error)
 }
 
 type Classroom interface {
@@ -51,18 +93,51 @@ type Classroom interface {
 	UpdateClassroom(ctx context.Context, classroomData models.Classroom) (models.Classroom, error)
 	GetClassroomByID(ctx context.Context, classroomID int64) (models.Classroom, error)
 	GetClassroomByName(ctx context.Context, classroomName string) (models.Classroom, error)
+	SetClassroomStaffTeam(ctx context.Context, classroomID int64, staffTeamName string) error
 	AddUserToClassroom(ctx context.Context, classroomID int64, classroomRole string, classroomStatus models.UserStatus, userID int64) (models.ClassroomUser, error)
 	RemoveUserFromClassroom(ctx context.Context, classroomID int64, userID int64) error
 	ModifyUserRole(ctx context.Context, classroomID int64, classroomRole string, userID int64) (models.ClassroomUser, error)
 	ModifyUserStatus(ctx context.Context, classroomID int64, status models.UserStatus, userID int64) (models.ClassroomUser, error)
+	MarkOnClassroomRoster(ctx context.Context, classroomID int64, userID int64, externalID *string) error
 	GetUsersInClassroom(ctx context.Context, classroomID int64) ([]models.ClassroomUser, error)
 	GetUserInClassroom(ctx context.Context, classroomID int64, userID int64) (models.ClassroomUser, error)
+	GetClassrooms(ctx context.Context) ([]models.Classroom, error)
 	GetClassroomsInOrg(ctx context.Context, orgID int64) ([]models.Classroom, error)
 	GetUserClassroomsInOrg(ctx context.Context, orgID int64, userID int64) ([]models.ClassroomUser, error)
 	CreateClassroomToken(ctx context.Context, tokenData models.ClassroomToken) (models.ClassroomToken, error)
 	GetClassroomToken(ctx context.Context, token string) (models.ClassroomToken, error)
 	GetPermanentClassroomTokenByClassroomIDAndRole(ctx context.Context, classroomID int64, classroomRole models.ClassroomRole) (models.ClassroomToken, error)
-	GetNumberOfStudentsInClassroom(ctx context.Context, classroomID int64) (int, error)
+	GetActiveClassroomTokens(ctx context.Context, classroomID int64) ([]models.ClassroomToken, error)
+	RevokeClassroomToken(ctx context.Context, classroomID int64, token string) (models.ClassroomToken, error)
+	RedeemClassroomToken(ctx context.Context, token string, userID int64) (models.ClassroomToken, error)
+	GetNumberOfStudentsInClassroom(ctx context.Context, classroomID int64, sectionID *int64) (int, error)
+}
+
+type Section interface {
+	CreateSection(ctx context.Context, section models.Section) (models.Section, error)
+	SetSectionTeam(ctx context.Context, sectionID int64, teamID int64, teamName string) (models.Section, error)
+	GetSectionsInClassroom(ctx context.Context, classroomID int64) ([]models.Section, error)
+	GetSectionByID(ctx context.Context, sectionID int64) (models.Section, error)
+	GetUserSectionsInClassroom(ctx context.Context, classroomID int64, userID int64) ([]models.Section, error)
+	DeleteSection(ctx context.Context, sectionID int64) error
+	GetSectionMembers(ctx context.Context, sectionID int64) ([]models.SectionMember, error)
+	AddSectionMember(ctx context.Context, sectionID int64, userID int64) error
+	RemoveSectionMember(ctx context.Context, sectionID int64, userID int64) error
+}
+
+type JoinRequest interface {
+	GetJoinSettings(ctx context.Context, classroomID int64) (models.JoinSettings, error)
+	UpdateJoinSettings(ctx context.Context, settings models.JoinSettings) (models.JoinSettings, error)
+	IsOnClassroomRoster(ctx context.Context, classroomID int64, userID int64) (bool, error)
+	GetJoinRequests(ctx context.Context, classroomID int64, filter models.JoinRequestFilter) ([]models.JoinRequest, error)
+	QueueJoinApprovals(ctx context.Context, classroomID int64, userIDs []int64, approvedBy int64) ([]models.JoinApproval, error)
+	GetJoinApprovals(ctx context.Context, classroomID int64) ([]models.JoinApproval, error)
+	GetPendingJoinApprovals(ctx context.Context, limit int) ([]models.JoinApproval, error)
+}
+
+type AuditLog interface {
+	CreateAuditLogEntry(ctx context.Context, entry models.AuditLogEntry) (models.AuditLogEntry, error)
+	GetClassroomAuditLog(ctx context.Context, classroomID int64) ([]models.AuditLogEntry, error)
 }
 
 type User interface {
@@ -78,13 +153,93 @@ type AssignmentOutline interface {
 	GetAssignmentByNameAndClassroomID(ctx context.Context, assignmentName string, classroom int64) (*models.AssignmentOutline, error)
 	CreateAssignment(ctx context.Context, assignmentData models.AssignmentOutline) (models.AssignmentOutline, error)
 	UpdateAssignmentRubric(ctx context.Context, rubricID int64, assignmentID int64) (models.AssignmentOutline, error)
-	CountWorksByState(ctx context.Context, assignmentID int) (map[models.WorkState]int, error)
-	GetEarliestCommitDate(ctx context.Context, assignmentID int) (*time.Time, error)
-	GetTotalWorkCommits(ctx context.Context, assignmentID int) (int, error)
+	CountWorksByState(ctx context.Context, assignmentID int, sectionID *int64) (map[models.WorkState]int, error)
+	GetEarliestCommitDate(ctx context.Context, assignmentID int, sectionID *int64) (*time.Time, error)
+	GetTotalWorkCommits(ctx context.Context, assignmentID int, sectionID *int64) (int, error)
 	GetAssignmentByToken(ctx context.Context, token string) (models.AssignmentOutline, error)
 	CreateAssignmentToken(ctx context.Context, tokenData models.AssignmentToken) (models.AssignmentToken, error)
+	GetAssignmentToken(ctx context.Context, token string) (models.AssignmentToken, error)
 	GetAssignmentByRepoName(ctx context.Context, repoName string) (*models.AssignmentOutline, error)
 	GetPermanentAssignmentTokenByAssignmentID(ctx context.Context, assignmentID int64) (models.AssignmentToken, error)
+	GetActiveAssignmentTokens(ctx context.Context, assignmentID int64) ([]models.AssignmentToken, error)
+	RevokeAssignmentToken(ctx context.Context, assignmentID int64, token string) (models.AssignmentToken, error)
+	RedeemAssignmentToken(ctx context.Context, token string, userID int64) (models.AssignmentToken, error)
+	GetAssignmentsToRelease(ctx context.Context) ([]models.AssignmentOutline, error)
+	MarkAssignmentReleased(ctx context.Context, assignmentID int64) error
+	UpdateAssignment(ctx context.Context, assignmentData models.AssignmentOutline) (models.AssignmentOutline, error)
+	ArchiveAssignment(ctx context.Context, assignmentID int64) (models.AssignmentOutline, error)
+	AssignmentHasStudentActivity(ctx context.Context, assignmentID int64) (bool, error)
+	GetAssignmentWorkRepoNames(ctx context.Context, assignmentID int64) ([]string, error)
+	DeleteAssignment(ctx context.Context, assignmentID int64) error
+}
+
+type AssignmentGroup interface {
+	CreateAssignmentGroup(ctx context.Context, groupData models.AssignmentGroup, memberIDs []int64) (models.AssignmentGroup, error)
+	GetAssignmentGroupByID(ctx context.Context, groupID int64) (models.AssignmentGroup, error)
+	GetAssignmentGroupByJoinCode(ctx context.Context, assignmentID int64, joinCode string) (models.AssignmentGroup, error)
+	GetUserAssignmentGroup(ctx context.Context, assignmentID int64, userID int64) (models.AssignmentGroup, error)
+	GetAssignmentGroups(ctx context.Context, assignmentID int64) ([]models.AssignmentGroupWithMembers, error)
+	GetAssignmentGroupMembers(ctx context.Context, groupID int64) ([]models.User, error)
+	AddAssignmentGroupMember(ctx context.Context, group models.AssignmentGroup, userID int64, maxGroupSize *int) error
+	ClaimAssignmentGroupRepoName(ctx context.Context, groupID int64, repoName string) (string, error)
+	RemoveAssignmentGroupMember(ctx context.Context, groupID int64, userID int64) error
+	SetAssignmentGroupWork(ctx context.Context, groupID int64, studentWorkID int) error
+	DeleteAssignmentGroup(ctx context.Context, groupID int64) error
+}
+
+type Job interface {
+	CompleteJob(ctx context.Context, job models.Job) error
+	TryLockJob(ctx context.Context, name string) (func(), bool, error)
+}
+
+type DeadlineUpdate interface {
+	GetWorkRepoNamesWithDueDate(ctx context.Context, assignmentID int64, dueDate *time.Time) ([]string, error)
+	QueueDeadlineUpdates(ctx context.Context, assignmentID int64, baseRepo models.AssignmentBaseRepo, orgName, branchName string, oldDueDate *time.Time, newDueDate time.Time, queueWorkflows bool) ([]models.DeadlineUpdate, error)
+	GetPendingDeadlineUpdates(ctx context.Context, limit int) ([]models.DeadlineUpdate, error)
+	GetDeadlineUpdatesByAssignment(ctx context.Context, assignmentID int64) ([]models.DeadlineUpdate, error)
+}
+
+type StarterCodeSync interface {
+	CreateStarterCodeSync(ctx context.Context, syncData models.StarterCodeSync) (models.StarterCodeSyncWithPRs, error)
+	GetStarterCodeSyncByID(ctx context.Context, syncID int) (models.StarterCodeSync, error)
+	GetStarterCodeSyncsByAssignment(ctx context.Context, assignmentID int64) ([]models.StarterCodeSyncWithPRs, error)
+	GetPendingStarterCodeSyncPRs(ctx context.Context, limit int) ([]models.StarterCodeSyncPR, error)
+	CompleteStarterCodeSyncPR(ctx context.Context, pullRequest models.StarterCodeSyncPR) error
+	UpdateStarterCodeSyncPRState(ctx context.Context, repoName string, prNumber int, state models.StarterCodePRState) error
+}
+
+type RepoProvision interface {
+	QueueRepoProvisions(ctx context.Context, assignmentID int64, classroomID int64) ([]models.RepoProvision, error)
+	GetPendingRepoProvisions(ctx context.Context, limit int) ([]models.RepoProvision, error)
+	CompleteRepoProvision(ctx context.Context, provision models.RepoProvision) error
+	GetRepoProvisionsByAssignment(ctx context.Context, assignmentID int64) ([]models.RepoProvision, error)
+	RetryRepoProvisions(ctx context.Context, assignmentID int64) (int64, error)
+}
+
+type ClassroomClone interface {
+	QueueClassroomClone(ctx context.Context, clone models.ClassroomClone, targetOrgID int64) (models.ClassroomClone, []models.ClassroomCloneAssignment, error)
+	GetClassroomCloneByID(ctx context.Context, cloneID int) (models.ClassroomClone, error)
+	GetClassroomClones(ctx context.Context, sourceClassroomID int64) ([]models.ClassroomClone, error)
+	GetClassroomCloneAssignments(ctx context.Context, cloneID int) ([]models.ClassroomCloneAssignment, error)
+	GetPendingClassroomCloneAssignments(ctx context.Context, limit int) ([]models.ClassroomCloneAssignment, error)
+	SetClassroomCloneBaseRepoName(ctx context.Context, cloneAssignmentID int, baseRepoName string) error
+	CompleteClassroomCloneAssignment(ctx context.Context, assignment models.ClassroomCloneAssignment) error
+}
+
+type RosterImport interface {
+	QueueRosterImport(ctx context.Context, rosterImport models.RosterImport, rows []models.RosterImportRow) (models.RosterImport, []models.RosterImportRow, error)
+	GetRosterImportByID(ctx context.Context, importID int) (models.RosterImport, error)
+	GetRosterImportRows(ctx context.Context, importID int) ([]models.RosterImportRow, error)
+	GetPendingRosterImportRows(ctx context.Context, limit int) ([]models.RosterImportRow, error)
+	CompleteRosterImportRow(ctx context.Context, row models.RosterImportRow) error
+}
+
+type Submission interface {
+	CreateSubmission(ctx context.Context, submission models.Submission) (models.Submission, error)
+	SetSubmissionTag(ctx context.Context, submissionID int, tagName string) error
+	GetSubmissionsByWork(ctx context.Context, studentWorkID int) ([]models.Submission, error)
+	GetSelectedSubmission(ctx context.Context, studentWorkID int) (models.Submission, error)
+	SelectSubmissionForGrading(ctx context.Context, studentWorkID int, submissionID int) (models.Submission, error)
 }
 
 type AssignmentTemplate interface {
@@ -97,6 +252,7 @@ type AssignmentTemplate interface {
 type AssignmentBaseRepo interface {
 	CreateBaseRepo(ctx context.Context, baseRepoData models.AssignmentBaseRepo) error
 	GetBaseRepoByID(ctx context.Context, id int64) (models.AssignmentBaseRepo, error)
+	MarkBaseRepoInitialized(ctx context.Context, id int64) error
 }
 
 type Rubric interface {
@@ -9,5 +9,5 @@ import (
 type Params struct {
 	UserCfg   config.GitHubUserClient
 	Store     storage.Storage
-	GitHubApp github.GitHubAppClient
+	GitHubApp github.GitHubAppInstallations
 }
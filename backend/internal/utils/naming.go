@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// GitHub rejects repository names longer than this
+const MaxRepoNameLength = 100
+
+// Placeholders available in repository naming templates
+const (
+	RepoNameOrg        = "{org}"
+	RepoNameClassroom  = "{classroom}"
+	RepoNameAssignment = "{assignment}"
+	// the student's GitHub login, or the group name on group assignments
+	RepoNameLogin = "{login}"
+)
+
+var (
+	repoNamePlaceholder     = regexp.MustCompile(`\{[^{}]*\}`)
+	repoNameDisallowedChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+	repoNameRepeatedHyphens = regexp.MustCompile(`-{2,}`)
+)
+
+// Checks that a naming template only uses known placeholders and includes every required one
+func ValidateRepoNameTemplate(template string, required ...string) error {
+	for _, placeholder := range repoNamePlaceholder.FindAllString(template, -1) {
+		switch placeholder {
+		case RepoNameOrg, RepoNameClassroom, RepoNameAssignment, RepoNameLogin:
+		default:
+			return fmt.Errorf("unknown placeholder %s in naming template", placeholder)
+		}
+	}
+
+	for _, placeholder := range required {
+		if !strings.Contains(template, placeholder) {
+			return fmt.Errorf("naming template must include %s", placeholder)
+		}
+	}
+
+	sample := map[string]string{RepoNameOrg: "x", RepoNameClassroom: "x", RepoNameAssignment: "x", RepoNameLogin: "x"}
+	if RenderRepoName(template, sample) == "" {
+		return fmt.Errorf("naming template %q produces an empty name", template)
+	}
+
+	return nil
+}
+
+// Fills in a naming template and sanitizes the result into a valid repository name
+func RenderRepoName(template string, values map[string]string) string {
+	name := repoNamePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		return values[placeholder]
+	})
+
+	return SanitizeRepoName(name)
+}
+
+// Lowercases a name and reduces it to the characters GitHub allows in repository names, within the length limit
+func SanitizeRepoName(name string) string {
+	name = repoNameDisallowedChars.ReplaceAllString(strings.ToLower(name), "-")
+	name = repoNameRepeatedHyphens.ReplaceAllString(name, "-")
+	name = trimRepoName(name)
+	if len(name) > MaxRepoNameLength {
+		name = trimRepoName(name[:MaxRepoNameLength])
+	}
+
+	return name
+}
+
+// Suffixes tried before giving up on finding a free repository name
+const maxRepoNameSuffix = 1000
+
+// Returns the name, or the name with the first free numeric suffix if it is already taken
+func ResolveRepoNameCollision(ctx context.Context, name string, isTaken func(ctx context.Context, name string) (bool, error)) (string, error) {
+	candidate := name
+	for suffix := 2; suffix <= maxRepoNameSuffix+1; suffix++ {
+		taken, err := isTaken(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+
+		// shorten the name rather than the suffix so the result stays within the length limit
+		suffixText := fmt.Sprintf("-%d", suffix)
+		base := name
+		if len(base)+len(suffixText) > MaxRepoNameLength {
+			base = trimRepoName(base[:MaxRepoNameLength-len(suffixText)])
+		}
+		candidate = base + suffixText
+	}
+
+	return "", fmt.Errorf("no free repository name for %s", name)
+}
+
+// Removes the separators GitHub doesn't allow at either end of a name
+func trimRepoName(name string) string {
+	return strings.Trim(name, "-.")
+}
@@ -0,0 +1,29 @@
+package utils
+
+import "strconv"
+
+// Parses an optional ID, such as a filter in a query string, returning nil if it is empty
+func ParseOptionalID(value string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// Parses an optional boolean filter in a query string, returning nil if it is empty
+func ParseOptionalBool(value string) (*bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}